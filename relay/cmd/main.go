@@ -4,12 +4,17 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/Renespeare/mailpulse/relay/internal/api"
 	"github.com/Renespeare/mailpulse/relay/internal/auth"
+	"github.com/Renespeare/mailpulse/relay/internal/bounce"
+	"github.com/Renespeare/mailpulse/relay/internal/domains"
+	"github.com/Renespeare/mailpulse/relay/internal/jobs"
 	"github.com/Renespeare/mailpulse/relay/internal/security"
 	"github.com/Renespeare/mailpulse/relay/internal/smtp"
 	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/Renespeare/mailpulse/relay/internal/webhooks"
 	"github.com/joho/godotenv"
 )
 
@@ -39,14 +44,25 @@ func main() {
 	rateLimiter := security.NewInMemoryRateLimiter()
 	log.Println("✅ Using in-memory rate limiter")
 	
-	// Initialize authentication manager with storage adapter
+	// Initialize authentication manager backed by the database with a short-lived cache
+	authCacheTTL := auth.DefaultCacheTTL
+	if ttl := os.Getenv("AUTH_CACHE_TTL"); ttl != "" {
+		if parsed, err := time.ParseDuration(ttl); err == nil {
+			authCacheTTL = parsed
+		} else {
+			log.Printf("⚠️  Invalid AUTH_CACHE_TTL %q, using %s", ttl, authCacheTTL)
+		}
+	}
 	storageAdapter := api.NewStorageAdapter(store)
-	authManager := auth.NewInMemoryAuthManager(storageAdapter)
+	authManager := auth.NewDatabaseAuthManager(storageAdapter, authCacheTTL)
 	
-	// Load existing projects from database
-	log.Println("🔍 Loading projects from database...")
-	if err := authManager.ReloadProjects(); err != nil {
-		log.Printf("⚠️  Could not load projects from database: %v", err)
+	// Evict cached projects when any replica changes them
+	projectChanges, err := store.ListenProjectChanges()
+	if err != nil {
+		log.Printf("⚠️  Could not subscribe to project changes, relying on %s cache TTL: %v", authCacheTTL, err)
+	} else {
+		go authManager.WatchInvalidations(projectChanges)
+		log.Printf("✅ Auth cache (TTL %s) subscribed to project changes", authCacheTTL)
 	}
 	
 	
@@ -61,8 +77,68 @@ func main() {
 		httpPort = "8080"
 	}
 	
+	// Initialize sender domain verification (DNS TXT challenge)
+	domainVerifier := domains.NewVerifier(store, domains.NewResolver(os.Getenv("DNS_RESOLVER")))
+	recheckInterval := 6 * time.Hour
+	if interval := os.Getenv("DOMAIN_RECHECK_INTERVAL"); interval != "" {
+		if parsed, err := time.ParseDuration(interval); err == nil && parsed > 0 {
+			recheckInterval = parsed
+		} else {
+			log.Printf("⚠️  Invalid DOMAIN_RECHECK_INTERVAL %q, using %s", interval, recheckInterval)
+		}
+	}
+	domainVerifier.Start(recheckInterval)
+	log.Printf("✅ Sender domain re-check every %s", recheckInterval)
+	
+	// Initialize webhook delivery (queued events are retried from the database)
+	webhookDispatcher := webhooks.NewDispatcher(store)
+	webhookPollInterval := 15 * time.Second
+	if interval := os.Getenv("WEBHOOK_POLL_INTERVAL"); interval != "" {
+		if parsed, err := time.ParseDuration(interval); err == nil && parsed > 0 {
+			webhookPollInterval = parsed
+		} else {
+			log.Printf("⚠️  Invalid WEBHOOK_POLL_INTERVAL %q, using %s", interval, webhookPollInterval)
+		}
+	}
+	webhookDispatcher.Start(webhookPollInterval)
+	log.Printf("✅ Webhook deliveries checked every %s", webhookPollInterval)
+	
+	// Initialize email forwarder (shared so upstream health is tracked in one place)
+	emailForwarder := smtp.NewEmailForwarder(authManager, store, webhookDispatcher)
+	
+	// Send queued emails and release scheduled ones once they are due (the queue is kept in the database)
+	queuePollInterval := 15 * time.Second
+	if interval := os.Getenv("QUEUE_POLL_INTERVAL"); interval != "" {
+		if parsed, err := time.ParseDuration(interval); err == nil && parsed > 0 {
+			queuePollInterval = parsed
+		} else {
+			log.Printf("⚠️  Invalid QUEUE_POLL_INTERVAL %q, using %s", interval, queuePollInterval)
+		}
+	}
+	emailForwarder.StartQueue(queuePollInterval)
+	log.Printf("✅ Email queue checked every %s", queuePollInterval)
+	
+	// Start the inbound bounce processor when VERP return paths are enabled
+	if bounceDomain := os.Getenv("BOUNCE_DOMAIN"); bounceDomain != "" {
+		bouncePort := os.Getenv("BOUNCE_SMTP_PORT")
+		if bouncePort == "" {
+			bouncePort = "2526"
+		}
+		bounceServer := bounce.NewServer(fmt.Sprintf(":%s", bouncePort), bounceDomain, bounce.NewProcessor(store, bounceDomain, webhookDispatcher))
+		go func() {
+			if err := bounceServer.Start(); err != nil {
+				log.Fatalf("Bounce server failed: %v", err)
+			}
+		}()
+		log.Printf("📨 Accepting bounces for %s on port %s", bounceDomain, bouncePort)
+	}
+	
+	// Bulk retry/cancel jobs (jobs left running by a stopped relay are marked failed)
+	jobRunner := jobs.NewRunner(store, emailForwarder.WakeQueue)
+	jobRunner.Start(time.Minute)
+	
 	// Initialize HTTP API server
-	apiServer := api.NewServer(authManager, store, rateLimiter)
+	apiServer := api.NewServer(authManager, store, rateLimiter, emailForwarder, domainVerifier, jobRunner)
 	
 	// Start HTTP API server in background
 	go func() {
@@ -71,9 +147,6 @@ func main() {
 		}
 	}()
 	
-	// Initialize email forwarder
-	emailForwarder := smtp.NewEmailForwarder(authManager, store)
-	
 	// Initialize SMTP server
 	smtpConfig := smtp.Config{
 		Address:     fmt.Sprintf(":%s", smtpPort),
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/auth"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// listAllowlistHandler returns the IP allowlist entries for a project
+func (s *Server) listAllowlistHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	project, err := s.storage.GetProject(projectID)
+	if err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	entries, err := s.storage.ListIPAllowlist(projectID)
+	if err != nil {
+		log.Printf("Failed to list IP allowlist for project %s: %v", projectID, err)
+		http.Error(w, "Failed to list IP allowlist", http.StatusInternalServerError)
+		return
+	}
+	
+	response := map[string]interface{}{
+		"projectId":      projectID,
+		"requireIpAllow": project.RequireIPAllow,
+		"entries":        entries,
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// addAllowlistEntryHandler adds an IPv4/IPv6 address or CIDR range to a project's allowlist
+func (s *Server) addAllowlistEntryHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	var req struct {
+		CIDR        string `json:"cidr"`
+		Description string `json:"description"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	cidr, err := auth.NormalizeCIDR(req.CIDR)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	entry := &storage.IPAllowlistEntry{
+		ID:          generateID(),
+		ProjectID:   projectID,
+		CIDR:        cidr,
+		Description: req.Description,
+		CreatedAt:   time.Now(),
+	}
+	
+	if err := s.storage.AddIPAllowlistEntry(entry); err != nil {
+		log.Printf("Failed to add IP allowlist entry for project %s: %v", projectID, err)
+		http.Error(w, "Failed to add IP allowlist entry (it may already exist)", http.StatusConflict)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_allowlist_added", &projectID, map[string]interface{}{
+		"entry_id":    entry.ID,
+		"cidr":        entry.CIDR,
+		"description": entry.Description,
+	})
+	
+	s.reloadAuthProjects()
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// deleteAllowlistEntryHandler removes an entry from a project's allowlist
+func (s *Server) deleteAllowlistEntryHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	entryID := vars["entryId"]
+	
+	if err := s.storage.DeleteIPAllowlistEntry(projectID, entryID); err != nil {
+		log.Printf("Failed to delete IP allowlist entry %s for project %s: %v", entryID, projectID, err)
+		http.Error(w, "IP allowlist entry not found", http.StatusNotFound)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_allowlist_removed", &projectID, map[string]interface{}{
+		"entry_id": entryID,
+	})
+	
+	s.reloadAuthProjects()
+	
+	response := map[string]interface{}{
+		"success": true,
+		"message": "IP allowlist entry removed",
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// reloadAuthProjects refreshes the auth manager after project settings change
+func (s *Server) reloadAuthProjects() {
+	if err := s.authManager.ReloadProjects(); err != nil {
+		log.Printf("⚠️  Failed to reload projects in auth manager: %v", err)
+	}
+}
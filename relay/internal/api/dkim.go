@@ -0,0 +1,203 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/crypto"
+	"github.com/Renespeare/mailpulse/relay/internal/dkim"
+	"github.com/Renespeare/mailpulse/relay/internal/domains"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// DKIMKeyResponse represents a DKIM key without its private part
+type DKIMKeyResponse struct {
+	ID        string
+	ProjectID string
+	Domain    string
+	Selector  string
+	Algorithm string
+	PublicKey string
+	CreatedAt time.Time
+	DNSRecord map[string]string
+}
+
+// toDKIMKeyResponse strips the private key and adds the DNS record to publish
+func toDKIMKeyResponse(key *storage.DKIMKey) *DKIMKeyResponse {
+	return &DKIMKeyResponse{
+		ID:        key.ID,
+		ProjectID: key.ProjectID,
+		Domain:    key.Domain,
+		Selector:  key.Selector,
+		Algorithm: key.Algorithm,
+		PublicKey: key.PublicKey,
+		CreatedAt: key.CreatedAt,
+		DNSRecord: map[string]string{
+			"type":  "TXT",
+			"name":  dkim.RecordName(key.Selector, key.Domain),
+			"value": dkim.RecordValue(key.Algorithm, key.PublicKey),
+		},
+	}
+}
+
+// listDKIMKeysHandler returns the DKIM keys of a project
+func (s *Server) listDKIMKeysHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	keys, err := s.storage.ListDKIMKeys(projectID)
+	if err != nil {
+		log.Printf("Failed to list DKIM keys for project %s: %v", projectID, err)
+		http.Error(w, "Failed to list DKIM keys", http.StatusInternalServerError)
+		return
+	}
+	
+	response := []*DKIMKeyResponse{}
+	for _, key := range keys {
+		response = append(response, toDKIMKeyResponse(key))
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// addDKIMKeyHandler generates a new DKIM key, or imports one when privateKey is given
+func (s *Server) addDKIMKeyHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	var req struct {
+		Domain     string `json:"domain"`
+		Selector   string `json:"selector"`
+		Algorithm  string `json:"algorithm"`
+		PrivateKey string `json:"privateKey"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	domain, err := domains.NormalizeDomain(req.Domain)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	if !dkim.ValidSelector(req.Selector) {
+		http.Error(w, "Invalid selector", http.StatusBadRequest)
+		return
+	}
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	privateKey := req.PrivateKey
+	algorithm := req.Algorithm
+	publicKey := ""
+	imported := privateKey != ""
+	
+	if imported {
+		// Import: the algorithm follows from the key type
+		signer, keyAlgorithm, err := dkim.ParsePrivateKey(privateKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if algorithm != "" && algorithm != keyAlgorithm {
+			http.Error(w, "Algorithm does not match the private key", http.StatusBadRequest)
+			return
+		}
+		algorithm = keyAlgorithm
+		if publicKey, err = dkim.PublicKey(signer); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		if algorithm == "" {
+			algorithm = dkim.AlgorithmRSASHA256
+		}
+		if algorithm != dkim.AlgorithmRSASHA256 && algorithm != dkim.AlgorithmEd25519SHA256 {
+			http.Error(w, "Algorithm must be rsa-sha256 or ed25519-sha256", http.StatusBadRequest)
+			return
+		}
+		if privateKey, publicKey, err = dkim.GenerateKey(algorithm); err != nil {
+			log.Printf("Failed to generate DKIM key: %v", err)
+			http.Error(w, "Failed to generate DKIM key", http.StatusInternalServerError)
+			return
+		}
+	}
+	
+	privateKeyEnc, err := crypto.EncryptDKIMKey(privateKey)
+	if err != nil {
+		log.Printf("Failed to encrypt DKIM key: %v", err)
+		http.Error(w, "Failed to encrypt DKIM key", http.StatusInternalServerError)
+		return
+	}
+	
+	key := &storage.DKIMKey{
+		ID:            generateID(),
+		ProjectID:     projectID,
+		Domain:        domain,
+		Selector:      req.Selector,
+		Algorithm:     algorithm,
+		PrivateKeyEnc: privateKeyEnc,
+		PublicKey:     publicKey,
+		CreatedAt:     time.Now(),
+	}
+	
+	if err := s.storage.AddDKIMKey(key); err != nil {
+		log.Printf("Failed to add DKIM key for project %s: %v", projectID, err)
+		http.Error(w, "Failed to add DKIM key (selector may already exist for this domain)", http.StatusConflict)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_dkim_key_added", &projectID, map[string]interface{}{
+		"key_id":    key.ID,
+		"domain":    key.Domain,
+		"selector":  key.Selector,
+		"algorithm": key.Algorithm,
+		"imported":  imported,
+	})
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toDKIMKeyResponse(key))
+}
+
+// deleteDKIMKeyHandler removes a DKIM key from a project
+func (s *Server) deleteDKIMKeyHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	keyID := vars["keyId"]
+	
+	if err := s.storage.DeleteDKIMKey(projectID, keyID); err != nil {
+		log.Printf("Failed to delete DKIM key %s for project %s: %v", keyID, projectID, err)
+		http.Error(w, "DKIM key not found", http.StatusNotFound)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_dkim_key_removed", &projectID, map[string]interface{}{
+		"key_id": keyID,
+	})
+	
+	response := map[string]interface{}{
+		"success": true,
+		"message": "DKIM key removed",
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
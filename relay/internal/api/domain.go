@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/domains"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// SenderDomainResponse represents a sender domain with the DNS record to publish
+type SenderDomainResponse struct {
+	*storage.SenderDomain
+	DNSRecord map[string]string `json:"DNSRecord"`
+}
+
+// toSenderDomainResponse adds the expected TXT record to a sender domain
+func toSenderDomainResponse(domain *storage.SenderDomain) *SenderDomainResponse {
+	return &SenderDomainResponse{
+		SenderDomain: domain,
+		DNSRecord: map[string]string{
+			"type":  "TXT",
+			"name":  domains.RecordName(domain.Domain),
+			"value": domains.RecordValue(domain.VerificationToken),
+		},
+	}
+}
+
+// listDomainsHandler returns the sender domains claimed by a project
+func (s *Server) listDomainsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	senderDomains, err := s.storage.ListSenderDomains(projectID)
+	if err != nil {
+		log.Printf("Failed to list sender domains for project %s: %v", projectID, err)
+		http.Error(w, "Failed to list sender domains", http.StatusInternalServerError)
+		return
+	}
+	
+	response := []*SenderDomainResponse{}
+	for _, domain := range senderDomains {
+		response = append(response, toSenderDomainResponse(domain))
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// addDomainHandler claims a sender domain and returns its verification record
+func (s *Server) addDomainHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	var req struct {
+		Domain string `json:"domain"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	name, err := domains.NormalizeDomain(req.Domain)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	token, err := domains.GenerateToken()
+	if err != nil {
+		log.Printf("Failed to generate verification token: %v", err)
+		http.Error(w, "Failed to generate verification token", http.StatusInternalServerError)
+		return
+	}
+	
+	domain := &storage.SenderDomain{
+		ID:                generateID(),
+		ProjectID:         projectID,
+		Domain:            name,
+		VerificationToken: token,
+		Status:            storage.DomainStatusPending,
+		CreatedAt:         time.Now(),
+	}
+	
+	if err := s.storage.AddSenderDomain(domain); err != nil {
+		log.Printf("Failed to add sender domain for project %s: %v", projectID, err)
+		http.Error(w, "Failed to add sender domain (it may already exist)", http.StatusConflict)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_domain_added", &projectID, map[string]interface{}{
+		"domain_id": domain.ID,
+		"domain":    domain.Domain,
+	})
+	
+	s.reloadAuthProjects()
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toSenderDomainResponse(domain))
+}
+
+// verifyDomainHandler checks a sender domain's TXT record now
+func (s *Server) verifyDomainHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	domainID := vars["domainId"]
+	
+	domain, err := s.storage.GetSenderDomain(projectID, domainID)
+	if err != nil {
+		log.Printf("Failed to get sender domain %s for project %s: %v", domainID, projectID, err)
+		http.Error(w, "Sender domain not found", http.StatusNotFound)
+		return
+	}
+	
+	status, verifyErr := s.domainVerifier.Verify(domain)
+	if verifyErr != nil && !errors.Is(verifyErr, domains.ErrRecordMissing) {
+		// Transient DNS failure - the stored status is unchanged
+		log.Printf("Failed to verify %s: %v", domain.Domain, verifyErr)
+		http.Error(w, "DNS lookup failed, try again later", http.StatusServiceUnavailable)
+		return
+	}
+	if status == "" {
+		log.Printf("Failed to store verification result for %s: %v", domain.Domain, verifyErr)
+		http.Error(w, "Failed to verify sender domain", http.StatusInternalServerError)
+		return
+	}
+	
+	action := "project_domain_verified"
+	details := map[string]interface{}{
+		"domain_id": domain.ID,
+		"domain":    domain.Domain,
+	}
+	if verifyErr != nil {
+		action = "project_domain_verification_failed"
+		details["error"] = verifyErr.Error()
+	}
+	s.recordAuditLog(r, action, &projectID, details)
+	
+	s.reloadAuthProjects()
+	
+	// Return the stored state
+	if updated, err := s.storage.GetSenderDomain(projectID, domainID); err == nil {
+		domain = updated
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toSenderDomainResponse(domain))
+}
+
+// deleteDomainHandler removes a sender domain from a project
+func (s *Server) deleteDomainHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	domainID := vars["domainId"]
+	
+	if err := s.storage.DeleteSenderDomain(projectID, domainID); err != nil {
+		log.Printf("Failed to delete sender domain %s for project %s: %v", domainID, projectID, err)
+		http.Error(w, "Sender domain not found", http.StatusNotFound)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_domain_removed", &projectID, map[string]interface{}{
+		"domain_id": domainID,
+	})
+	
+	s.reloadAuthProjects()
+	
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Sender domain removed",
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -2,11 +2,9 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/Renespeare/mailpulse/relay/internal/storage"
 	"github.com/gorilla/mux"
@@ -32,12 +30,13 @@ func (s *Server) emailStatsHandler(w http.ResponseWriter, r *http.Request) {
 	
 	// Calculate statistics
 	stats := map[string]interface{}{
-		"projectId":     projectID,
-		"totalEmails":   len(emails),
-		"sentEmails":    0,
-		"failedEmails":  0,
-		"queuedEmails":  0,
-		"totalSize":     0,
+		"projectId":       projectID,
+		"totalEmails":     len(emails),
+		"sentEmails":      0,
+		"failedEmails":    0,
+		"queuedEmails":    0,
+		"scheduledEmails": 0,
+		"totalSize":       0,
 	}
 	
 	for _, email := range emails {
@@ -48,6 +47,8 @@ func (s *Server) emailStatsHandler(w http.ResponseWriter, r *http.Request) {
 			stats["failedEmails"] = stats["failedEmails"].(int) + 1
 		case "queued":
 			stats["queuedEmails"] = stats["queuedEmails"].(int) + 1
+		case storage.EmailStatusScheduled:
+			stats["scheduledEmails"] = stats["scheduledEmails"].(int) + 1
 		}
 		stats["totalSize"] = stats["totalSize"].(int) + email.Size
 	}
@@ -59,6 +60,7 @@ func (s *Server) emailStatsHandler(w http.ResponseWriter, r *http.Request) {
 		successRate = float64(stats["sentEmails"].(int)) / float64(totalProcessed) * 100
 	}
 	stats["successRate"] = successRate
+	addEngagementStats(stats, emails)
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
@@ -76,11 +78,12 @@ func (s *Server) allEmailStatsHandler(w http.ResponseWriter, r *http.Request) {
 	
 	// Calculate statistics
 	stats := map[string]interface{}{
-		"totalEmails":   len(emails),
-		"sentEmails":    0,
-		"failedEmails":  0,
-		"queuedEmails":  0,
-		"totalSize":     0,
+		"totalEmails":     len(emails),
+		"sentEmails":      0,
+		"failedEmails":    0,
+		"queuedEmails":    0,
+		"scheduledEmails": 0,
+		"totalSize":       0,
 	}
 	
 	for _, email := range emails {
@@ -91,6 +94,8 @@ func (s *Server) allEmailStatsHandler(w http.ResponseWriter, r *http.Request) {
 			stats["failedEmails"] = stats["failedEmails"].(int) + 1
 		case "queued":
 			stats["queuedEmails"] = stats["queuedEmails"].(int) + 1
+		case storage.EmailStatusScheduled:
+			stats["scheduledEmails"] = stats["scheduledEmails"].(int) + 1
 		}
 		stats["totalSize"] = stats["totalSize"].(int) + email.Size
 	}
@@ -102,6 +107,7 @@ func (s *Server) allEmailStatsHandler(w http.ResponseWriter, r *http.Request) {
 		successRate = float64(stats["sentEmails"].(int)) / float64(totalProcessed) * 100
 	}
 	stats["successRate"] = successRate
+	addEngagementStats(stats, emails)
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
@@ -131,47 +137,48 @@ func (s *Server) resendEmailHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	// Update email status to queued for resend
-	err = s.storage.UpdateEmailStatus(emailID, "queued", nil)
+	// Only resend to recipients that were not delivered
+	recipients, err := s.failedRecipients(email)
+	if err != nil {
+		log.Printf("Failed to get recipients of email %s: %v", emailID, err)
+		http.Error(w, "Failed to get email recipients", http.StatusInternalServerError)
+		return
+	}
+	if len(recipients) == 0 {
+		http.Error(w, "No failed recipients to resend to", http.StatusBadRequest)
+		return
+	}
+	
+	// Put the email back in the delivery queue, which resends it to every
+	// undelivered recipient unless the project's delivery is paused
+	err = s.storage.UpdateEmailStatus(emailID, storage.EmailStatusQueued, nil)
 	if err != nil {
 		log.Printf("Failed to update email status for resend: %v", err)
 		http.Error(w, "Failed to queue email for resend", http.StatusInternalServerError)
 		return
 	}
+	if err := s.storage.ResetUndeliveredRecipients(emailID); err != nil {
+		log.Printf("Failed to reset recipients for resend: %v", err)
+		http.Error(w, "Failed to queue email for resend", http.StatusInternalServerError)
+		return
+	}
 	
 	// Record audit log for email resend request
 	s.recordAuditLog(r, "email_resend_requested", &email.ProjectID, map[string]interface{}{
 		"email_id":   emailID,
 		"message_id": email.MessageID,
 		"from":       email.From,
-		"to":         email.To,
+		"to":         recipients,
 		"subject":    email.Subject,
 	})
 	
-	// Actually forward the email using SMTP
-	go func() {
-		// Simulate processing time
-		time.Sleep(1 * time.Second)
-		
-		// Use the email forwarder to actually resend the email
-		err := s.forwarder.ForwardEmail(email, email.ProjectID)
-		
-		if err == nil {
-			// Success - mark as sent
-			s.storage.UpdateEmailStatus(emailID, "delivered", nil)
-			log.Printf("✅ Email %s resent successfully via SMTP", emailID)
-		} else {
-			// Failed - mark as failed with error
-			errorMsg := fmt.Sprintf("SMTP forwarding failed: %s", err.Error())
-			s.storage.UpdateEmailStatus(emailID, "failed", &errorMsg)
-			log.Printf("❌ Email %s resend failed: %s", emailID, err.Error())
-		}
-	}()
+	s.forwarder.WakeQueue()
 	
 	response := map[string]interface{}{
 		"success": true,
-		"message": "Email queued for resend",
-		"emailId": emailID,
+		"message":    "Email queued for resend",
+		"emailId":    emailID,
+		"recipients": recipients,
 	}
 	
 	// Set CORS headers
@@ -182,6 +189,71 @@ func (s *Server) resendEmailHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// failedRecipients returns the recipients of an email that were not delivered.
+// Emails stored before recipients were tracked resend to everyone.
+func (s *Server) failedRecipients(email *storage.Email) ([]string, error) {
+	tracked, err := s.storage.ListEmailRecipients(email.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(tracked) == 0 {
+		return email.To, nil
+	}
+	
+	var recipients []string
+	for _, recipient := range tracked {
+		switch recipient.Status {
+		case storage.RecipientStatusBounced, storage.RecipientStatusFailed, storage.RecipientStatusDeferred:
+			recipients = append(recipients, recipient.Recipient)
+		}
+	}
+	return recipients, nil
+}
+
+// getEmailHandler returns an email with the delivery status of each recipient
+func (s *Server) getEmailHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	emailID := vars["emailId"]
+	
+	email, err := s.storage.GetEmail(emailID)
+	if err != nil {
+		log.Printf("Failed to get email %s: %v", emailID, err)
+		http.Error(w, "Email not found", http.StatusNotFound)
+		return
+	}
+	
+	recipients, err := s.storage.ListEmailRecipients(emailID)
+	if err != nil {
+		log.Printf("Failed to get recipients of email %s: %v", emailID, err)
+		http.Error(w, "Failed to get email recipients", http.StatusInternalServerError)
+		return
+	}
+	
+	opens, err := s.storage.ListEmailOpens(emailID, 50)
+	if err != nil {
+		log.Printf("Failed to get opens of email %s: %v", emailID, err)
+		http.Error(w, "Failed to get email opens", http.StatusInternalServerError)
+		return
+	}
+	
+	links, err := s.storage.ListEmailLinkStats(emailID)
+	if err != nil {
+		log.Printf("Failed to get link clicks of email %s: %v", emailID, err)
+		http.Error(w, "Failed to get email link clicks", http.StatusInternalServerError)
+		return
+	}
+	
+	response := map[string]interface{}{
+		"email":      email,
+		"recipients": recipients,
+		"opens":      opens,
+		"links":      links,
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // listEmailsHandler returns emails with pagination, search, and status filtering
 func (s *Server) listEmailsHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
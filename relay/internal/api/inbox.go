@@ -0,0 +1,247 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/smtp"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// CapturedMessageResponse summarises a captured sandbox message
+type CapturedMessageResponse struct {
+	ID         string
+	EmailID    string
+	From       string
+	Recipients []string
+	Subject    string
+	Size       int
+	CreatedAt  time.Time
+}
+
+// AttachmentResponse describes an attachment of a captured message
+type AttachmentResponse struct {
+	Index       int
+	Filename    string
+	ContentType string
+	Size        int
+}
+
+// toCapturedMessageResponse converts a storage.CapturedMessage for API responses
+func toCapturedMessageResponse(message *storage.CapturedMessage) *CapturedMessageResponse {
+	return &CapturedMessageResponse{
+		ID:         message.ID,
+		EmailID:    message.EmailID,
+		From:       message.From,
+		Recipients: message.Recipients,
+		Subject:    message.Subject,
+		Size:       message.Size,
+		CreatedAt:  message.CreatedAt,
+	}
+}
+
+// listInboxHandler lists the messages captured for a sandbox project, newest
+// first, optionally only those sent to ?recipient=
+func (s *Server) listInboxHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	limit := 20 // default page size
+	offset := 0 // default offset
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+	recipient := strings.TrimSpace(r.URL.Query().Get("recipient"))
+	
+	messages, totalCount, err := s.storage.ListCapturedMessages(projectID, recipient, limit, offset)
+	if err != nil {
+		log.Printf("Failed to list captured messages for project %s: %v", projectID, err)
+		http.Error(w, "Failed to list captured messages", http.StatusInternalServerError)
+		return
+	}
+	
+	response := []*CapturedMessageResponse{}
+	for _, message := range messages {
+		response = append(response, toCapturedMessageResponse(message))
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"messages":   response,
+		"totalCount": totalCount,
+		"limit":      limit,
+		"offset":     offset,
+		"hasMore":    offset+len(messages) < totalCount,
+	})
+}
+
+// capturedMessage loads the message named in the route, writing an error response when missing
+func (s *Server) capturedMessage(w http.ResponseWriter, r *http.Request) (*storage.CapturedMessage, *smtp.MessageContent, bool) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	messageID := vars["messageId"]
+	
+	message, err := s.storage.GetCapturedMessage(projectID, messageID)
+	if err != nil {
+		log.Printf("Failed to get captured message %s: %v", messageID, err)
+		http.Error(w, "Captured message not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	
+	content, err := smtp.ParseMessageContent(message.Raw)
+	if err != nil {
+		log.Printf("Failed to parse captured message %s: %v", messageID, err)
+		content = &smtp.MessageContent{}
+	}
+	
+	return message, content, true
+}
+
+// getInboxMessageHandler returns a captured message with its text and HTML
+// parts and a list of its attachments
+func (s *Server) getInboxMessageHandler(w http.ResponseWriter, r *http.Request) {
+	message, content, ok := s.capturedMessage(w, r)
+	if !ok {
+		return
+	}
+	
+	attachments := []*AttachmentResponse{}
+	for i, attachment := range content.Attachments {
+		attachments = append(attachments, &AttachmentResponse{
+			Index:       i,
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Size:        len(attachment.Data),
+		})
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":     toCapturedMessageResponse(message),
+		"text":        content.Text,
+		"html":        content.HTML,
+		"attachments": attachments,
+	})
+}
+
+// getInboxMessageHTMLHandler renders the HTML part of a captured message.
+// Scripts are blocked by the content security policy.
+func (s *Server) getInboxMessageHTMLHandler(w http.ResponseWriter, r *http.Request) {
+	_, content, ok := s.capturedMessage(w, r)
+	if !ok {
+		return
+	}
+	if content.HTML == "" {
+		http.Error(w, "Message has no HTML part", http.StatusNotFound)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "script-src 'none'; object-src 'none'")
+	w.Write([]byte(content.HTML))
+}
+
+// getInboxMessageTextHandler returns the plain text part of a captured message
+func (s *Server) getInboxMessageTextHandler(w http.ResponseWriter, r *http.Request) {
+	_, content, ok := s.capturedMessage(w, r)
+	if !ok {
+		return
+	}
+	if content.Text == "" {
+		http.Error(w, "Message has no text part", http.StatusNotFound)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(content.Text))
+}
+
+// getInboxMessageRawHandler downloads a captured message as an .eml file
+func (s *Server) getInboxMessageRawHandler(w http.ResponseWriter, r *http.Request) {
+	message, _, ok := s.capturedMessage(w, r)
+	if !ok {
+		return
+	}
+	
+	w.Header().Set("Content-Type", "message/rfc822")
+	w.Header().Set("Content-Disposition", attachmentDisposition(message.ID+".eml"))
+	w.Write(message.Raw)
+}
+
+// getInboxAttachmentHandler downloads one attachment of a captured message
+func (s *Server) getInboxAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	_, content, ok := s.capturedMessage(w, r)
+	if !ok {
+		return
+	}
+	
+	index, err := strconv.Atoi(mux.Vars(r)["index"])
+	if err != nil || index < 0 || index >= len(content.Attachments) {
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return
+	}
+	attachment := content.Attachments[index]
+	
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", attachmentDisposition(attachment.Filename))
+	w.Write(attachment.Data)
+}
+
+// clearInboxHandler deletes all captured messages of a project
+func (s *Server) clearInboxHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	deleted, err := s.storage.ClearCapturedMessages(projectID)
+	if err != nil {
+		log.Printf("Failed to clear captured messages for project %s: %v", projectID, err)
+		http.Error(w, "Failed to clear inbox", http.StatusInternalServerError)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_inbox_cleared", &projectID, map[string]interface{}{
+		"deleted": deleted,
+	})
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"deleted": deleted,
+	})
+}
+
+// attachmentDisposition builds a Content-Disposition header for a download
+func attachmentDisposition(filename string) string {
+	filename = strings.Map(func(r rune) rune {
+		if r == '"' || r == '\\' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, filename)
+	return fmt.Sprintf(`attachment; filename="%s"`, filename)
+}
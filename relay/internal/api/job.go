@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/jobs"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// BulkEmailRequest selects the emails of a bulk operation. Every field is
+// optional; times are RFC 3339 and compared with when the email was sent.
+type BulkEmailRequest struct {
+	ProjectID string `json:"projectId"`
+	Status    string `json:"status"`
+	Since     string `json:"since"`
+	Until     string `json:"until"`
+	Error     string `json:"error"` // substring of the email's error message
+}
+
+// bulkRetryHandler queues the failed and deferred emails matching a filter
+// for another delivery attempt, as a background job
+func (s *Server) bulkRetryHandler(w http.ResponseWriter, r *http.Request) {
+	s.startBulkJob(w, r, storage.JobTypeRetry, "emails_bulk_retry_requested")
+}
+
+// bulkCancelHandler cancels the queued and scheduled emails matching a
+// filter, as a background job
+func (s *Server) bulkCancelHandler(w http.ResponseWriter, r *http.Request) {
+	s.startBulkJob(w, r, storage.JobTypeCancel, "emails_bulk_cancel_requested")
+}
+
+// startBulkJob parses the filter of a bulk request and starts its job
+func (s *Server) startBulkJob(w http.ResponseWriter, r *http.Request, jobType string, auditAction string) {
+	var req BulkEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	filter := storage.EmailFilter{
+		ProjectID:     strings.TrimSpace(req.ProjectID),
+		ErrorContains: strings.TrimSpace(req.Error),
+	}
+	if filter.ProjectID != "" {
+		if _, err := s.storage.GetProject(filter.ProjectID); err != nil {
+			log.Printf("Failed to get project %s: %v", filter.ProjectID, err)
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+	}
+	if req.Status != "" {
+		if !jobs.Eligible(jobType, req.Status) {
+			http.Error(w, fmt.Sprintf("Cannot %s %s emails", jobType, req.Status), http.StatusBadRequest)
+			return
+		}
+		filter.Statuses = []string{req.Status}
+	}
+	var err error
+	if filter.Since, err = parseOptionalTime(req.Since); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid since: %v", err), http.StatusBadRequest)
+		return
+	}
+	if filter.Until, err = parseOptionalTime(req.Until); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid until: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	job, err := s.jobRunner.Run(jobType, filter)
+	if err != nil {
+		log.Printf("Failed to start %s job: %v", jobType, err)
+		http.Error(w, "Failed to start job", http.StatusInternalServerError)
+		return
+	}
+	
+	s.recordAuditLog(r, auditAction, job.ProjectID, map[string]interface{}{
+		"job_id":   job.ID,
+		"statuses": filter.Statuses,
+		"since":    filter.Since,
+		"until":    filter.Until,
+		"error":    filter.ErrorContains,
+	})
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// parseOptionalTime parses an RFC 3339 time, returning nil when value is empty
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// listJobsHandler lists background jobs, newest first, optionally only those
+// of ?project=
+func (s *Server) listJobsHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.URL.Query().Get("project")
+	
+	limit := 20 // default page size
+	offset := 0 // default offset
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+	
+	jobList, totalCount, err := s.storage.ListJobs(projectID, limit, offset)
+	if err != nil {
+		log.Printf("Failed to list jobs: %v", err)
+		http.Error(w, "Failed to list jobs", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jobs":       jobList,
+		"totalCount": totalCount,
+		"limit":      limit,
+		"offset":     offset,
+		"hasMore":    offset+len(jobList) < totalCount,
+	})
+}
+
+// getJobHandler returns a background job with its progress
+func (s *Server) getJobHandler(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["jobId"]
+	
+	job, err := s.storage.GetJob(jobID)
+	if err != nil {
+		log.Printf("Failed to get job %s: %v", jobID, err)
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
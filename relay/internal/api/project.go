@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"net/mail"
 	"strings"
 	"time"
 
 	"github.com/Renespeare/mailpulse/relay/internal/crypto"
+	"github.com/Renespeare/mailpulse/relay/internal/smtp"
 	"github.com/Renespeare/mailpulse/relay/internal/storage"
 	"github.com/gorilla/mux"
 	"golang.org/x/crypto/bcrypt"
@@ -25,11 +27,40 @@ type ProjectResponse struct {
 	QuotaDaily       int       `json:"QuotaDaily"`
 	QuotaPerMinute   int       `json:"QuotaPerMinute"`
 	Status           string    `json:"Status"`
+	RequireIPAllow   bool      `json:"RequireIPAllow"`
+	DeliveryMode     string    `json:"DeliveryMode"`
+	UpstreamTLS      storage.UpstreamTLSPolicy `json:"UpstreamTLS"`
+	UpstreamAuth     UpstreamAuthResponse `json:"UpstreamAuth"`
+	Transport        TransportResponse `json:"Transport"`
+	SuppressionMode  string    `json:"SuppressionMode"`
+	TrackOpens       bool      `json:"TrackOpens"`
+	TrackClicks      bool      `json:"TrackClicks"`
+	ListUnsubscribe  bool      `json:"ListUnsubscribe"`
+	UnsubscribeEmail *string   `json:"UnsubscribeEmail"`
+	DeliveryPaused   bool      `json:"DeliveryPaused"`
 	UserID           *string   `json:"UserID"`
 	CreatedAt        time.Time `json:"CreatedAt"`
 	LastUsedAt       *time.Time `json:"LastUsedAt"`
 }
 
+// UpstreamAuthResponse describes upstream authentication without the OAuth secrets
+type UpstreamAuthResponse struct {
+	Mechanism       string  `json:"Mechanism"`
+	OAuthTokenURL   *string `json:"OAuthTokenURL"`
+	OAuthClientID   *string `json:"OAuthClientID"`
+	OAuthScope      *string `json:"OAuthScope"`
+	HasClientSecret bool    `json:"HasClientSecret"`
+	HasRefreshToken bool    `json:"HasRefreshToken"`
+}
+
+// TransportResponse describes the HTTP transport settings without the secret
+type TransportResponse struct {
+	Endpoint    *string `json:"Endpoint"`
+	Region      *string `json:"Region"`
+	AccessKeyID *string `json:"AccessKeyID"`
+	HasSecret   bool    `json:"HasSecret"`
+}
+
 // toProjectResponse converts a storage.Project to ProjectResponse with decrypted API key
 func toProjectResponse(project *storage.Project) (*ProjectResponse, error) {
 	// Decrypt API key for response
@@ -53,9 +84,32 @@ func toProjectResponse(project *storage.Project) (*ProjectResponse, error) {
 		QuotaDaily:     project.QuotaDaily,
 		QuotaPerMinute: project.QuotaPerMinute,
 		Status:         project.Status,
-		UserID:         project.UserID,
-		CreatedAt:      project.CreatedAt,
-		LastUsedAt:     project.LastUsedAt,
+		RequireIPAllow: project.RequireIPAllow,
+		DeliveryMode:   project.DeliveryMode,
+		UpstreamTLS:    project.UpstreamTLS,
+		UpstreamAuth: UpstreamAuthResponse{
+			Mechanism:       project.UpstreamAuth.Mechanism,
+			OAuthTokenURL:   project.UpstreamAuth.OAuthTokenURL,
+			OAuthClientID:   project.UpstreamAuth.OAuthClientID,
+			OAuthScope:      project.UpstreamAuth.OAuthScope,
+			HasClientSecret: project.UpstreamAuth.OAuthClientSecretEnc != nil && *project.UpstreamAuth.OAuthClientSecretEnc != "",
+			HasRefreshToken: project.UpstreamAuth.OAuthRefreshTokenEnc != nil && *project.UpstreamAuth.OAuthRefreshTokenEnc != "",
+		},
+		Transport: TransportResponse{
+			Endpoint:    project.Transport.Endpoint,
+			Region:      project.Transport.Region,
+			AccessKeyID: project.Transport.AccessKeyID,
+			HasSecret:   project.Transport.SecretEnc != nil && *project.Transport.SecretEnc != "",
+		},
+		SuppressionMode:  project.SuppressionMode,
+		TrackOpens:       project.TrackOpens,
+		TrackClicks:      project.TrackClicks,
+		ListUnsubscribe:  project.ListUnsubscribe,
+		UnsubscribeEmail: project.UnsubscribeEmail,
+		DeliveryPaused:   project.DeliveryPaused,
+		UserID:           project.UserID,
+		CreatedAt:        project.CreatedAt,
+		LastUsedAt:       project.LastUsedAt,
 	}, nil
 }
 
@@ -95,6 +149,15 @@ func (s *Server) createProjectHandler(w http.ResponseWriter, r *http.Request) {
 		SMTPPassword string `json:"smtpPassword,omitempty"`
 		QuotaPerMinute int  `json:"quotaPerMinute"`
 		QuotaDaily     int  `json:"quotaDaily"`
+		DeliveryMode   string `json:"deliveryMode,omitempty"`
+		UpstreamTLSMode       string `json:"upstreamTlsMode,omitempty"`
+		UpstreamTLSMinVersion string `json:"upstreamTlsMinVersion,omitempty"`
+		UpstreamTLSCABundle   string `json:"upstreamTlsCaBundle,omitempty"`
+		UpstreamTLSServerName string `json:"upstreamTlsServerName,omitempty"`
+		TransportEndpoint     string `json:"transportEndpoint,omitempty"`
+		TransportRegion       string `json:"transportRegion,omitempty"`
+		TransportAccessKeyID  string `json:"transportAccessKeyId,omitempty"`
+		TransportSecret       string `json:"transportSecret,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -132,6 +195,49 @@ func (s *Server) createProjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	deliveryMode := req.DeliveryMode
+	if deliveryMode == "" {
+		deliveryMode = storage.DeliveryModeRelay
+	}
+	if !validDeliveryMode(deliveryMode) {
+		http.Error(w, "Invalid delivery mode", http.StatusBadRequest)
+		return
+	}
+
+	upstreamTLS := storage.UpstreamTLSPolicy{
+		Mode:       req.UpstreamTLSMode,
+		MinVersion: req.UpstreamTLSMinVersion,
+		CABundle:   stringPtrFromString(req.UpstreamTLSCABundle),
+		ServerName: stringPtrFromString(req.UpstreamTLSServerName),
+	}
+	if upstreamTLS.Mode == "" {
+		upstreamTLS.Mode = storage.UpstreamTLSOpportunistic
+	}
+	if err := smtp.ValidateUpstreamTLS(upstreamTLS); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// HTTP API transport settings (webhook, ses and sendgrid modes)
+	transport := storage.TransportSettings{
+		Endpoint:    stringPtrFromString(req.TransportEndpoint),
+		Region:      stringPtrFromString(req.TransportRegion),
+		AccessKeyID: stringPtrFromString(req.TransportAccessKeyID),
+	}
+	if req.TransportSecret != "" {
+		encrypted, err := crypto.EncryptTransportSecret(req.TransportSecret)
+		if err != nil {
+			log.Printf("Failed to encrypt transport secret: %v", err)
+			http.Error(w, "Failed to encrypt transport secret", http.StatusInternalServerError)
+			return
+		}
+		transport.SecretEnc = &encrypted
+	}
+	if err := smtp.ValidateTransport(deliveryMode, transport); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Set default quotas if not provided
 	quotaPerMinute := req.QuotaPerMinute
 	if quotaPerMinute == 0 {
@@ -160,6 +266,7 @@ func (s *Server) createProjectHandler(w http.ResponseWriter, r *http.Request) {
 		Name:           req.Name,
 		Description:    req.Description,
 		APIKeyEnc:      encryptedAPIKey,
+		APIKeyDigest:   stringPtr(crypto.HashAPIKey(apiKey)),
 		PasswordHash:   stringPtr(string(hashedPassword)),
 		SMTPHost:       stringPtrFromString(req.SMTPHost),
 		SMTPPort:       intPtrFromInt(req.SMTPPort),
@@ -168,6 +275,9 @@ func (s *Server) createProjectHandler(w http.ResponseWriter, r *http.Request) {
 		QuotaDaily:     quotaDaily,
 		QuotaPerMinute: quotaPerMinute,
 		Status:         "active",
+		DeliveryMode:   deliveryMode,
+		UpstreamTLS:    upstreamTLS,
+		Transport:      transport,
 		UserID:         nil,
 		CreatedAt:      time.Now(),
 		LastUsedAt:     nil,
@@ -186,6 +296,7 @@ func (s *Server) createProjectHandler(w http.ResponseWriter, r *http.Request) {
 		"quota_daily":      project.QuotaDaily,
 		"quota_per_minute": project.QuotaPerMinute,
 		"has_smtp_config":  project.SMTPHost != nil,
+		"delivery_mode":    project.DeliveryMode,
 	})
 
 	// Reload auth manager projects so new project is available immediately
@@ -282,6 +393,139 @@ func (s *Server) updateProjectHandler(w http.ResponseWriter, r *http.Request) {
 		project.SMTPPasswordEnc = &encryptedPassword
 	}
 
+	// IP allowlist enforcement
+	if requireIPAllow, ok := updates["requireIpAllow"].(bool); ok {
+		project.RequireIPAllow = requireIPAllow
+	}
+
+	// Delivery mode (upstream relay or direct to MX)
+	if deliveryMode, ok := updates["deliveryMode"].(string); ok {
+		if !validDeliveryMode(deliveryMode) {
+			http.Error(w, "Invalid delivery mode", http.StatusBadRequest)
+			return
+		}
+		project.DeliveryMode = deliveryMode
+	}
+
+	// Upstream TLS policy
+	if tlsMode, ok := updates["upstreamTlsMode"].(string); ok {
+		project.UpstreamTLS.Mode = tlsMode
+	}
+	if minVersion, ok := updates["upstreamTlsMinVersion"].(string); ok {
+		project.UpstreamTLS.MinVersion = minVersion
+	}
+	if caBundle, ok := updates["upstreamTlsCaBundle"].(string); ok {
+		project.UpstreamTLS.CABundle = stringPtrFromString(caBundle)
+	}
+	if serverName, ok := updates["upstreamTlsServerName"].(string); ok {
+		project.UpstreamTLS.ServerName = stringPtrFromString(serverName)
+	}
+	if err := smtp.ValidateUpstreamTLS(project.UpstreamTLS); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Upstream authentication mechanism and XOAUTH2 credentials
+	if mechanism, ok := updates["upstreamAuthMechanism"].(string); ok {
+		project.UpstreamAuth.Mechanism = strings.ToLower(mechanism)
+	}
+	if tokenURL, ok := updates["oauthTokenUrl"].(string); ok {
+		project.UpstreamAuth.OAuthTokenURL = stringPtrFromString(tokenURL)
+	}
+	if clientID, ok := updates["oauthClientId"].(string); ok {
+		project.UpstreamAuth.OAuthClientID = stringPtrFromString(clientID)
+	}
+	if scope, ok := updates["oauthScope"].(string); ok {
+		project.UpstreamAuth.OAuthScope = stringPtrFromString(scope)
+	}
+	if clientSecret, ok := updates["oauthClientSecret"].(string); ok && clientSecret != "" {
+		encrypted, err := crypto.EncryptOAuthSecret(clientSecret)
+		if err != nil {
+			log.Printf("Failed to encrypt OAuth client secret: %v", err)
+			http.Error(w, "Failed to encrypt OAuth client secret", http.StatusInternalServerError)
+			return
+		}
+		project.UpstreamAuth.OAuthClientSecretEnc = &encrypted
+	}
+	if refreshToken, ok := updates["oauthRefreshToken"].(string); ok && refreshToken != "" {
+		encrypted, err := crypto.EncryptOAuthSecret(refreshToken)
+		if err != nil {
+			log.Printf("Failed to encrypt OAuth refresh token: %v", err)
+			http.Error(w, "Failed to encrypt OAuth refresh token", http.StatusInternalServerError)
+			return
+		}
+		project.UpstreamAuth.OAuthRefreshTokenEnc = &encrypted
+	}
+	if err := smtp.ValidateUpstreamAuth(project.UpstreamAuth); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// HTTP API transport settings
+	if endpoint, ok := updates["transportEndpoint"].(string); ok {
+		project.Transport.Endpoint = stringPtrFromString(endpoint)
+	}
+	if region, ok := updates["transportRegion"].(string); ok {
+		project.Transport.Region = stringPtrFromString(region)
+	}
+	if accessKeyID, ok := updates["transportAccessKeyId"].(string); ok {
+		project.Transport.AccessKeyID = stringPtrFromString(accessKeyID)
+	}
+	if secret, ok := updates["transportSecret"].(string); ok && secret != "" {
+		encrypted, err := crypto.EncryptTransportSecret(secret)
+		if err != nil {
+			log.Printf("Failed to encrypt transport secret: %v", err)
+			http.Error(w, "Failed to encrypt transport secret", http.StatusInternalServerError)
+			return
+		}
+		project.Transport.SecretEnc = &encrypted
+	}
+	if err := smtp.ValidateTransport(project.DeliveryMode, project.Transport); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// What happens to recipients on the suppression list
+	if suppressionMode, ok := updates["suppressionMode"].(string); ok {
+		if suppressionMode != storage.SuppressionModeReject && suppressionMode != storage.SuppressionModeDrop {
+			http.Error(w, "Invalid suppression mode (use reject or drop)", http.StatusBadRequest)
+			return
+		}
+		project.SuppressionMode = suppressionMode
+	}
+
+	// Engagement tracking (injected into HTML parts when forwarding)
+	if trackOpens, ok := updates["trackOpens"].(bool); ok {
+		project.TrackOpens = trackOpens
+	}
+	if trackClicks, ok := updates["trackClicks"].(bool); ok {
+		project.TrackClicks = trackClicks
+	}
+
+	// List-Unsubscribe headers (one-click URL plus an optional mailto: address)
+	if listUnsubscribe, ok := updates["listUnsubscribe"].(bool); ok {
+		project.ListUnsubscribe = listUnsubscribe
+	}
+	if unsubscribeEmail, ok := updates["unsubscribeEmail"].(string); ok {
+		unsubscribeEmail = strings.TrimSpace(unsubscribeEmail)
+		if unsubscribeEmail != "" {
+			address, err := mail.ParseAddress(unsubscribeEmail)
+			if err != nil {
+				http.Error(w, "Invalid unsubscribe email address", http.StatusBadRequest)
+				return
+			}
+			unsubscribeEmail = address.Address
+		}
+		project.UnsubscribeEmail = stringPtrFromString(unsubscribeEmail)
+	}
+
+	// Pausing delivery holds new and retried mail in the queue until resumed
+	resumed := false
+	if deliveryPaused, ok := updates["deliveryPaused"].(bool); ok {
+		resumed = project.DeliveryPaused && !deliveryPaused
+		project.DeliveryPaused = deliveryPaused
+	}
+
 	// Quota updates
 	if quotaDaily, ok := updates["quotaDaily"].(float64); ok && quotaDaily >= 0 {
 		project.QuotaDaily = int(quotaDaily)
@@ -313,11 +557,40 @@ func (s *Server) updateProjectHandler(w http.ResponseWriter, r *http.Request) {
 			auditDetails["updated_smtp_config"] = true
 		case "smtpPassword":
 			auditDetails["updated_smtp_password"] = true
+		case "requireIpAllow":
+			auditDetails["updated_require_ip_allow"] = value
+		case "deliveryMode":
+			auditDetails["updated_delivery_mode"] = value
+		case "upstreamTlsMode", "upstreamTlsMinVersion", "upstreamTlsCaBundle", "upstreamTlsServerName":
+			auditDetails["updated_upstream_tls"] = true
+		case "upstreamAuthMechanism":
+			auditDetails["updated_upstream_auth_mechanism"] = value
+		case "oauthTokenUrl", "oauthClientId", "oauthScope", "oauthClientSecret", "oauthRefreshToken":
+			auditDetails["updated_oauth_credentials"] = true
+		case "transportEndpoint", "transportRegion", "transportAccessKeyId":
+			auditDetails["updated_transport_config"] = true
+		case "transportSecret":
+			auditDetails["updated_transport_secret"] = true
+		case "suppressionMode":
+			auditDetails["updated_suppression_mode"] = value
+		case "trackOpens":
+			auditDetails["updated_track_opens"] = value
+		case "trackClicks":
+			auditDetails["updated_track_clicks"] = value
+		case "listUnsubscribe", "unsubscribeEmail":
+			auditDetails["updated_list_unsubscribe"] = true
+		case "deliveryPaused":
+			auditDetails["updated_delivery_paused"] = value
 		}
 	}
 
 	s.recordAuditLog(r, "project_updated", &projectID, auditDetails)
 
+	// Send the mail held while delivery was paused
+	if resumed {
+		s.forwarder.WakeQueue()
+	}
+
 	// Reload auth manager projects to reflect status changes
 	if err := s.authManager.ReloadProjects(); err != nil {
 		log.Printf("⚠️  Failed to reload projects in auth manager: %v", err)
@@ -364,3 +637,8 @@ func (s *Server) deleteProjectHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// validDeliveryMode reports whether mode is a supported project delivery mode
+func validDeliveryMode(mode string) bool {
+	return mode == storage.DeliveryModeRelay || mode == storage.DeliveryModeMX ||
+		mode == storage.DeliveryModeSandbox || smtp.IsTransportMode(mode)
+}
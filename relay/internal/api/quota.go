@@ -47,6 +47,7 @@ func (s *Server) quotaUsageHandler(w http.ResponseWriter, r *http.Request) {
 		"minuteRemaining":    usage.MinuteRemaining,
 		"dailyUsagePercent":  dailyPercent,
 		"minuteUsagePercent": minutePercent,
+		"warmups":            usage.Warmups,
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/smtp"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// scheduledEmail loads the email named in the route, writing an error
+// response when it is missing or no longer scheduled
+func (s *Server) scheduledEmail(w http.ResponseWriter, r *http.Request) (*storage.Email, bool) {
+	emailID := mux.Vars(r)["emailId"]
+	
+	email, err := s.storage.GetEmail(emailID)
+	if err != nil {
+		log.Printf("Failed to get email %s: %v", emailID, err)
+		http.Error(w, "Email not found", http.StatusNotFound)
+		return nil, false
+	}
+	if email.Status != storage.EmailStatusScheduled {
+		http.Error(w, fmt.Sprintf("Email is not scheduled (status: %s)", email.Status), http.StatusConflict)
+		return nil, false
+	}
+	
+	return email, true
+}
+
+// cancelEmailHandler cancels a queued or scheduled email before it is sent
+func (s *Server) cancelEmailHandler(w http.ResponseWriter, r *http.Request) {
+	emailID := mux.Vars(r)["emailId"]
+	
+	email, err := s.storage.GetEmail(emailID)
+	if err != nil {
+		log.Printf("Failed to get email %s: %v", emailID, err)
+		http.Error(w, "Email not found", http.StatusNotFound)
+		return
+	}
+	if email.Status != storage.EmailStatusQueued && email.Status != storage.EmailStatusScheduled {
+		http.Error(w, fmt.Sprintf("Only queued or scheduled emails can be cancelled (status: %s)", email.Status), http.StatusConflict)
+		return
+	}
+	
+	cancelled, err := s.storage.CancelEmail(email.ID)
+	if err != nil {
+		log.Printf("Failed to cancel scheduled email %s: %v", email.ID, err)
+		http.Error(w, "Failed to cancel email", http.StatusInternalServerError)
+		return
+	}
+	if !cancelled {
+		// Sent by the queue after it was loaded
+		http.Error(w, "Email is no longer queued or scheduled", http.StatusConflict)
+		return
+	}
+	
+	s.recordAuditLog(r, "email_cancelled", &email.ProjectID, map[string]interface{}{
+		"email_id":     email.ID,
+		"message_id":   email.MessageID,
+		"status":       email.Status,
+		"scheduled_at": email.ScheduledAt,
+	})
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"emailId": email.ID,
+		"status":  storage.EmailStatusCancelled,
+	})
+}
+
+// rescheduleEmailHandler moves a scheduled email to a new send-at time
+func (s *Server) rescheduleEmailHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SendAt string `json:"send_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	requested, err := smtp.ParseSendAt(req.SendAt)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid send_at: %v", err), http.StatusBadRequest)
+		return
+	}
+	sendAt, err := smtp.ScheduleTime(requested)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid send_at: %v", err), http.StatusBadRequest)
+		return
+	}
+	if sendAt == nil {
+		// A time in the past sends the email on the scheduler's next poll
+		now := time.Now().UTC()
+		sendAt = &now
+	}
+	
+	email, ok := s.scheduledEmail(w, r)
+	if !ok {
+		return
+	}
+	
+	rescheduled, err := s.storage.RescheduleEmail(email.ID, *sendAt)
+	if err != nil {
+		log.Printf("Failed to reschedule email %s: %v", email.ID, err)
+		http.Error(w, "Failed to reschedule email", http.StatusInternalServerError)
+		return
+	}
+	if !rescheduled {
+		http.Error(w, "Email is no longer scheduled", http.StatusConflict)
+		return
+	}
+	
+	s.recordAuditLog(r, "email_rescheduled", &email.ProjectID, map[string]interface{}{
+		"email_id":         email.ID,
+		"message_id":       email.MessageID,
+		"scheduled_at":     email.ScheduledAt,
+		"new_scheduled_at": sendAt,
+	})
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"emailId": email.ID,
+		"status":  storage.EmailStatusScheduled,
+		"sendAt":  sendAt,
+	})
+}
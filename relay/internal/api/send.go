@@ -0,0 +1,395 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/smtp"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/Renespeare/mailpulse/relay/internal/webhooks"
+)
+
+// SendEmailRequest represents an email submitted through the HTTP send API
+type SendEmailRequest struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text"`
+	HTML    string   `json:"html"`
+	SendAt  string   `json:"send_at"` // optional RFC 3339 time to schedule the email for
+}
+
+// sendEmailHandler accepts an email over HTTP using the same credentials as SMTP AUTH
+// (HTTP Basic: API key as username, project password as password)
+func (s *Server) sendEmailHandler(w http.ResponseWriter, r *http.Request) {
+	clientIP := requestClientIP(r)
+	
+	// Check rate limit for auth attempts
+	if err := s.rateLimiter.CheckAuthAttempt(clientIP); err != nil {
+		log.Printf("Rate limit exceeded for auth attempts from %s: %v", clientIP, err)
+		http.Error(w, "Too many authentication attempts", http.StatusTooManyRequests)
+		return
+	}
+	
+	apiKey, password, ok := r.BasicAuth()
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="mailpulse"`)
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	
+	project, err := s.authManager.ValidateAPIKey(apiKey, password)
+	if err != nil {
+		log.Printf("API authentication failed from %s: %v", clientIP, err)
+		s.recordAuditLog(r, "api_auth_failed", nil, map[string]interface{}{
+			"username": apiKey,
+			"method":   "HTTP Basic",
+			"reason":   "invalid_credentials",
+		})
+		w.Header().Set("WWW-Authenticate", `Basic realm="mailpulse"`)
+		http.Error(w, "Authentication failed", http.StatusUnauthorized)
+		return
+	}
+	
+	// Check IP allowlist if required
+	if project.RequireIPAllow && !s.authManager.IsIPAllowed(project.ID, clientIP) {
+		log.Printf("IP %s not allowed for project %s", clientIP, project.ID)
+		s.recordAuditLog(r, "api_ip_blocked", &project.ID, map[string]interface{}{
+			"method":    "HTTP Basic",
+			"reason":    "ip_not_allowed",
+			"client_ip": clientIP,
+		})
+		http.Error(w, "IP not authorized", http.StatusForbidden)
+		return
+	}
+	
+	var req SendEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	// Header values must stay on one line, or they could inject extra headers
+	if hasLineBreak(req.From) || hasLineBreak(req.Subject) {
+		http.Error(w, "Line breaks are not allowed in from or subject", http.StatusBadRequest)
+		return
+	}
+	for _, to := range req.To {
+		if hasLineBreak(to) {
+			http.Error(w, "Line breaks are not allowed in recipient addresses", http.StatusBadRequest)
+			return
+		}
+	}
+	
+	if _, err := mail.ParseAddress(req.From); err != nil {
+		http.Error(w, "Invalid from address", http.StatusBadRequest)
+		return
+	}
+	if from := envelopeAddress(req.From); !s.authManager.IsSenderAllowed(project.ID, from) {
+		log.Printf("❌ Sender %s not allowed for project %s", from, project.ID)
+		s.recordAuditLog(r, "api_sender_rejected", &project.ID, map[string]interface{}{
+			"from":   from,
+			"reason": "sender_not_allowed",
+		})
+		http.Error(w, "Sender address not allowed for this project", http.StatusForbidden)
+		return
+	}
+	if len(req.To) == 0 {
+		http.Error(w, "At least one recipient is required", http.StatusBadRequest)
+		return
+	}
+	for _, to := range req.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid recipient address %q", to), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Text == "" && req.HTML == "" {
+		http.Error(w, "Either text or html body is required", http.StatusBadRequest)
+		return
+	}
+	if req.Subject == "" {
+		req.Subject = "No Subject"
+	}
+	var sendAt *time.Time
+	if req.SendAt != "" {
+		requested, err := smtp.ParseSendAt(req.SendAt)
+		if err == nil {
+			sendAt, err = smtp.ScheduleTime(requested)
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid send_at: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	
+	// Refuse, or silently drop, recipients on the project's suppression list
+	recipients, suppressed, err := s.filterSuppressed(project.ID, envelopeAddresses(req.To))
+	if err != nil {
+		log.Printf("Failed to check suppression list for project %s: %v", project.ID, err)
+		http.Error(w, "Failed to check suppression list", http.StatusInternalServerError)
+		return
+	}
+	if len(suppressed) > 0 && recipients == nil {
+		s.recordAuditLog(r, "api_recipient_suppressed", &project.ID, map[string]interface{}{
+			"from":       req.From,
+			"suppressed": suppressed,
+			"mode":       storage.SuppressionModeReject,
+		})
+		http.Error(w, fmt.Sprintf("Recipient addresses on the suppression list: %s", strings.Join(suppressed, ", ")), http.StatusUnprocessableEntity)
+		return
+	}
+	if len(suppressed) > 0 {
+		s.recordAuditLog(r, "api_recipient_suppressed", &project.ID, map[string]interface{}{
+			"from":       req.From,
+			"suppressed": suppressed,
+			"mode":       storage.SuppressionModeDrop,
+		})
+	}
+	if len(recipients) == 0 {
+		// Every recipient was dropped: nothing to send
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":    true,
+			"status":     "suppressed",
+			"suppressed": suppressed,
+		})
+		return
+	}
+	
+	// Check email quotas before processing; scheduled emails are checked when they are due
+	if sendAt == nil {
+		if err := s.storage.CheckQuotaLimits(project.ID, req.From); err != nil {
+			log.Printf("Email quota exceeded for project %s: %v", project.ID, err)
+			s.recordAuditLog(r, "email_quota_exceeded", &project.ID, map[string]interface{}{
+				"from":   req.From,
+				"to":     req.To,
+				"reason": "quota_limit_exceeded",
+			})
+			http.Error(w, "Quota exceeded", http.StatusTooManyRequests)
+			return
+		}
+	}
+	
+	// Generate unique message ID
+	messageID := fmt.Sprintf("%d@mailpulse", time.Now().UnixNano())
+	
+	content, err := buildRawMessage(&req, messageID)
+	if err != nil {
+		log.Printf("Failed to build message: %v", err)
+		http.Error(w, "Failed to build message", http.StatusInternalServerError)
+		return
+	}
+	
+	email := &storage.Email{
+		ID:         fmt.Sprintf("email_%d", time.Now().UnixNano()),
+		MessageID:  messageID,
+		ProjectID:  project.ID,
+		From:       envelopeAddress(req.From),
+		To:         recipients,
+		Subject:    req.Subject,
+		ContentEnc: content,
+		Size:       len(content),
+		Status:     "processed",
+		Attempts:   1,
+		SentAt:     time.Now(),
+	}
+	if sendAt != nil {
+		email.Status = storage.EmailStatusScheduled
+		email.ScheduledAt = sendAt
+	}
+	
+	if err := s.storage.StoreEmail(email); err != nil {
+		log.Printf("❌ Failed to store email in database: %v", err)
+		http.Error(w, "Failed to store email", http.StatusInternalServerError)
+		return
+	}
+	
+	auditDetails := map[string]interface{}{
+		"message_id": messageID,
+		"from":       email.From,
+		"to":         email.To,
+		"subject":    email.Subject,
+		"size":       email.Size,
+		"source":     "http",
+	}
+	if sendAt != nil {
+		auditDetails["scheduled_at"] = sendAt.Format(time.RFC3339)
+	}
+	s.recordAuditLog(r, "email_processed", &project.ID, auditDetails)
+	
+	s.forwarder.Events().Emit(webhooks.EventAccepted, project.ID, email, map[string]interface{}{
+		"source": "http",
+	})
+	
+	response := map[string]interface{}{
+		"success":   true,
+		"emailId":   email.ID,
+		"messageId": messageID,
+		"status":    email.Status,
+	}
+	if sendAt != nil {
+		// The scheduler forwards the email once it is due
+		response["sendAt"] = sendAt
+	} else {
+		// Forward to upstream asynchronously; the stored email counts towards quotas
+		go s.forwarder.Deliver(email)
+	}
+	if len(suppressed) > 0 {
+		response["suppressed"] = suppressed
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// filterSuppressed checks recipients against the project's suppression list.
+// In drop mode the suppressed addresses are removed from recipients; in reject
+// mode recipients is nil when any address is suppressed.
+func (s *Server) filterSuppressed(projectID string, recipients []string) ([]string, []string, error) {
+	suppressions, err := s.storage.FindSuppressions(projectID, recipients)
+	if err != nil || len(suppressions) == 0 {
+		return recipients, nil, err
+	}
+	
+	project, err := s.storage.GetProject(projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	isSuppressed := make(map[string]bool, len(suppressions))
+	for _, suppression := range suppressions {
+		isSuppressed[suppression.Address] = true
+	}
+	
+	var allowed, suppressed []string
+	for _, recipient := range recipients {
+		if isSuppressed[strings.ToLower(recipient)] {
+			suppressed = append(suppressed, recipient)
+		} else {
+			allowed = append(allowed, recipient)
+		}
+	}
+	
+	if project.SuppressionMode != storage.SuppressionModeDrop {
+		return nil, suppressed, nil
+	}
+	if allowed == nil {
+		allowed = []string{}
+	}
+	return allowed, suppressed, nil
+}
+
+// hasLineBreak reports whether a header value contains a CR or LF
+func hasLineBreak(value string) bool {
+	return strings.ContainsAny(value, "\r\n")
+}
+
+// buildRawMessage renders a send API request as an RFC 5322 message
+func buildRawMessage(req *SendEmailRequest, messageID string) ([]byte, error) {
+	var buf bytes.Buffer
+	
+	fmt.Fprintf(&buf, "From: %s\r\n", req.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(req.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", req.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s>\r\n", messageID)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	
+	switch {
+	case req.Text != "" && req.HTML != "":
+		writer := multipart.NewWriter(&buf)
+		fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", writer.Boundary())
+		for _, part := range []struct{ contentType, body string }{
+			{"text/plain; charset=UTF-8", req.Text},
+			{"text/html; charset=UTF-8", req.HTML},
+		} {
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Type", part.contentType)
+			header.Set("Content-Transfer-Encoding", "quoted-printable")
+			partWriter, err := writer.CreatePart(header)
+			if err != nil {
+				return nil, err
+			}
+			if err := writeQuotedPrintable(partWriter, part.body); err != nil {
+				return nil, err
+			}
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+	case req.HTML != "":
+		buf.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, req.HTML); err != nil {
+			return nil, err
+		}
+	default:
+		buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, req.Text); err != nil {
+			return nil, err
+		}
+	}
+	
+	return buf.Bytes(), nil
+}
+
+// writeQuotedPrintable writes a body part using quoted-printable encoding
+func writeQuotedPrintable(w interface{ Write([]byte) (int, error) }, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// envelopeAddress extracts the bare address from a display-name address
+func envelopeAddress(address string) string {
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		return parsed.Address
+	}
+	return address
+}
+
+// envelopeAddresses extracts bare addresses from a list of display-name addresses
+func envelopeAddresses(addresses []string) []string {
+	result := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		result = append(result, envelopeAddress(address))
+	}
+	return result
+}
+
+// requestClientIP returns the caller's IP for access checks.
+// X-Forwarded-For is only honoured when TRUST_PROXY=true, and only its last
+// entry - the one appended by the trusted proxy - is used, since the client
+// controls everything before it.
+func requestClientIP(r *http.Request) string {
+	if os.Getenv("TRUST_PROXY") == "true" {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			entries := strings.Split(forwarded[len(forwarded)-1], ",")
+			if clientIP := strings.TrimSpace(entries[len(entries)-1]); clientIP != "" {
+				return clientIP
+			}
+		}
+	}
+	
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/auth"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// listSenderRulesHandler returns the allowed sender patterns for a project
+func (s *Server) listSenderRulesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	rules, err := s.storage.ListSenderRules(projectID)
+	if err != nil {
+		log.Printf("Failed to list sender rules for project %s: %v", projectID, err)
+		http.Error(w, "Failed to list sender rules", http.StatusInternalServerError)
+		return
+	}
+	
+	response := map[string]interface{}{
+		"projectId":    projectID,
+		"unrestricted": len(rules) == 0,
+		"rules":        rules,
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// addSenderRuleHandler adds an allowed sender address, domain or wildcard domain
+func (s *Server) addSenderRuleHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	var req struct {
+		Pattern string `json:"pattern"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	pattern, err := auth.NormalizeSenderPattern(req.Pattern)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	rule := &storage.SenderRule{
+		ID:        generateID(),
+		ProjectID: projectID,
+		Pattern:   pattern,
+		CreatedAt: time.Now(),
+	}
+	
+	if err := s.storage.AddSenderRule(rule); err != nil {
+		log.Printf("Failed to add sender rule for project %s: %v", projectID, err)
+		http.Error(w, "Failed to add sender rule (it may already exist)", http.StatusConflict)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_sender_added", &projectID, map[string]interface{}{
+		"rule_id": rule.ID,
+		"pattern": rule.Pattern,
+	})
+	
+	s.reloadAuthProjects()
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// deleteSenderRuleHandler removes an allowed sender pattern from a project
+func (s *Server) deleteSenderRuleHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	ruleID := vars["ruleId"]
+	
+	if err := s.storage.DeleteSenderRule(projectID, ruleID); err != nil {
+		log.Printf("Failed to delete sender rule %s for project %s: %v", ruleID, projectID, err)
+		http.Error(w, "Sender rule not found", http.StatusNotFound)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_sender_removed", &projectID, map[string]interface{}{
+		"rule_id": ruleID,
+	})
+	
+	s.reloadAuthProjects()
+	
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Sender rule removed",
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
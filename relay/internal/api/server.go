@@ -9,9 +9,12 @@ import (
 	"time"
 
 	"github.com/Renespeare/mailpulse/relay/internal/auth"
+	"github.com/Renespeare/mailpulse/relay/internal/domains"
+	"github.com/Renespeare/mailpulse/relay/internal/jobs"
 	"github.com/Renespeare/mailpulse/relay/internal/security"
 	"github.com/Renespeare/mailpulse/relay/internal/smtp"
 	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/Renespeare/mailpulse/relay/internal/tracking"
 	"github.com/gorilla/mux"
 )
 
@@ -21,16 +24,20 @@ type Server struct {
 	storage     storage.Storage
 	rateLimiter security.RateLimiter
 	forwarder   *smtp.EmailForwarder
+	domainVerifier *domains.Verifier
+	jobRunner   *jobs.Runner
 	router      *mux.Router
 }
 
 // NewServer creates a new API server
-func NewServer(authManager auth.AuthManager, storage storage.Storage, rateLimiter security.RateLimiter) *Server {
+func NewServer(authManager auth.AuthManager, storage storage.Storage, rateLimiter security.RateLimiter, forwarder *smtp.EmailForwarder, domainVerifier *domains.Verifier, jobRunner *jobs.Runner) *Server {
 	s := &Server{
 		authManager: authManager,
 		storage:     storage,
 		rateLimiter: rateLimiter,
-		forwarder:   smtp.NewEmailForwarder(authManager, storage),
+		forwarder:   forwarder,
+		domainVerifier: domainVerifier,
+		jobRunner:   jobRunner,
 		router:      mux.NewRouter(),
 	}
 	
@@ -54,6 +61,18 @@ func (s *Server) setupRoutes() {
 	s.router.HandleFunc("/api/admin/verify", s.handleAdminVerify).Methods("GET")
 	s.router.HandleFunc("/api/admin/verify", s.handleOptions).Methods("OPTIONS")
 	
+	// Send API (authenticated with project API key and password)
+	s.router.HandleFunc("/api/send", s.sendEmailHandler).Methods("POST")
+	s.router.HandleFunc("/api/send", s.handleOptions).Methods("OPTIONS")
+	
+	// Open and click tracking (signed per-email tokens)
+	s.router.HandleFunc(tracking.OpenPath+"{token}", s.openPixelHandler).Methods("GET")
+	s.router.HandleFunc(tracking.ClickPath+"{token}", s.clickHandler).Methods("GET")
+	
+	// One-click unsubscribe (RFC 8058 List-Unsubscribe-Post)
+	s.router.HandleFunc(tracking.UnsubscribePath+"{token}", s.unsubscribeFormHandler).Methods("GET")
+	s.router.HandleFunc(tracking.UnsubscribePath+"{token}", s.unsubscribeHandler).Methods("POST")
+	
 	// Protected routes (require admin authentication)
 	
 	// Quota usage
@@ -70,6 +89,30 @@ func (s *Server) setupRoutes() {
 	s.router.HandleFunc("/api/emails/{emailId}/resend", s.adminAuthMiddleware(s.resendEmailHandler)).Methods("POST")
 	s.router.HandleFunc("/api/emails/{emailId}/resend", s.handleOptions).Methods("OPTIONS")
 	
+	// Bulk operations run as background jobs (registered before the {emailId} routes)
+	s.router.HandleFunc("/api/emails/bulk/retry", s.adminAuthMiddleware(s.bulkRetryHandler)).Methods("POST")
+	s.router.HandleFunc("/api/emails/bulk/retry", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/emails/bulk/cancel", s.adminAuthMiddleware(s.bulkCancelHandler)).Methods("POST")
+	s.router.HandleFunc("/api/emails/bulk/cancel", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/jobs", s.adminAuthMiddleware(s.listJobsHandler)).Methods("GET")
+	s.router.HandleFunc("/api/jobs", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/jobs/{jobId}", s.adminAuthMiddleware(s.getJobHandler)).Methods("GET")
+	s.router.HandleFunc("/api/jobs/{jobId}", s.handleOptions).Methods("OPTIONS")
+	
+	// Recipient domain throttling
+	s.router.HandleFunc("/api/throttle", s.adminAuthMiddleware(s.domainThrottleHandler)).Methods("GET")
+	s.router.HandleFunc("/api/throttle", s.handleOptions).Methods("OPTIONS")
+	
+	// Queued and scheduled emails
+	s.router.HandleFunc("/api/emails/{emailId}/cancel", s.adminAuthMiddleware(s.cancelEmailHandler)).Methods("POST")
+	s.router.HandleFunc("/api/emails/{emailId}/cancel", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/emails/{emailId}/schedule", s.adminAuthMiddleware(s.rescheduleEmailHandler)).Methods("PATCH")
+	s.router.HandleFunc("/api/emails/{emailId}/schedule", s.handleOptions).Methods("OPTIONS")
+	
+	// Email detail with per-recipient status
+	s.router.HandleFunc("/api/emails/{emailId}", s.adminAuthMiddleware(s.getEmailHandler)).Methods("GET")
+	s.router.HandleFunc("/api/emails/{emailId}", s.handleOptions).Methods("OPTIONS")
+	
 	// Projects
 	s.router.HandleFunc("/api/projects", s.adminAuthMiddleware(s.listProjectsHandler)).Methods("GET")
 	s.router.HandleFunc("/api/projects", s.adminAuthMiddleware(s.createProjectHandler)).Methods("POST")
@@ -80,6 +123,97 @@ func (s *Server) setupRoutes() {
 	s.router.HandleFunc("/api/projects/{projectId}", s.adminAuthMiddleware(s.deleteProjectHandler)).Methods("DELETE")
 	s.router.HandleFunc("/api/projects/{projectId}", s.handleOptions).Methods("OPTIONS")
 	
+	// Project IP allowlist
+	s.router.HandleFunc("/api/projects/{projectId}/allowlist", s.adminAuthMiddleware(s.listAllowlistHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/allowlist", s.adminAuthMiddleware(s.addAllowlistEntryHandler)).Methods("POST")
+	s.router.HandleFunc("/api/projects/{projectId}/allowlist", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/allowlist/{entryId}", s.adminAuthMiddleware(s.deleteAllowlistEntryHandler)).Methods("DELETE")
+	s.router.HandleFunc("/api/projects/{projectId}/allowlist/{entryId}", s.handleOptions).Methods("OPTIONS")
+	
+	// Project allowed senders
+	s.router.HandleFunc("/api/projects/{projectId}/senders", s.adminAuthMiddleware(s.listSenderRulesHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/senders", s.adminAuthMiddleware(s.addSenderRuleHandler)).Methods("POST")
+	s.router.HandleFunc("/api/projects/{projectId}/senders", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/senders/{ruleId}", s.adminAuthMiddleware(s.deleteSenderRuleHandler)).Methods("DELETE")
+	s.router.HandleFunc("/api/projects/{projectId}/senders/{ruleId}", s.handleOptions).Methods("OPTIONS")
+	
+	// Project sender domains (DNS TXT verification)
+	s.router.HandleFunc("/api/projects/{projectId}/domains", s.adminAuthMiddleware(s.listDomainsHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/domains", s.adminAuthMiddleware(s.addDomainHandler)).Methods("POST")
+	s.router.HandleFunc("/api/projects/{projectId}/domains", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/domains/{domainId}", s.adminAuthMiddleware(s.deleteDomainHandler)).Methods("DELETE")
+	s.router.HandleFunc("/api/projects/{projectId}/domains/{domainId}", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/domains/{domainId}/verify", s.adminAuthMiddleware(s.verifyDomainHandler)).Methods("POST")
+	s.router.HandleFunc("/api/projects/{projectId}/domains/{domainId}/verify", s.handleOptions).Methods("OPTIONS")
+	
+	// Project warm-up plans
+	s.router.HandleFunc("/api/projects/{projectId}/warmup", s.adminAuthMiddleware(s.listWarmupPlansHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/warmup", s.adminAuthMiddleware(s.addWarmupPlanHandler)).Methods("POST")
+	s.router.HandleFunc("/api/projects/{projectId}/warmup", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/warmup/{planId}", s.adminAuthMiddleware(s.deleteWarmupPlanHandler)).Methods("DELETE")
+	s.router.HandleFunc("/api/projects/{projectId}/warmup/{planId}", s.handleOptions).Methods("OPTIONS")
+	
+	// Project upstream relays (failover)
+	s.router.HandleFunc("/api/projects/{projectId}/upstreams", s.adminAuthMiddleware(s.listUpstreamsHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/upstreams", s.adminAuthMiddleware(s.addUpstreamHandler)).Methods("POST")
+	s.router.HandleFunc("/api/projects/{projectId}/upstreams", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/upstreams/{upstreamId}", s.adminAuthMiddleware(s.updateUpstreamHandler)).Methods("PATCH")
+	s.router.HandleFunc("/api/projects/{projectId}/upstreams/{upstreamId}", s.adminAuthMiddleware(s.deleteUpstreamHandler)).Methods("DELETE")
+	s.router.HandleFunc("/api/projects/{projectId}/upstreams/{upstreamId}", s.handleOptions).Methods("OPTIONS")
+	
+	// Project sandbox inbox (captured messages)
+	s.router.HandleFunc("/api/projects/{projectId}/inbox", s.adminAuthMiddleware(s.listInboxHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/inbox", s.adminAuthMiddleware(s.clearInboxHandler)).Methods("DELETE")
+	s.router.HandleFunc("/api/projects/{projectId}/inbox", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/inbox/{messageId}", s.adminAuthMiddleware(s.getInboxMessageHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/inbox/{messageId}", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/inbox/{messageId}/html", s.adminAuthMiddleware(s.getInboxMessageHTMLHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/inbox/{messageId}/html", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/inbox/{messageId}/text", s.adminAuthMiddleware(s.getInboxMessageTextHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/inbox/{messageId}/text", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/inbox/{messageId}/raw", s.adminAuthMiddleware(s.getInboxMessageRawHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/inbox/{messageId}/raw", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/inbox/{messageId}/attachments/{index}", s.adminAuthMiddleware(s.getInboxAttachmentHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/inbox/{messageId}/attachments/{index}", s.handleOptions).Methods("OPTIONS")
+	
+	// Project suppression list
+	s.router.HandleFunc("/api/projects/{projectId}/suppressions", s.adminAuthMiddleware(s.listSuppressionsHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/suppressions", s.adminAuthMiddleware(s.addSuppressionHandler)).Methods("POST")
+	s.router.HandleFunc("/api/projects/{projectId}/suppressions", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/suppressions/import", s.adminAuthMiddleware(s.importSuppressionsHandler)).Methods("POST")
+	s.router.HandleFunc("/api/projects/{projectId}/suppressions/import", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/suppressions/export", s.adminAuthMiddleware(s.exportSuppressionsHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/suppressions/export", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/suppressions/{suppressionId}", s.adminAuthMiddleware(s.deleteSuppressionHandler)).Methods("DELETE")
+	s.router.HandleFunc("/api/projects/{projectId}/suppressions/{suppressionId}", s.handleOptions).Methods("OPTIONS")
+	
+	// Project link click stats
+	s.router.HandleFunc("/api/projects/{projectId}/links", s.adminAuthMiddleware(s.projectLinksHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/links", s.handleOptions).Methods("OPTIONS")
+	
+	// Project webhooks (email lifecycle events)
+	s.router.HandleFunc("/api/projects/{projectId}/webhooks", s.adminAuthMiddleware(s.listWebhooksHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/webhooks", s.adminAuthMiddleware(s.addWebhookHandler)).Methods("POST")
+	s.router.HandleFunc("/api/projects/{projectId}/webhooks", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/webhooks/deliveries", s.adminAuthMiddleware(s.listWebhookDeliveriesHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/webhooks/deliveries", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/webhooks/deliveries/{deliveryId}/redeliver", s.adminAuthMiddleware(s.redeliverWebhookDeliveryHandler)).Methods("POST")
+	s.router.HandleFunc("/api/projects/{projectId}/webhooks/deliveries/{deliveryId}/redeliver", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/webhooks/dead-letters", s.adminAuthMiddleware(s.deadLetterHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/webhooks/dead-letters", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/webhooks/{webhookId}", s.adminAuthMiddleware(s.updateWebhookHandler)).Methods("PATCH")
+	s.router.HandleFunc("/api/projects/{projectId}/webhooks/{webhookId}", s.adminAuthMiddleware(s.deleteWebhookHandler)).Methods("DELETE")
+	s.router.HandleFunc("/api/projects/{projectId}/webhooks/{webhookId}", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/webhooks/{webhookId}/redeliver", s.adminAuthMiddleware(s.redeliverWebhookHandler)).Methods("POST")
+	s.router.HandleFunc("/api/projects/{projectId}/webhooks/{webhookId}/redeliver", s.handleOptions).Methods("OPTIONS")
+	
+	// Project DKIM keys
+	s.router.HandleFunc("/api/projects/{projectId}/dkim", s.adminAuthMiddleware(s.listDKIMKeysHandler)).Methods("GET")
+	s.router.HandleFunc("/api/projects/{projectId}/dkim", s.adminAuthMiddleware(s.addDKIMKeyHandler)).Methods("POST")
+	s.router.HandleFunc("/api/projects/{projectId}/dkim", s.handleOptions).Methods("OPTIONS")
+	s.router.HandleFunc("/api/projects/{projectId}/dkim/{keyId}", s.adminAuthMiddleware(s.deleteDKIMKeyHandler)).Methods("DELETE")
+	s.router.HandleFunc("/api/projects/{projectId}/dkim/{keyId}", s.handleOptions).Methods("OPTIONS")
+	
 	// Emails
 	s.router.HandleFunc("/api/emails", s.adminAuthMiddleware(s.listEmailsHandler)).Methods("GET")
 	s.router.HandleFunc("/api/emails", s.handleOptions).Methods("OPTIONS")
@@ -143,20 +277,96 @@ func (a *StorageAdapter) ListAllProjects() ([]*auth.StorageProject, error) {
 	
 	var authProjects []*auth.StorageProject
 	for _, p := range projects {
-		authProjects = append(authProjects, &auth.StorageProject{
-			ID:             p.ID,
-			Name:           p.Name,
-			APIKeyEnc:      p.APIKeyEnc,
-			PasswordHash:   p.PasswordHash,
-			QuotaDaily:     p.QuotaDaily,
-			QuotaPerMinute: p.QuotaPerMinute,
-			Status:         p.Status,
-		})
+		authProject, err := a.withAccessLists(toAuthStorageProject(p))
+		if err != nil {
+			return nil, err
+		}
+		authProjects = append(authProjects, authProject)
 	}
 	
 	return authProjects, nil
 }
 
+func (a *StorageAdapter) GetProject(id string) (*auth.StorageProject, error) {
+	project, err := a.storage.GetProject(id)
+	if err != nil {
+		return nil, err
+	}
+	
+	return a.withAccessLists(toAuthStorageProject(project))
+}
+
+func (a *StorageAdapter) GetProjectByAPIKeyDigest(digest string) (*auth.StorageProject, error) {
+	project, err := a.storage.GetProjectByAPIKeyDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+	
+	return a.withAccessLists(toAuthStorageProject(project))
+}
+
+// withAccessLists loads the project's IP allowlist, sender rules and sender domains into the auth project
+func (a *StorageAdapter) withAccessLists(project *auth.StorageProject) (*auth.StorageProject, error) {
+	entries, err := a.storage.ListIPAllowlist(project.ID)
+	if err != nil {
+		return nil, err
+	}
+	
+	for _, entry := range entries {
+		project.AllowedIPs = append(project.AllowedIPs, entry.CIDR)
+	}
+	
+	rules, err := a.storage.ListSenderRules(project.ID)
+	if err != nil {
+		return nil, err
+	}
+	
+	for _, rule := range rules {
+		project.AllowedSenders = append(project.AllowedSenders, rule.Pattern)
+	}
+	
+	domains, err := a.storage.ListSenderDomains(project.ID)
+	if err != nil {
+		return nil, err
+	}
+	
+	if len(domains) > 0 {
+		project.SenderDomains = make(map[string]string, len(domains))
+		for _, domain := range domains {
+			project.SenderDomains[domain.Domain] = domain.Status
+		}
+	}
+	
+	return project, nil
+}
+
+// toAuthStorageProject converts a storage.Project to the auth package representation
+func toAuthStorageProject(p *storage.Project) *auth.StorageProject {
+	apiKeyDigest := ""
+	if p.APIKeyDigest != nil {
+		apiKeyDigest = *p.APIKeyDigest
+	}
+	
+	return &auth.StorageProject{
+		ID:              p.ID,
+		Name:            p.Name,
+		APIKeyEnc:       p.APIKeyEnc,
+		APIKeyDigest:    apiKeyDigest,
+		PasswordHash:    p.PasswordHash,
+		SMTPHost:        p.SMTPHost,
+		SMTPPort:        p.SMTPPort,
+		SMTPUser:        p.SMTPUser,
+		SMTPPasswordEnc: p.SMTPPasswordEnc,
+		QuotaDaily:      p.QuotaDaily,
+		QuotaPerMinute:  p.QuotaPerMinute,
+		Status:          p.Status,
+		RequireIPAllow:  p.RequireIPAllow,
+		UserID:          p.UserID,
+		CreatedAt:       p.CreatedAt,
+		LastUsedAt:      p.LastUsedAt,
+	}
+}
+
 // recordAuditLog records an audit log entry for API operations
 func (s *Server) recordAuditLog(r *http.Request, action string, projectID *string, details map[string]interface{}) {
 	// Generate unique audit log ID
@@ -223,17 +433,55 @@ func (s *Server) Start(addr string) error {
 	log.Printf("   POST %s/api/admin/login - Admin authentication (public)", addr)
 	log.Printf("   POST %s/api/admin/logout - Admin logout (public)", addr)
 	log.Printf("   GET %s/api/admin/verify - Verify admin token (public)", addr)
+	log.Printf("   POST %s/api/send - Send email (project API key + password)", addr)
+	log.Printf("   GET %s%s{token} - Open tracking pixel (public)", addr, tracking.OpenPath)
+	log.Printf("   GET %s%s{token} - Click tracking redirect (public)", addr, tracking.ClickPath)
+	log.Printf("   POST %s%s{token} - One-click unsubscribe (public)", addr, tracking.UnsubscribePath)
 	log.Printf("   🔐 Protected endpoints (require admin authentication):")
 	log.Printf("   GET %s/api/projects - List all projects", addr)
 	log.Printf("   POST %s/api/projects - Create new project", addr)
 	log.Printf("   GET %s/api/projects/{projectId} - Get specific project", addr)
 	log.Printf("   PATCH %s/api/projects/{projectId} - Update project", addr)
 	log.Printf("   DELETE %s/api/projects/{projectId} - Delete project", addr)
+	log.Printf("   GET/POST %s/api/projects/{projectId}/allowlist - Project IP allowlist", addr)
+	log.Printf("   DELETE %s/api/projects/{projectId}/allowlist/{entryId} - Remove allowlist entry", addr)
+	log.Printf("   GET/POST %s/api/projects/{projectId}/senders - Project allowed senders", addr)
+	log.Printf("   DELETE %s/api/projects/{projectId}/senders/{ruleId} - Remove allowed sender", addr)
+	log.Printf("   GET/POST %s/api/projects/{projectId}/domains - Project sender domains", addr)
+	log.Printf("   POST %s/api/projects/{projectId}/domains/{domainId}/verify - Verify sender domain", addr)
+	log.Printf("   DELETE %s/api/projects/{projectId}/domains/{domainId} - Remove sender domain", addr)
+	log.Printf("   GET/POST %s/api/projects/{projectId}/warmup - Project warm-up plans", addr)
+	log.Printf("   DELETE %s/api/projects/{projectId}/warmup/{planId} - Remove warm-up plan", addr)
+	log.Printf("   GET/POST %s/api/projects/{projectId}/upstreams - Project upstream relays", addr)
+	log.Printf("   PATCH/DELETE %s/api/projects/{projectId}/upstreams/{upstreamId} - Update or remove upstream relay", addr)
+	log.Printf("   GET/DELETE %s/api/projects/{projectId}/inbox - List or clear captured sandbox messages", addr)
+	log.Printf("   GET %s/api/projects/{projectId}/inbox/{messageId} - Captured message (also /html, /text, /raw, /attachments/{index})", addr)
+	log.Printf("   GET/POST %s/api/projects/{projectId}/suppressions - Project suppression list", addr)
+	log.Printf("   POST %s/api/projects/{projectId}/suppressions/import - Import suppressions from CSV", addr)
+	log.Printf("   GET %s/api/projects/{projectId}/suppressions/export - Export suppressions as CSV", addr)
+	log.Printf("   DELETE %s/api/projects/{projectId}/suppressions/{suppressionId} - Remove suppression", addr)
+	log.Printf("   GET %s/api/projects/{projectId}/links - Project link click stats", addr)
+	log.Printf("   GET/POST %s/api/projects/{projectId}/webhooks - Project webhook endpoints", addr)
+	log.Printf("   PATCH/DELETE %s/api/projects/{projectId}/webhooks/{webhookId} - Update or remove webhook", addr)
+	log.Printf("   GET %s/api/projects/{projectId}/webhooks/deliveries - Webhook delivery log", addr)
+	log.Printf("   GET %s/api/projects/{projectId}/webhooks/dead-letters - Failing webhooks and dead-lettered deliveries", addr)
+	log.Printf("   POST %s/api/projects/{projectId}/webhooks/deliveries/{deliveryId}/redeliver - Redeliver webhook event", addr)
+	log.Printf("   POST %s/api/projects/{projectId}/webhooks/{webhookId}/redeliver - Redeliver dead-lettered events", addr)
+	log.Printf("   GET/POST %s/api/projects/{projectId}/dkim - Project DKIM keys", addr)
+	log.Printf("   DELETE %s/api/projects/{projectId}/dkim/{keyId} - Remove DKIM key", addr)
 	log.Printf("   GET %s/api/quota/{projectId} - Quota usage", addr)
 	log.Printf("   GET %s/api/emails - List all emails", addr)
 	log.Printf("   GET %s/api/emails/stats - All email statistics", addr)
 	log.Printf("   GET %s/api/emails/stats/{projectId} - Email statistics", addr)
-	log.Printf("   POST %s/api/emails/{emailId}/resend - Resend email", addr)
+	log.Printf("   GET %s/api/emails/{emailId} - Email with recipient statuses", addr)
+	log.Printf("   POST %s/api/emails/{emailId}/resend - Resend email to failed recipients", addr)
+	log.Printf("   POST %s/api/emails/{emailId}/cancel - Cancel queued or scheduled email", addr)
+	log.Printf("   POST %s/api/emails/bulk/retry - Retry failed emails by filter", addr)
+	log.Printf("   POST %s/api/emails/bulk/cancel - Cancel queued emails by filter", addr)
+	log.Printf("   GET %s/api/jobs - Background jobs", addr)
+	log.Printf("   GET %s/api/jobs/{jobId} - Background job progress", addr)
+	log.Printf("   GET %s/api/throttle - Recipient domain limits and queue depth", addr)
+	log.Printf("   PATCH %s/api/emails/{emailId}/schedule - Reschedule email", addr)
 	log.Printf("   GET %s/api/audit - All audit logs", addr)
 	log.Printf("   GET %s/api/audit/{projectId} - Project audit logs", addr)
 	
@@ -0,0 +1,293 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// maxSuppressionImportSize limits the size of a CSV suppression import
+const maxSuppressionImportSize = 10 << 20
+
+// listSuppressionsHandler lists a project's suppressed addresses, newest
+// first, optionally only those containing ?search=
+func (s *Server) listSuppressionsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	limit := 50 // default page size
+	offset := 0 // default offset
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 500 {
+			limit = l
+		}
+	}
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+	search := strings.TrimSpace(r.URL.Query().Get("search"))
+	
+	suppressions, totalCount, err := s.storage.ListSuppressions(projectID, search, limit, offset)
+	if err != nil {
+		log.Printf("Failed to list suppressions for project %s: %v", projectID, err)
+		http.Error(w, "Failed to list suppressions", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"suppressions": suppressions,
+		"totalCount":   totalCount,
+		"limit":        limit,
+		"offset":       offset,
+		"hasMore":      offset+len(suppressions) < totalCount,
+	})
+}
+
+// addSuppressionHandler suppresses an address by hand
+func (s *Server) addSuppressionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	var req struct {
+		Address string `json:"address"`
+		Reason  string `json:"reason"`
+		Source  string `json:"source,omitempty"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	address, ok := suppressionAddress(req.Address)
+	if !ok {
+		http.Error(w, "Invalid email address", http.StatusBadRequest)
+		return
+	}
+	
+	source := req.Source
+	if source == "" {
+		source = storage.SuppressionSourceManual
+	}
+	if !validSuppressionSource(source) {
+		http.Error(w, "Invalid source (use manual, import, bounce, complaint or unsubscribe)", http.StatusBadRequest)
+		return
+	}
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	suppression := &storage.Suppression{
+		ID:        generateID(),
+		ProjectID: projectID,
+		Address:   address,
+		Reason:    strings.TrimSpace(req.Reason),
+		Source:    source,
+		CreatedAt: time.Now(),
+	}
+	
+	if err := s.storage.AddSuppression(suppression); err != nil {
+		log.Printf("Failed to add suppression for project %s: %v", projectID, err)
+		http.Error(w, "Failed to add suppression", http.StatusInternalServerError)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_suppression_added", &projectID, map[string]interface{}{
+		"suppression_id": suppression.ID,
+		"address":        suppression.Address,
+		"source":         suppression.Source,
+	})
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(suppression)
+}
+
+// deleteSuppressionHandler removes an address from a project's suppression list
+func (s *Server) deleteSuppressionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	suppressionID := vars["suppressionId"]
+	
+	if err := s.storage.DeleteSuppression(projectID, suppressionID); err != nil {
+		log.Printf("Failed to delete suppression %s for project %s: %v", suppressionID, projectID, err)
+		http.Error(w, "Suppression not found", http.StatusNotFound)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_suppression_removed", &projectID, map[string]interface{}{
+		"suppression_id": suppressionID,
+	})
+	
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Suppression removed",
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// importSuppressionsHandler adds the addresses of a CSV body (address and an
+// optional reason per row, header row optional) to a project's suppression list
+func (s *Server) importSuppressionsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	reader := csv.NewReader(http.MaxBytesReader(w, r.Body, maxSuppressionImportSize))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	
+	imported := 0
+	invalid := []string{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid CSV: %v", err), http.StatusBadRequest)
+			return
+		}
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "address") {
+			continue // header row
+		}
+		
+		address, ok := suppressionAddress(record[0])
+		if !ok {
+			invalid = append(invalid, record[0])
+			continue
+		}
+		reason := ""
+		if len(record) > 1 {
+			reason = strings.TrimSpace(record[1])
+		}
+		
+		suppression := &storage.Suppression{
+			ID:        generateID(),
+			ProjectID: projectID,
+			Address:   address,
+			Reason:    reason,
+			Source:    storage.SuppressionSourceImport,
+			CreatedAt: time.Now(),
+		}
+		if err := s.storage.AddSuppression(suppression); err != nil {
+			log.Printf("Failed to import suppression for project %s: %v", projectID, err)
+			http.Error(w, "Failed to import suppressions", http.StatusInternalServerError)
+			return
+		}
+		imported++
+	}
+	
+	s.recordAuditLog(r, "project_suppressions_imported", &projectID, map[string]interface{}{
+		"imported": imported,
+		"invalid":  len(invalid),
+	})
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"imported": imported,
+		"invalid":  invalid,
+	})
+}
+
+// exportSuppressionsHandler downloads a project's suppression list as CSV
+func (s *Server) exportSuppressionsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	// Load everything first so a storage error can still be reported
+	var suppressions []*storage.Suppression
+	for offset := 0; ; {
+		page, totalCount, err := s.storage.ListSuppressions(projectID, "", 1000, offset)
+		if err != nil {
+			log.Printf("Failed to list suppressions for project %s: %v", projectID, err)
+			http.Error(w, "Failed to export suppressions", http.StatusInternalServerError)
+			return
+		}
+		suppressions = append(suppressions, page...)
+		offset += len(page)
+		if len(page) == 0 || offset >= totalCount {
+			break
+		}
+	}
+	
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", attachmentDisposition(projectID+"-suppressions.csv"))
+	
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"address", "reason", "source", "email_id", "created_at", "updated_at"})
+	for _, suppression := range suppressions {
+		emailID := ""
+		if suppression.EmailID != nil {
+			emailID = *suppression.EmailID
+		}
+		writer.Write([]string{
+			suppression.Address,
+			suppression.Reason,
+			suppression.Source,
+			emailID,
+			suppression.CreatedAt.Format(time.RFC3339),
+			suppression.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	writer.Flush()
+}
+
+// suppressionAddress validates an address for the suppression list and
+// returns its lower-cased bare form
+func suppressionAddress(address string) (string, bool) {
+	parsed, err := mail.ParseAddress(strings.TrimSpace(address))
+	if err != nil {
+		return "", false
+	}
+	return strings.ToLower(parsed.Address), true
+}
+
+// validSuppressionSource reports whether source is a known suppression source
+func validSuppressionSource(source string) bool {
+	switch source {
+	case storage.SuppressionSourceManual, storage.SuppressionSourceImport,
+		storage.SuppressionSourceBounce, storage.SuppressionSourceComplaint, storage.SuppressionSourceUnsubscribe:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/Renespeare/mailpulse/relay/internal/smtp"
+)
+
+// DomainThrottleResponse represents a recipient domain's throttle limits,
+// current load and queue depth for API responses
+type DomainThrottleResponse struct {
+	smtp.DomainThrottleStatus
+	Queued int // pending recipients of queued emails
+}
+
+// domainThrottleHandler returns the outbound limits of each recipient domain
+// with how much of them is in use and how many recipients wait in the queue
+func (s *Server) domainThrottleHandler(w http.ResponseWriter, r *http.Request) {
+	queued, err := s.storage.CountQueuedRecipientsByDomain()
+	if err != nil {
+		log.Printf("Failed to count queued recipients: %v", err)
+		http.Error(w, "Failed to get queue depth", http.StatusInternalServerError)
+		return
+	}
+	
+	statuses := s.forwarder.DomainThrottle()
+	domains := make([]DomainThrottleResponse, 0, len(statuses)+len(queued))
+	for _, status := range statuses {
+		domains = append(domains, DomainThrottleResponse{
+			DomainThrottleStatus: status,
+			Queued:               queued[status.Domain],
+		})
+		delete(queued, status.Domain)
+	}
+	
+	// Queued domains that have not been sent to since the relay started
+	for domain, count := range queued {
+		domains = append(domains, DomainThrottleResponse{
+			DomainThrottleStatus: s.forwarder.DomainThrottleFor(domain),
+			Queued:               count,
+		})
+	}
+	sort.SliceStable(domains, func(i, j int) bool {
+		return domains[i].Queued > domains[j].Queued
+	})
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"domains": domains,
+	})
+}
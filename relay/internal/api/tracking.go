@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/Renespeare/mailpulse/relay/internal/tracking"
+	"github.com/Renespeare/mailpulse/relay/internal/webhooks"
+	"github.com/gorilla/mux"
+)
+
+// openPixelHandler serves the open tracking pixel and records the open.
+// The pixel is returned even for unknown tokens so no broken image shows.
+func (s *Server) openPixelHandler(w http.ResponseWriter, r *http.Request) {
+	if emailID, ok := tracking.EmailIDFromOpenToken(mux.Vars(r)["token"]); ok {
+		s.recordOpen(r, emailID)
+	}
+	
+	// Every view must reach us, so nothing may cache the pixel
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, private")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Write(tracking.Pixel)
+}
+
+// recordOpen stores an open of an email and reports it to the project's webhooks
+func (s *Server) recordOpen(r *http.Request, emailID string) {
+	email, err := s.storage.GetEmail(emailID)
+	if err != nil {
+		log.Printf("⚠️  Open tracked for unknown email %s: %v", emailID, err)
+		return
+	}
+	
+	userAgent := r.UserAgent()
+	clientIP := requestClientIP(r)
+	open := &storage.EmailOpen{
+		ID:        generateID(),
+		EmailID:   email.ID,
+		UserAgent: userAgent,
+		IPAddress: clientIP,
+		Proxy:     tracking.DetectProxy(userAgent, clientIP),
+		CreatedAt: time.Now(),
+	}
+	
+	openCount, err := s.storage.RecordEmailOpen(open)
+	if err != nil {
+		log.Printf("⚠️  Failed to record open of email %s: %v", emailID, err)
+		return
+	}
+	
+	s.forwarder.Events().Emit(webhooks.EventOpened, email.ProjectID, email, map[string]interface{}{
+		"firstOpen": openCount == 1,
+		"openCount": openCount,
+		"userAgent": userAgent,
+		"proxy":     open.Proxy,
+	})
+}
+
+// clickHandler records a click of a tracked link and redirects to its
+// original URL. Only URLs carried in a validly signed token are redirected to.
+func (s *Server) clickHandler(w http.ResponseWriter, r *http.Request) {
+	emailID, target, ok := tracking.ParseClickToken(mux.Vars(r)["token"])
+	if !ok {
+		http.Error(w, "Link not found", http.StatusNotFound)
+		return
+	}
+	
+	s.recordClick(r, emailID, target)
+	
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// recordClick stores a click of a tracked link and reports it to the project's webhooks
+func (s *Server) recordClick(r *http.Request, emailID, target string) {
+	email, err := s.storage.GetEmail(emailID)
+	if err != nil {
+		log.Printf("⚠️  Click tracked for unknown email %s: %v", emailID, err)
+		return
+	}
+	
+	userAgent := r.UserAgent()
+	click := &storage.EmailClick{
+		ID:        generateID(),
+		EmailID:   email.ID,
+		URL:       target,
+		UserAgent: userAgent,
+		IPAddress: requestClientIP(r),
+		CreatedAt: time.Now(),
+	}
+	
+	clickCount, err := s.storage.RecordEmailClick(click)
+	if err != nil {
+		log.Printf("⚠️  Failed to record click of email %s: %v", emailID, err)
+		return
+	}
+	
+	s.forwarder.Events().Emit(webhooks.EventClicked, email.ProjectID, email, map[string]interface{}{
+		"url":        target,
+		"firstClick": clickCount == 1,
+		"clickCount": clickCount,
+		"userAgent":  userAgent,
+	})
+}
+
+// projectLinksHandler returns the click stats of each link clicked in a
+// project's emails, most clicked first
+func (s *Server) projectLinksHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	limit := 50 // default page size
+	offset := 0 // default offset
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 200 {
+			limit = l
+		}
+	}
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+	
+	links, totalCount, err := s.storage.ListProjectLinkStats(projectID, limit, offset)
+	if err != nil {
+		log.Printf("Failed to list link stats for project %s: %v", projectID, err)
+		http.Error(w, "Failed to list link stats", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"projectId":  projectID,
+		"links":      links,
+		"totalCount": totalCount,
+		"limit":      limit,
+		"offset":     offset,
+		"hasMore":    offset+len(links) < totalCount,
+	})
+}
+
+// addEngagementStats adds open and click tracking figures to email
+// statistics. Emails only opened by a mail provider proxy count as opened
+// but not as directly opened.
+func addEngagementStats(stats map[string]interface{}, emails []*storage.Email) {
+	opened := 0
+	directlyOpened := 0
+	totalOpens := 0
+	clicked := 0
+	totalClicks := 0
+	for _, email := range emails {
+		if email.OpenedAt != nil {
+			opened++
+		}
+		if email.OpenCount > email.ProxyOpenCount {
+			directlyOpened++
+		}
+		totalOpens += email.OpenCount
+		if email.ClickedAt != nil {
+			clicked++
+		}
+		totalClicks += email.ClickCount
+	}
+	
+	openRate := 0.0
+	directOpenRate := 0.0
+	clickRate := 0.0
+	if sent := stats["sentEmails"].(int); sent > 0 {
+		openRate = float64(opened) / float64(sent) * 100
+		directOpenRate = float64(directlyOpened) / float64(sent) * 100
+		clickRate = float64(clicked) / float64(sent) * 100
+	}
+	clickToOpenRate := 0.0
+	if opened > 0 {
+		clickToOpenRate = float64(clicked) / float64(opened) * 100
+	}
+	
+	stats["openedEmails"] = opened
+	stats["directlyOpenedEmails"] = directlyOpened
+	stats["totalOpens"] = totalOpens
+	stats["openRate"] = openRate
+	stats["directOpenRate"] = directOpenRate
+	stats["clickedEmails"] = clicked
+	stats["totalClicks"] = totalClicks
+	stats["clickRate"] = clickRate
+	stats["clickToOpenRate"] = clickToOpenRate
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/Renespeare/mailpulse/relay/internal/tracking"
+	"github.com/Renespeare/mailpulse/relay/internal/webhooks"
+	"github.com/gorilla/mux"
+)
+
+// unsubscribePage is the page shown for unsubscribe links opened in a browser
+const unsubscribePage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>Unsubscribe</title></head>
+<body style="font-family:sans-serif;max-width:32em;margin:4em auto;padding:0 1em">%s</body></html>
+`
+
+// unsubscribeFormHandler shows a confirmation form for an unsubscribe link
+// opened in a browser. Nothing is changed on GET, so link scanners and
+// prefetching cannot unsubscribe anyone (RFC 8058).
+func (s *Server) unsubscribeFormHandler(w http.ResponseWriter, r *http.Request) {
+	_, recipient, ok := tracking.ParseUnsubscribeToken(mux.Vars(r)["token"])
+	if !ok {
+		http.Error(w, "Unsubscribe link not found", http.StatusNotFound)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprintf(w, unsubscribePage, fmt.Sprintf(
+		`<p>Stop sending email to <strong>%s</strong>?</p>
+<form method="post"><input type="hidden" name="List-Unsubscribe" value="One-Click"><button type="submit">Unsubscribe</button></form>`,
+		html.EscapeString(recipient)))
+}
+
+// unsubscribeHandler handles RFC 8058 one-click unsubscribe POSTs (and the
+// confirmation form) by adding the recipient to the project's suppression list
+func (s *Server) unsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	emailID, recipient, ok := tracking.ParseUnsubscribeToken(mux.Vars(r)["token"])
+	if !ok {
+		http.Error(w, "Unsubscribe link not found", http.StatusNotFound)
+		return
+	}
+	
+	email, err := s.storage.GetEmail(emailID)
+	if err != nil {
+		log.Printf("⚠️  Unsubscribe for unknown email %s: %v", emailID, err)
+		http.Error(w, "Unsubscribe link not found", http.StatusNotFound)
+		return
+	}
+	
+	r.Body = http.MaxBytesReader(w, r.Body, 4096)
+	oneClick := r.PostFormValue("List-Unsubscribe") == "One-Click"
+	
+	suppression := &storage.Suppression{
+		ID:        generateID(),
+		ProjectID: email.ProjectID,
+		Address:   strings.ToLower(recipient),
+		Reason:    "unsubscribed",
+		Source:    storage.SuppressionSourceUnsubscribe,
+		EmailID:   &email.ID,
+		CreatedAt: time.Now(),
+	}
+	if err := s.storage.AddSuppression(suppression); err != nil {
+		log.Printf("❌ Failed to unsubscribe %s from project %s: %v", recipient, email.ProjectID, err)
+		http.Error(w, "Failed to unsubscribe", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("✅ %s unsubscribed from project %s (email %s)", recipient, email.ProjectID, email.ID)
+	
+	s.recordAuditLog(r, "recipient_unsubscribed", &email.ProjectID, map[string]interface{}{
+		"recipient": recipient,
+		"email_id":  email.ID,
+		"one_click": oneClick,
+	})
+	s.forwarder.Events().Emit(webhooks.EventUnsubscribed, email.ProjectID, email, map[string]interface{}{
+		"recipient": recipient,
+		"oneClick":  oneClick,
+	})
+	
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprintf(w, unsubscribePage, fmt.Sprintf("<p><strong>%s</strong> has been unsubscribed.</p>",
+		html.EscapeString(recipient)))
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/crypto"
+	"github.com/Renespeare/mailpulse/relay/internal/smtp"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// UpstreamResponse represents an upstream relay for API responses (no password)
+type UpstreamResponse struct {
+	ID          string
+	ProjectID   string
+	Host        string
+	Port        int
+	Username    *string
+	HasPassword bool
+	Priority    int
+	Weight      int
+	Enabled     bool
+	CreatedAt   time.Time
+	Health      smtp.UpstreamHealth
+}
+
+// toUpstreamResponse strips the password and adds the circuit breaker state
+func (s *Server) toUpstreamResponse(upstream *storage.Upstream) *UpstreamResponse {
+	return &UpstreamResponse{
+		ID:          upstream.ID,
+		ProjectID:   upstream.ProjectID,
+		Host:        upstream.Host,
+		Port:        upstream.Port,
+		Username:    upstream.Username,
+		HasPassword: upstream.PasswordEnc != nil && *upstream.PasswordEnc != "",
+		Priority:    upstream.Priority,
+		Weight:      upstream.Weight,
+		Enabled:     upstream.Enabled,
+		CreatedAt:   upstream.CreatedAt,
+		Health:      s.forwarder.UpstreamHealth(upstream.ID),
+	}
+}
+
+// findUpstream returns one of a project's upstream relays
+func (s *Server) findUpstream(projectID, upstreamID string) (*storage.Upstream, error) {
+	upstreams, err := s.storage.ListUpstreams(projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, upstream := range upstreams {
+		if upstream.ID == upstreamID {
+			return upstream, nil
+		}
+	}
+	return nil, nil
+}
+
+// listUpstreamsHandler returns a project's upstream relays with their health
+func (s *Server) listUpstreamsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	project, err := s.storage.GetProject(projectID)
+	if err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	upstreams, err := s.storage.ListUpstreams(projectID)
+	if err != nil {
+		log.Printf("Failed to list upstreams for project %s: %v", projectID, err)
+		http.Error(w, "Failed to list upstreams", http.StatusInternalServerError)
+		return
+	}
+	
+	responseUpstreams := []*UpstreamResponse{}
+	for _, upstream := range upstreams {
+		responseUpstreams = append(responseUpstreams, s.toUpstreamResponse(upstream))
+	}
+	
+	response := map[string]interface{}{
+		"projectId": projectID,
+		"upstreams": responseUpstreams,
+	}
+	
+	// Projects without upstreams relay through their smtp_host setting
+	if project.SMTPHost != nil && *project.SMTPHost != "" {
+		response["smtpHostHealth"] = s.forwarder.UpstreamHealth(smtp.LegacyUpstreamID(projectID))
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// addUpstreamHandler adds an upstream relay to a project
+func (s *Server) addUpstreamHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	var req struct {
+		Host     string `json:"host"`
+		Port     int    `json:"port"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Priority int    `json:"priority"`
+		Weight   int    `json:"weight"`
+		Enabled  *bool  `json:"enabled"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	req.Host = strings.TrimSpace(req.Host)
+	if req.Host == "" {
+		http.Error(w, "Host is required", http.StatusBadRequest)
+		return
+	}
+	if req.Port == 0 {
+		req.Port = 587
+	}
+	if req.Port < 1 || req.Port > 65535 {
+		http.Error(w, "Invalid port", http.StatusBadRequest)
+		return
+	}
+	if req.Weight == 0 {
+		req.Weight = 1
+	}
+	if req.Weight < 0 || req.Priority < 0 {
+		http.Error(w, "Priority and weight must not be negative", http.StatusBadRequest)
+		return
+	}
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	var passwordEnc *string
+	if req.Password != "" {
+		encrypted, err := crypto.EncryptSMTPPassword(req.Password)
+		if err != nil {
+			log.Printf("Failed to encrypt upstream password: %v", err)
+			http.Error(w, "Failed to encrypt SMTP password", http.StatusInternalServerError)
+			return
+		}
+		passwordEnc = &encrypted
+	}
+	
+	upstream := &storage.Upstream{
+		ID:          generateID(),
+		ProjectID:   projectID,
+		Host:        req.Host,
+		Port:        req.Port,
+		Username:    stringPtrFromString(req.Username),
+		PasswordEnc: passwordEnc,
+		Priority:    req.Priority,
+		Weight:      req.Weight,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		CreatedAt:   time.Now(),
+	}
+	
+	if err := s.storage.AddUpstream(upstream); err != nil {
+		log.Printf("Failed to add upstream for project %s: %v", projectID, err)
+		http.Error(w, "Failed to add upstream", http.StatusInternalServerError)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_upstream_added", &projectID, map[string]interface{}{
+		"upstream_id": upstream.ID,
+		"host":        upstream.Host,
+		"port":        upstream.Port,
+		"priority":    upstream.Priority,
+		"weight":      upstream.Weight,
+	})
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(s.toUpstreamResponse(upstream))
+}
+
+// updateUpstreamHandler changes an upstream relay's settings
+func (s *Server) updateUpstreamHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	upstreamID := vars["upstreamId"]
+	
+	upstream, err := s.findUpstream(projectID, upstreamID)
+	if err != nil {
+		log.Printf("Failed to list upstreams for project %s: %v", projectID, err)
+		http.Error(w, "Failed to get upstream", http.StatusInternalServerError)
+		return
+	}
+	if upstream == nil {
+		http.Error(w, "Upstream not found", http.StatusNotFound)
+		return
+	}
+	
+	var updates map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	if host, ok := updates["host"].(string); ok && strings.TrimSpace(host) != "" {
+		upstream.Host = strings.TrimSpace(host)
+	}
+	if port, ok := updates["port"].(float64); ok && port >= 1 && port <= 65535 {
+		upstream.Port = int(port)
+	}
+	if username, ok := updates["username"].(string); ok {
+		upstream.Username = stringPtrFromString(username)
+	}
+	if password, ok := updates["password"].(string); ok {
+		if password == "" {
+			upstream.PasswordEnc = nil
+		} else {
+			encrypted, err := crypto.EncryptSMTPPassword(password)
+			if err != nil {
+				log.Printf("Failed to encrypt upstream password: %v", err)
+				http.Error(w, "Failed to encrypt SMTP password", http.StatusInternalServerError)
+				return
+			}
+			upstream.PasswordEnc = &encrypted
+		}
+	}
+	if priority, ok := updates["priority"].(float64); ok && priority >= 0 {
+		upstream.Priority = int(priority)
+	}
+	if weight, ok := updates["weight"].(float64); ok && weight >= 1 {
+		upstream.Weight = int(weight)
+	}
+	if enabled, ok := updates["enabled"].(bool); ok {
+		upstream.Enabled = enabled
+	}
+	
+	if err := s.storage.UpdateUpstream(upstream); err != nil {
+		log.Printf("Failed to update upstream %s: %v", upstreamID, err)
+		http.Error(w, "Failed to update upstream", http.StatusInternalServerError)
+		return
+	}
+	
+	auditDetails := map[string]interface{}{
+		"upstream_id": upstreamID,
+	}
+	for key, value := range updates {
+		if key == "password" {
+			auditDetails["updated_password"] = true
+			continue
+		}
+		auditDetails["updated_"+key] = value
+	}
+	s.recordAuditLog(r, "project_upstream_updated", &projectID, auditDetails)
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.toUpstreamResponse(upstream))
+}
+
+// deleteUpstreamHandler removes an upstream relay from a project
+func (s *Server) deleteUpstreamHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	upstreamID := vars["upstreamId"]
+	
+	if err := s.storage.DeleteUpstream(projectID, upstreamID); err != nil {
+		log.Printf("Failed to delete upstream %s for project %s: %v", upstreamID, projectID, err)
+		http.Error(w, "Upstream not found", http.StatusNotFound)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_upstream_removed", &projectID, map[string]interface{}{
+		"upstream_id": upstreamID,
+	})
+	
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Upstream removed",
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/domains"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// Warm-up plan defaults and bounds
+const (
+	defaultWarmupStartDaily = 50
+	defaultWarmupDays       = 30
+	maxWarmupDays           = 365
+)
+
+// WarmupPlanResponse represents a warm-up plan with where it is in its ramp
+type WarmupPlanResponse struct {
+	*storage.WarmupPlan
+	Progress *storage.WarmupProgress
+}
+
+// listWarmupPlansHandler returns a project's warm-up plans and their progress
+func (s *Server) listWarmupPlansHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	project, err := s.storage.GetProject(projectID)
+	if err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	plans, err := s.storage.ListWarmupPlans(projectID)
+	if err != nil {
+		log.Printf("Failed to list warm-up plans for project %s: %v", projectID, err)
+		http.Error(w, "Failed to list warm-up plans", http.StatusInternalServerError)
+		return
+	}
+	
+	now := time.Now()
+	response := []*WarmupPlanResponse{}
+	for _, plan := range plans {
+		response = append(response, &WarmupPlanResponse{
+			WarmupPlan: plan,
+			Progress:   plan.Progress(now, project.QuotaDaily, project.QuotaPerMinute),
+		})
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// addWarmupPlanHandler adds a warm-up plan for a project or one of its sender domains
+func (s *Server) addWarmupPlanHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	var req struct {
+		Domain     string `json:"domain"`
+		StartDaily int    `json:"startDaily"`
+		Days       int    `json:"days"`
+		StartAt    string `json:"startAt"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	if req.Domain != "" {
+		name, err := domains.NormalizeDomain(req.Domain)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Domain = name
+	}
+	if req.StartDaily == 0 {
+		req.StartDaily = defaultWarmupStartDaily
+	}
+	if req.Days == 0 {
+		req.Days = defaultWarmupDays
+	}
+	if req.StartDaily < 1 {
+		http.Error(w, "startDaily must be at least 1", http.StatusBadRequest)
+		return
+	}
+	if req.Days < 1 || req.Days > maxWarmupDays {
+		http.Error(w, fmt.Sprintf("days must be between 1 and %d", maxWarmupDays), http.StatusBadRequest)
+		return
+	}
+	startAt, err := parseOptionalTime(req.StartAt)
+	if err != nil {
+		http.Error(w, "Invalid startAt, expected RFC 3339", http.StatusBadRequest)
+		return
+	}
+	if startAt != nil && startAt.After(time.Now()) {
+		http.Error(w, "startAt cannot be in the future", http.StatusBadRequest)
+		return
+	}
+	
+	project, err := s.storage.GetProject(projectID)
+	if err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	plan := &storage.WarmupPlan{
+		ID:         generateID(),
+		ProjectID:  projectID,
+		Domain:     req.Domain,
+		StartDaily: req.StartDaily,
+		Days:       req.Days,
+		StartedAt:  time.Now(),
+		CreatedAt:  time.Now(),
+	}
+	if startAt != nil {
+		plan.StartedAt = *startAt
+	}
+	
+	if err := s.storage.CreateWarmupPlan(plan); err != nil {
+		log.Printf("Failed to add warm-up plan for project %s: %v", projectID, err)
+		http.Error(w, "Failed to add warm-up plan (one may already exist for this domain)", http.StatusConflict)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_warmup_added", &projectID, map[string]interface{}{
+		"plan_id":     plan.ID,
+		"domain":      plan.Domain,
+		"start_daily": plan.StartDaily,
+		"days":        plan.Days,
+		"started_at":  plan.StartedAt,
+	})
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&WarmupPlanResponse{
+		WarmupPlan: plan,
+		Progress:   plan.Progress(time.Now(), project.QuotaDaily, project.QuotaPerMinute),
+	})
+}
+
+// deleteWarmupPlanHandler removes a warm-up plan, lifting its limits right away
+func (s *Server) deleteWarmupPlanHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	planID := vars["planId"]
+	
+	if err := s.storage.DeleteWarmupPlan(projectID, planID); err != nil {
+		log.Printf("Failed to delete warm-up plan %s for project %s: %v", planID, projectID, err)
+		http.Error(w, "Warm-up plan not found", http.StatusNotFound)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_warmup_removed", &projectID, map[string]interface{}{
+		"plan_id": planID,
+	})
+	
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Warm-up plan removed",
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,478 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/crypto"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/Renespeare/mailpulse/relay/internal/webhooks"
+	"github.com/gorilla/mux"
+)
+
+// WebhookResponse represents a webhook endpoint for API responses (no secret)
+type WebhookResponse struct {
+	ID                  string
+	ProjectID           string
+	URL                 string
+	Events              []string
+	Enabled             bool
+	ConsecutiveFailures int
+	FailedDeliveries    int
+	LastSuccessAt       *time.Time
+	LastFailureAt       *time.Time
+	LastError           *string
+	CreatedAt           time.Time
+	Secret              string `json:",omitempty"` // only when created or rotated
+}
+
+// WebhookDeliveryResponse represents a queued, delivered or dead-lettered event
+type WebhookDeliveryResponse struct {
+	ID            string
+	WebhookID     string
+	EventType     string
+	EmailID       *string
+	Status        string
+	Attempts      int
+	ResponseCode  *int
+	LastError     *string
+	NextAttemptAt *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeliveredAt   *time.Time
+	Payload       json.RawMessage
+}
+
+// toWebhookResponse converts a storage.Webhook for API responses
+func toWebhookResponse(webhook *storage.Webhook) *WebhookResponse {
+	events := webhook.Events
+	if events == nil {
+		events = []string{}
+	}
+	return &WebhookResponse{
+		ID:                  webhook.ID,
+		ProjectID:           webhook.ProjectID,
+		URL:                 webhook.URL,
+		Events:              events,
+		Enabled:             webhook.Enabled,
+		ConsecutiveFailures: webhook.ConsecutiveFailures,
+		FailedDeliveries:    webhook.FailedDeliveries,
+		LastSuccessAt:       webhook.LastSuccessAt,
+		LastFailureAt:       webhook.LastFailureAt,
+		LastError:           webhook.LastError,
+		CreatedAt:           webhook.CreatedAt,
+	}
+}
+
+// toWebhookDeliveryResponse converts a storage.WebhookDelivery for API responses
+func toWebhookDeliveryResponse(delivery *storage.WebhookDelivery) *WebhookDeliveryResponse {
+	response := &WebhookDeliveryResponse{
+		ID:           delivery.ID,
+		WebhookID:    delivery.WebhookID,
+		EventType:    delivery.EventType,
+		EmailID:      delivery.EmailID,
+		Status:       delivery.Status,
+		Attempts:     delivery.Attempts,
+		ResponseCode: delivery.ResponseCode,
+		LastError:    delivery.LastError,
+		CreatedAt:    delivery.CreatedAt,
+		UpdatedAt:    delivery.UpdatedAt,
+		DeliveredAt:  delivery.DeliveredAt,
+		Payload:      json.RawMessage(delivery.Payload),
+	}
+	if delivery.Status == storage.WebhookDeliveryPending {
+		response.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	return response
+}
+
+// parseWebhookURL validates a webhook endpoint URL
+func parseWebhookURL(rawURL string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return "", fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	return parsed.String(), nil
+}
+
+// parseWebhookEvents validates an event type filter (empty subscribes to all events)
+func parseWebhookEvents(events []string) ([]string, error) {
+	parsed := []string{}
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if !webhooks.ValidEventType(event) {
+			return nil, fmt.Errorf("unknown event type %q (use %s)", event, strings.Join(webhooks.EventTypes, ", "))
+		}
+		parsed = append(parsed, event)
+	}
+	return parsed, nil
+}
+
+// listWebhooksHandler returns a project's webhook endpoints with their health
+func (s *Server) listWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	hooks, err := s.storage.ListWebhooks(projectID)
+	if err != nil {
+		log.Printf("Failed to list webhooks for project %s: %v", projectID, err)
+		http.Error(w, "Failed to list webhooks", http.StatusInternalServerError)
+		return
+	}
+	
+	responseWebhooks := []*WebhookResponse{}
+	for _, webhook := range hooks {
+		responseWebhooks = append(responseWebhooks, toWebhookResponse(webhook))
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"projectId":  projectID,
+		"webhooks":   responseWebhooks,
+		"eventTypes": webhooks.EventTypes,
+	})
+}
+
+// addWebhookHandler adds a webhook endpoint. The signing secret is only
+// returned in this response.
+func (s *Server) addWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	var req struct {
+		URL     string   `json:"url"`
+		Events  []string `json:"events"`
+		Enabled *bool    `json:"enabled"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	endpoint, err := parseWebhookURL(req.URL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	events, err := parseWebhookEvents(req.Events)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	secret := webhooks.GenerateSecret()
+	secretEnc, err := crypto.EncryptWebhookSecret(secret)
+	if err != nil {
+		log.Printf("Failed to encrypt webhook secret: %v", err)
+		http.Error(w, "Failed to encrypt webhook secret", http.StatusInternalServerError)
+		return
+	}
+	
+	webhook := &storage.Webhook{
+		ID:        generateID(),
+		ProjectID: projectID,
+		URL:       endpoint,
+		SecretEnc: secretEnc,
+		Events:    events,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		CreatedAt: time.Now(),
+	}
+	
+	if err := s.storage.AddWebhook(webhook); err != nil {
+		log.Printf("Failed to add webhook for project %s: %v", projectID, err)
+		http.Error(w, "Failed to add webhook", http.StatusInternalServerError)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_webhook_added", &projectID, map[string]interface{}{
+		"webhook_id": webhook.ID,
+		"url":        webhook.URL,
+		"events":     webhook.Events,
+	})
+	
+	response := toWebhookResponse(webhook)
+	response.Secret = secret
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// updateWebhookHandler changes a webhook's URL, event filter or enabled flag,
+// or rotates its secret with {"rotateSecret": true}
+func (s *Server) updateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	webhookID := vars["webhookId"]
+	
+	webhook, err := s.storage.GetWebhook(projectID, webhookID)
+	if err != nil {
+		log.Printf("Failed to get webhook %s: %v", webhookID, err)
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	
+	var req struct {
+		URL          *string   `json:"url"`
+		Events       *[]string `json:"events"`
+		Enabled      *bool     `json:"enabled"`
+		RotateSecret bool      `json:"rotateSecret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	auditDetails := map[string]interface{}{
+		"webhook_id": webhookID,
+	}
+	if req.URL != nil {
+		endpoint, err := parseWebhookURL(*req.URL)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		webhook.URL = endpoint
+		auditDetails["url"] = endpoint
+	}
+	if req.Events != nil {
+		events, err := parseWebhookEvents(*req.Events)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		webhook.Events = events
+		auditDetails["events"] = events
+	}
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+		auditDetails["enabled"] = *req.Enabled
+	}
+	
+	secret := ""
+	if req.RotateSecret {
+		secret = webhooks.GenerateSecret()
+		secretEnc, err := crypto.EncryptWebhookSecret(secret)
+		if err != nil {
+			log.Printf("Failed to encrypt webhook secret: %v", err)
+			http.Error(w, "Failed to encrypt webhook secret", http.StatusInternalServerError)
+			return
+		}
+		webhook.SecretEnc = secretEnc
+		auditDetails["rotated_secret"] = true
+	}
+	
+	if err := s.storage.UpdateWebhook(webhook); err != nil {
+		log.Printf("Failed to update webhook %s: %v", webhookID, err)
+		http.Error(w, "Failed to update webhook", http.StatusInternalServerError)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_webhook_updated", &projectID, auditDetails)
+	
+	// Deliveries held while the endpoint was disabled can go out now
+	if webhook.Enabled {
+		s.forwarder.Events().Wake()
+	}
+	
+	response := toWebhookResponse(webhook)
+	response.Secret = secret
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// deleteWebhookHandler removes a webhook endpoint and its deliveries
+func (s *Server) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	webhookID := vars["webhookId"]
+	
+	if err := s.storage.DeleteWebhook(projectID, webhookID); err != nil {
+		log.Printf("Failed to delete webhook %s for project %s: %v", webhookID, projectID, err)
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	
+	s.recordAuditLog(r, "project_webhook_removed", &projectID, map[string]interface{}{
+		"webhook_id": webhookID,
+	})
+	
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Webhook removed",
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// listWebhookDeliveriesHandler lists a project's webhook deliveries, newest
+// first (?webhookId= and ?status=pending|delivered|failed filter them)
+func (s *Server) listWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	status := r.URL.Query().Get("status")
+	if status != "" && status != storage.WebhookDeliveryPending && status != storage.WebhookDeliveryDelivered &&
+		status != storage.WebhookDeliveryFailed {
+		http.Error(w, "Invalid status (use pending, delivered or failed)", http.StatusBadRequest)
+		return
+	}
+	
+	s.writeWebhookDeliveries(w, r, projectID, r.URL.Query().Get("webhookId"), status, nil)
+}
+
+// deadLetterHandler shows the endpoints that are failing and the deliveries
+// that ran out of retries
+func (s *Server) deadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	
+	hooks, err := s.storage.ListWebhooks(projectID)
+	if err != nil {
+		log.Printf("Failed to list webhooks for project %s: %v", projectID, err)
+		http.Error(w, "Failed to list webhooks", http.StatusInternalServerError)
+		return
+	}
+	
+	failing := []*WebhookResponse{}
+	for _, webhook := range hooks {
+		if webhook.ConsecutiveFailures > 0 || webhook.FailedDeliveries > 0 {
+			failing = append(failing, toWebhookResponse(webhook))
+		}
+	}
+	
+	s.writeWebhookDeliveries(w, r, projectID, r.URL.Query().Get("webhookId"), storage.WebhookDeliveryFailed, map[string]interface{}{
+		"failingEndpoints": failing,
+	})
+}
+
+// writeWebhookDeliveries writes a page of webhook deliveries, plus any extra response fields
+func (s *Server) writeWebhookDeliveries(w http.ResponseWriter, r *http.Request, projectID, webhookID, status string, extra map[string]interface{}) {
+	if _, err := s.storage.GetProject(projectID); err != nil {
+		log.Printf("Failed to get project %s: %v", projectID, err)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	limit := 50 // default page size
+	offset := 0 // default offset
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 200 {
+			limit = l
+		}
+	}
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+	
+	deliveries, totalCount, err := s.storage.ListWebhookDeliveries(projectID, webhookID, status, limit, offset)
+	if err != nil {
+		log.Printf("Failed to list webhook deliveries for project %s: %v", projectID, err)
+		http.Error(w, "Failed to list webhook deliveries", http.StatusInternalServerError)
+		return
+	}
+	
+	responseDeliveries := []*WebhookDeliveryResponse{}
+	for _, delivery := range deliveries {
+		responseDeliveries = append(responseDeliveries, toWebhookDeliveryResponse(delivery))
+	}
+	
+	response := map[string]interface{}{
+		"deliveries": responseDeliveries,
+		"totalCount": totalCount,
+		"limit":      limit,
+		"offset":     offset,
+		"hasMore":    offset+len(deliveries) < totalCount,
+	}
+	for key, value := range extra {
+		response[key] = value
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// redeliverWebhookDeliveryHandler queues one delivery for a fresh round of attempts
+func (s *Server) redeliverWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	deliveryID := vars["deliveryId"]
+	
+	delivery, err := s.storage.GetWebhookDelivery(projectID, deliveryID)
+	if err != nil {
+		log.Printf("Failed to get webhook delivery %s: %v", deliveryID, err)
+		http.Error(w, "Webhook delivery not found", http.StatusNotFound)
+		return
+	}
+	
+	if err := s.forwarder.Events().Redeliver(delivery); err != nil {
+		log.Printf("Failed to requeue webhook delivery %s: %v", deliveryID, err)
+		http.Error(w, "Failed to requeue webhook delivery", http.StatusInternalServerError)
+		return
+	}
+	
+	s.recordAuditLog(r, "webhook_redelivery_requested", &projectID, map[string]interface{}{
+		"delivery_id": deliveryID,
+		"webhook_id":  delivery.WebhookID,
+		"event_type":  delivery.EventType,
+	})
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toWebhookDeliveryResponse(delivery))
+}
+
+// redeliverWebhookHandler queues every dead-lettered delivery of a webhook
+// again, e.g. after its endpoint was fixed
+func (s *Server) redeliverWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectId"]
+	webhookID := vars["webhookId"]
+	
+	if _, err := s.storage.GetWebhook(projectID, webhookID); err != nil {
+		log.Printf("Failed to get webhook %s: %v", webhookID, err)
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	
+	requeued, err := s.storage.RequeueWebhookDeliveries(projectID, webhookID)
+	if err != nil {
+		log.Printf("Failed to requeue deliveries of webhook %s: %v", webhookID, err)
+		http.Error(w, "Failed to requeue webhook deliveries", http.StatusInternalServerError)
+		return
+	}
+	s.forwarder.Events().Wake()
+	
+	s.recordAuditLog(r, "webhook_redelivery_requested", &projectID, map[string]interface{}{
+		"webhook_id": webhookID,
+		"requeued":   requeued,
+	})
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"requeued": requeued,
+	})
+}
@@ -5,10 +5,11 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/Renespeare/mailpulse/relay/internal/crypto"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -27,6 +28,8 @@ type Project struct {
 	Status           string
 	RequireIPAllow   bool
 	AllowedIPs       []string
+	AllowedSenders   []string  // Sender patterns; empty means unrestricted
+	SenderDomains    map[string]string // Claimed sender domain -> verification status
 	UserID           string
 	CreatedAt        time.Time
 	LastUsedAt       *time.Time
@@ -37,15 +40,29 @@ type StorageProject struct {
 	ID             string
 	Name           string
 	APIKeyEnc      string   // Encrypted API key
+	APIKeyDigest   string   // Keyed HMAC digest of the API key
 	PasswordHash   *string
+	SMTPHost       *string
+	SMTPPort       *int
+	SMTPUser       *string
+	SMTPPasswordEnc *string
 	QuotaDaily     int
 	QuotaPerMinute int
 	Status         string
+	RequireIPAllow bool
+	AllowedIPs     []string // IPs or CIDR ranges
+	AllowedSenders []string // Sender patterns
+	SenderDomains  map[string]string // Claimed sender domain -> verification status
+	UserID         *string
+	CreatedAt      time.Time
+	LastUsedAt     *time.Time
 }
 
 // ProjectStorage interface for loading projects
 type ProjectStorage interface {
 	ListAllProjects() ([]*StorageProject, error)
+	GetProject(id string) (*StorageProject, error)
+	GetProjectByAPIKeyDigest(digest string) (*StorageProject, error)
 }
 
 // AuthManager handles authentication and authorization
@@ -53,55 +70,54 @@ type AuthManager interface {
 	ValidateAPIKey(username, password string) (*Project, error)
 	CheckRateLimit(projectID string) error
 	IsIPAllowed(projectID string, ip string) bool
+	IsSenderAllowed(projectID string, address string) bool
 	RecordAuthAttempt(ip string, success bool)
 	GenerateAPIKey(prefix string) (string, string, error) // key, hash, error
 	ReloadProjects() error
 }
 
-// InMemoryAuthManager is a basic implementation for testing
-type InMemoryAuthManager struct {
-	projects     map[string]*Project
-	authAttempts map[string][]time.Time
-	storage      ProjectStorage
-}
-
-// DatabaseAuthManager uses the database for authentication
-type DatabaseAuthManager struct {
-	storage interface {
-		GetProjectByAPIKey(apiKey string) (*Project, error)
+// projectFromStorage converts a storage project into an auth Project
+func projectFromStorage(stored *StorageProject) *Project {
+	project := &Project{
+		ID:              stored.ID,
+		Name:            stored.Name,
+		EncryptedAPIKey: stored.APIKeyEnc,
+		SMTPPort:        587, // Upstream default when none is configured
+		QuotaDaily:      stored.QuotaDaily,
+		QuotaPerMinute:  stored.QuotaPerMinute,
+		Status:          stored.Status,
+		RequireIPAllow:  stored.RequireIPAllow,
+		AllowedIPs:      stored.AllowedIPs,
+		AllowedSenders:  stored.AllowedSenders,
+		SenderDomains:   stored.SenderDomains,
+		CreatedAt:       stored.CreatedAt,
+		LastUsedAt:      stored.LastUsedAt,
 	}
-	authAttempts map[string][]time.Time
-}
-
-// NewInMemoryAuthManager creates a new in-memory auth manager
-func NewInMemoryAuthManager(storage ProjectStorage) *InMemoryAuthManager {
-	return &InMemoryAuthManager{
-		projects:     make(map[string]*Project),
-		authAttempts: make(map[string][]time.Time),
-		storage:      storage,
+	
+	if stored.PasswordHash != nil {
+		project.SMTPPasswordHash = *stored.PasswordHash
 	}
-}
-
-// LoadProjectFromDB adds a project to the in-memory store from database data
-func (m *InMemoryAuthManager) LoadProjectFromDB(id, name, apiKeyEnc, passwordHash, status string) {
-	project := &Project{
-		ID:               id,
-		Name:             name,
-		EncryptedAPIKey:  apiKeyEnc,     // Store encrypted API key
-		SMTPPasswordHash: passwordHash,  // Store SMTP password hash 
-		Status:           status,
-		SMTPHost:         "smtp.gmail.com", // Default values
-		SMTPPort:         587,
-		QuotaDaily:       500,
-		QuotaPerMinute:   10,
-		RequireIPAllow:   false,
-		CreatedAt:        time.Now(),
+	if stored.SMTPHost != nil {
+		project.SMTPHost = *stored.SMTPHost
+	}
+	if stored.SMTPPort != nil && *stored.SMTPPort > 0 {
+		project.SMTPPort = *stored.SMTPPort
+	}
+	if stored.SMTPUser != nil {
+		project.SMTPUsername = *stored.SMTPUser
+	}
+	if stored.SMTPPasswordEnc != nil {
+		project.SMTPPasswordEnc = *stored.SMTPPasswordEnc
+	}
+	if stored.UserID != nil {
+		project.UserID = *stored.UserID
 	}
-	m.projects[id] = project
+	
+	return project
 }
 
-// GenerateAPIKey generates a new API key and its bcrypt hash
-func (m *InMemoryAuthManager) GenerateAPIKey(prefix string) (string, string, error) {
+// generateAPIKey generates a new API key and its bcrypt hash
+func generateAPIKey(prefix string) (string, string, error) {
 	// Generate 32 random bytes
 	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
@@ -120,92 +136,54 @@ func (m *InMemoryAuthManager) GenerateAPIKey(prefix string) (string, string, err
 	return apiKey, string(hash), nil
 }
 
-// ValidateAPIKey validates username (API key) and password
-func (m *InMemoryAuthManager) ValidateAPIKey(username, password string) (*Project, error) {
-	// Find project by decrypting and matching the API key
-	for _, project := range m.projects {
-		
-		// Decrypt the stored API key
-		decryptedAPIKey, err := crypto.DecryptAPIKey(project.EncryptedAPIKey)
+// verifyProject checks the SMTP password and status of a project matched by API key
+func verifyProject(project *Project, password string) error {
+	// If project has a SMTP password hash, verify the password
+	if project.SMTPPasswordHash != "" {
+		// Convert password to lowercase for comparison (SMTP servers often uppercase)
+		lowercasePassword := strings.ToLower(password)
+		err := bcrypt.CompareHashAndPassword([]byte(project.SMTPPasswordHash), []byte(lowercasePassword))
 		if err != nil {
-			// Skip this project if decryption fails
-			continue
-		}
-		
-		// Compare the provided username with the decrypted API key
-		if strings.EqualFold(decryptedAPIKey, username) {
-			// If project has a SMTP password hash, verify the password
-			if project.SMTPPasswordHash != "" {
-				// Convert password to lowercase for comparison (SMTP servers often uppercase)
-				lowercasePassword := strings.ToLower(password)
-				err := bcrypt.CompareHashAndPassword([]byte(project.SMTPPasswordHash), []byte(lowercasePassword))
-				if err != nil {
-					return nil, errors.New("invalid password")
-				}
-			}
-			
-			// Check if project is active
-			if project.Status != "active" {
-				return nil, errors.New("project is not active")
-			}
-			
-			// Update last used time
-			now := time.Now()
-			project.LastUsedAt = &now
-			return project, nil
-		}
-	}
-	
-	return nil, errors.New("invalid API credentials")
-}
-
-// CheckRateLimit checks if project has exceeded rate limits
-func (m *InMemoryAuthManager) CheckRateLimit(projectID string) error {
-	// Basic rate limiting implementation
-	// This would use Redis in production
-	
-	project, exists := m.projects[projectID]
-	if !exists {
-		return errors.New("project not found")
-	}
-	
-	// Simple per-minute check (would be more sophisticated in Redis)
-	now := time.Now()
-	attempts := 0
-	
-	// Count recent attempts (last minute)
-	for ip, times := range m.authAttempts {
-		if strings.HasPrefix(ip, projectID) {
-			for _, t := range times {
-				if now.Sub(t) < time.Minute {
-					attempts++
-				}
-			}
+			return errors.New("invalid password")
 		}
 	}
 	
-	if attempts >= project.QuotaPerMinute {
-		return fmt.Errorf("rate limit exceeded: %d requests per minute", project.QuotaPerMinute)
+	// Check if project is active
+	if project.Status != "active" {
+		return errors.New("project is not active")
 	}
 	
 	return nil
 }
 
-// IsIPAllowed checks if IP is in project's allowlist
-func (m *InMemoryAuthManager) IsIPAllowed(projectID string, ip string) bool {
-	project, exists := m.projects[projectID]
-	if !exists {
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// burnPasswordCheck runs a bcrypt comparison against a throwaway hash
+func burnPasswordCheck(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("mailpulse-unknown-api-key"), bcrypt.DefaultCost)
+	})
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(strings.ToLower(password)))
+}
+
+// ipInAllowlist checks an IP against allowlist entries (single IPs or CIDR ranges)
+func ipInAllowlist(ip string, allowed []string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
 		return false
 	}
 	
-	// If no IP restrictions, allow all
-	if !project.RequireIPAllow || len(project.AllowedIPs) == 0 {
-		return true
-	}
-	
-	// Check if IP is in allowlist
-	for _, allowedIP := range project.AllowedIPs {
-		if ip == allowedIP {
+	for _, entry := range allowed {
+		if strings.Contains(entry, "/") {
+			if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(parsed) {
+				return true
+			}
+			continue
+		}
+		if allowedIP := net.ParseIP(entry); allowedIP != nil && allowedIP.Equal(parsed) {
 			return true
 		}
 	}
@@ -213,52 +191,25 @@ func (m *InMemoryAuthManager) IsIPAllowed(projectID string, ip string) bool {
 	return false
 }
 
-// RecordAuthAttempt records an authentication attempt for rate limiting
-func (m *InMemoryAuthManager) RecordAuthAttempt(ip string, success bool) {
-	now := time.Now()
+// NormalizeCIDR validates an IPv4/IPv6 address or CIDR range and returns it in
+// canonical CIDR form (single addresses become /32 or /128)
+func NormalizeCIDR(value string) (string, error) {
+	value = strings.TrimSpace(value)
 	
-	// Clean old attempts (older than 1 hour)
-	cleanTime := now.Add(-time.Hour)
-	for key, times := range m.authAttempts {
-		var cleanTimes []time.Time
-		for _, t := range times {
-			if t.After(cleanTime) {
-				cleanTimes = append(cleanTimes, t)
-			}
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid CIDR %q", value)
 		}
-		m.authAttempts[key] = cleanTimes
+		return network.String(), nil
 	}
 	
-	// Record new attempt
-	m.authAttempts[ip] = append(m.authAttempts[ip], now)
-}
-
-// AddProject adds a project for testing
-func (m *InMemoryAuthManager) AddProject(project *Project) {
-	m.projects[project.ID] = project
-}
-
-// ReloadProjects reloads projects from storage
-func (m *InMemoryAuthManager) ReloadProjects() error {
-	if m.storage == nil {
-		return errors.New("no storage configured")
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return "", fmt.Errorf("invalid IP address %q", value)
 	}
-	
-	projects, err := m.storage.ListAllProjects()
-	if err != nil {
-		return fmt.Errorf("failed to load projects from storage: %w", err)
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.String() + "/32", nil
 	}
-	
-	// Clear existing projects and reload
-	m.projects = make(map[string]*Project)
-	
-	for _, project := range projects {
-		passwordHash := ""
-		if project.PasswordHash != nil {
-			passwordHash = *project.PasswordHash
-		}
-		m.LoadProjectFromDB(project.ID, project.Name, project.APIKeyEnc, passwordHash, project.Status)
-	}
-	
-	return nil
-}
\ No newline at end of file
+	return ip.String() + "/128", nil
+}
@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/crypto"
+)
+
+// DefaultCacheTTL is how long a project stays cached when no invalidation arrives
+const DefaultCacheTTL = 30 * time.Second
+
+// DatabaseAuthManager uses the database for authentication.
+// Projects are cached for a short TTL and evicted early when storage
+// reports a change (see WatchInvalidations).
+type DatabaseAuthManager struct {
+	storage      ProjectStorage
+	cacheTTL     time.Duration
+	mu           sync.Mutex
+	byID         map[string]*cachedProject
+	byDigest     map[string]*cachedProject
+	authAttempts map[string][]time.Time
+}
+
+// cachedProject is a cache entry for a project loaded from storage
+type cachedProject struct {
+	project   *Project
+	digest    string
+	expiresAt time.Time
+}
+
+// NewDatabaseAuthManager creates a new database-backed auth manager
+func NewDatabaseAuthManager(storage ProjectStorage, cacheTTL time.Duration) *DatabaseAuthManager {
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultCacheTTL
+	}
+
+	return &DatabaseAuthManager{
+		storage:      storage,
+		cacheTTL:     cacheTTL,
+		byID:         make(map[string]*cachedProject),
+		byDigest:     make(map[string]*cachedProject),
+		authAttempts: make(map[string][]time.Time),
+	}
+}
+
+// ValidateAPIKey validates username (API key) and password
+func (m *DatabaseAuthManager) ValidateAPIKey(username, password string) (*Project, error) {
+	digest := crypto.HashAPIKey(username)
+
+	entry := m.cachedByDigest(digest)
+	if entry == nil {
+		stored, err := m.storage.GetProjectByAPIKeyDigest(digest)
+		if err != nil || stored == nil || !crypto.CompareAPIKeyDigest(stored.APIKeyDigest, digest) {
+			// Spend the same bcrypt work as a known key so timing doesn't reveal valid keys
+			burnPasswordCheck(password)
+			return nil, errors.New("invalid API credentials")
+		}
+		entry = m.cache(stored)
+	}
+
+	project := entry.project
+	if err := verifyProject(project, password); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	project.LastUsedAt = &now
+	m.mu.Unlock()
+
+	return project, nil
+}
+
+// CheckRateLimit checks if project has exceeded rate limits
+func (m *DatabaseAuthManager) CheckRateLimit(projectID string) error {
+	project, err := m.getProject(projectID)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Count recent attempts (last minute)
+	now := time.Now()
+	attempts := 0
+	for ip, times := range m.authAttempts {
+		if strings.HasPrefix(ip, projectID) {
+			for _, t := range times {
+				if now.Sub(t) < time.Minute {
+					attempts++
+				}
+			}
+		}
+	}
+
+	if attempts >= project.QuotaPerMinute {
+		return fmt.Errorf("rate limit exceeded: %d requests per minute", project.QuotaPerMinute)
+	}
+
+	return nil
+}
+
+// IsIPAllowed checks if IP is in project's allowlist
+func (m *DatabaseAuthManager) IsIPAllowed(projectID string, ip string) bool {
+	project, err := m.getProject(projectID)
+	if err != nil {
+		return false
+	}
+
+	// If no IP restrictions, allow all. A required but empty allowlist
+	// allows no one, so removing the last entry doesn't open the project.
+	if !project.RequireIPAllow {
+		return true
+	}
+
+	return ipInAllowlist(ip, project.AllowedIPs)
+}
+
+// IsSenderAllowed checks if an address may be used as sender by the project
+func (m *DatabaseAuthManager) IsSenderAllowed(projectID string, address string) bool {
+	project, err := m.getProject(projectID)
+	if err != nil {
+		return false
+	}
+
+	return senderPermitted(project, address)
+}
+
+// RecordAuthAttempt records an authentication attempt for rate limiting
+func (m *DatabaseAuthManager) RecordAuthAttempt(ip string, success bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	// Clean old attempts (older than 1 hour)
+	cleanTime := now.Add(-time.Hour)
+	for key, times := range m.authAttempts {
+		var cleanTimes []time.Time
+		for _, t := range times {
+			if t.After(cleanTime) {
+				cleanTimes = append(cleanTimes, t)
+			}
+		}
+		if len(cleanTimes) == 0 {
+			delete(m.authAttempts, key)
+			continue
+		}
+		m.authAttempts[key] = cleanTimes
+	}
+
+	m.authAttempts[ip] = append(m.authAttempts[ip], now)
+}
+
+// GenerateAPIKey generates a new API key and its bcrypt hash
+func (m *DatabaseAuthManager) GenerateAPIKey(prefix string) (string, string, error) {
+	return generateAPIKey(prefix)
+}
+
+// ReloadProjects drops every cached project so the next lookup hits the database
+func (m *DatabaseAuthManager) ReloadProjects() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.byID = make(map[string]*cachedProject)
+	m.byDigest = make(map[string]*cachedProject)
+	return nil
+}
+
+// Invalidate evicts a single project from the cache
+func (m *DatabaseAuthManager) Invalidate(projectID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.byID[projectID]; ok {
+		delete(m.byDigest, entry.digest)
+		delete(m.byID, projectID)
+	}
+}
+
+// WatchInvalidations evicts cached projects as change notifications arrive.
+// An empty project ID means the notification stream was interrupted and the
+// whole cache is dropped. Blocks until the channel is closed.
+func (m *DatabaseAuthManager) WatchInvalidations(changes <-chan string) {
+	for projectID := range changes {
+		if projectID == "" {
+			m.ReloadProjects()
+			log.Printf("🔄 Auth cache cleared after project change stream reconnect")
+			continue
+		}
+		m.Invalidate(projectID)
+	}
+}
+
+// getProject returns a project by ID from the cache or the database
+func (m *DatabaseAuthManager) getProject(projectID string) (*Project, error) {
+	m.mu.Lock()
+	entry, ok := m.byID[projectID]
+	m.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.project, nil
+	}
+
+	stored, err := m.storage.GetProject(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("project not found: %w", err)
+	}
+
+	return m.cache(stored).project, nil
+}
+
+// cachedByDigest returns an unexpired cache entry for an API key digest
+func (m *DatabaseAuthManager) cachedByDigest(digest string) *cachedProject {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.byDigest[digest]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil
+	}
+	return entry
+}
+
+// cache stores a project loaded from storage under both its ID and API key digest
+func (m *DatabaseAuthManager) cache(stored *StorageProject) *cachedProject {
+	entry := &cachedProject{
+		project:   projectFromStorage(stored),
+		digest:    stored.APIKeyDigest,
+		expiresAt: time.Now().Add(m.cacheTTL),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.byID[stored.ID]; ok {
+		delete(m.byDigest, old.digest)
+	}
+	m.byID[stored.ID] = entry
+	if entry.digest != "" {
+		m.byDigest[entry.digest] = entry
+	}
+
+	return entry
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeSenderPattern validates an allowed sender pattern and returns it lowercased.
+// Accepted forms are an exact address ("user@example.com"), a domain
+// ("example.com") or a wildcard covering every subdomain ("*.example.com").
+// "*@example.com" is treated as the domain form.
+func NormalizeSenderPattern(pattern string) (string, error) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	pattern = strings.TrimPrefix(pattern, "*@")
+	
+	if pattern == "" {
+		return "", fmt.Errorf("sender pattern is required")
+	}
+	
+	domain := pattern
+	if at := strings.LastIndex(pattern, "@"); at != -1 {
+		if at == 0 || strings.Contains(pattern[:at], "*") {
+			return "", fmt.Errorf("invalid sender address %q", pattern)
+		}
+		domain = pattern[at+1:]
+	} else {
+		domain = strings.TrimPrefix(domain, "*.")
+	}
+	
+	if !strings.Contains(domain, ".") || strings.ContainsAny(domain, "*@ ") ||
+		strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", fmt.Errorf("invalid sender domain %q", domain)
+	}
+	
+	return pattern, nil
+}
+
+// senderPermitted applies both sender restrictions of a project: the address
+// must match an allowed sender pattern (when any exist) and its domain must be
+// covered by a verified sender domain (once any claimed domain is verified, so
+// claiming a domain doesn't block mail before its DNS record is published)
+func senderPermitted(project *Project, address string) bool {
+	if len(project.AllowedSenders) > 0 && !senderAllowed(address, project.AllowedSenders) {
+		return false
+	}
+	if anyDomainVerified(project.SenderDomains) && !domainVerified(address, project.SenderDomains) {
+		return false
+	}
+	return true
+}
+
+// anyDomainVerified reports whether at least one claimed sender domain is verified
+func anyDomainVerified(domains map[string]string) bool {
+	for _, status := range domains {
+		if status == "verified" {
+			return true
+		}
+	}
+	return false
+}
+
+// domainVerified reports whether the address's domain, or a parent domain,
+// has passed DNS verification
+func domainVerified(address string, domains map[string]string) bool {
+	address = strings.ToLower(strings.TrimSpace(address))
+	at := strings.LastIndex(address, "@")
+	if at <= 0 {
+		return false
+	}
+	
+	domain := address[at+1:]
+	for {
+		if domains[domain] == "verified" {
+			return true
+		}
+		dot := strings.Index(domain, ".")
+		if dot == -1 {
+			return false
+		}
+		domain = domain[dot+1:]
+	}
+}
+
+// senderAllowed reports whether an address matches any allowed sender pattern
+func senderAllowed(address string, patterns []string) bool {
+	address = strings.ToLower(strings.TrimSpace(address))
+	at := strings.LastIndex(address, "@")
+	if at <= 0 {
+		return false
+	}
+	domain := address[at+1:]
+	
+	for _, pattern := range patterns {
+		switch {
+		case strings.Contains(pattern, "@"):
+			if address == pattern {
+				return true
+			}
+		case strings.HasPrefix(pattern, "*."):
+			if strings.HasSuffix(domain, pattern[1:]) {
+				return true
+			}
+		default:
+			if domain == pattern {
+				return true
+			}
+		}
+	}
+	
+	return false
+}
@@ -0,0 +1,183 @@
+package bounce
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Bounce classifications
+const (
+	Hard = "hard" // permanent failure - the address should not be retried
+	Soft = "soft" // temporary failure - delivery may still succeed later
+)
+
+// ErrNotDSN means the message is not an RFC 3464 delivery status notification
+var ErrNotDSN = errors.New("message is not a delivery status notification")
+
+// RecipientStatus is one per-recipient block of a delivery status notification
+type RecipientStatus struct {
+	Recipient  string
+	Action     string // failed, delayed, delivered, relayed or expanded
+	Status     string // enhanced status code, e.g. 5.1.1
+	Diagnostic string // the remote server's reply, e.g. "550 5.1.1 User unknown"
+	Code       int    // SMTP reply code from the diagnostic, when present
+}
+
+// ParseDSN extracts the recipient statuses from a multipart/report delivery
+// status notification (RFC 3464)
+func ParseDSN(raw []byte) ([]RecipientStatus, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+	
+	statusPart, err := findReportPart(msg.Header.Get("Content-Type"), msg.Body, "message/delivery-status")
+	if err == errNoReportPart {
+		return nil, ErrNotDSN
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	return parseDeliveryStatus(statusPart)
+}
+
+// errNoReportPart means a message has no part of the wanted report type
+var errNoReportPart = errors.New("report part not found")
+
+// findReportPart returns the first part of type wanted (e.g.
+// message/delivery-status), searching nested multiparts
+func findReportPart(contentType string, body io.Reader, wanted string) ([]byte, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, errNoReportPart
+	}
+	
+	if mediaType == wanted {
+		return io.ReadAll(body)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return nil, errNoReportPart
+	}
+	
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			return nil, errNoReportPart
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read report part: %w", err)
+		}
+		if report, err := findReportPart(part.Header.Get("Content-Type"), part, wanted); err == nil {
+			return report, nil
+		}
+	}
+}
+
+// parseDeliveryStatus reads the per-message block followed by one block per recipient
+func parseDeliveryStatus(body []byte) ([]RecipientStatus, error) {
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(normalizeBlankLines(body))))
+	
+	// Per-message fields (Reporting-MTA, Arrival-Date, ...) are not needed
+	if _, err := reader.ReadMIMEHeader(); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid delivery status: %w", err)
+	}
+	
+	var statuses []RecipientStatus
+	for {
+		fields, err := reader.ReadMIMEHeader()
+		if len(fields) > 0 {
+			recipient := addressField(fields.Get("Final-Recipient"))
+			if recipient == "" {
+				recipient = addressField(fields.Get("Original-Recipient"))
+			}
+			if recipient != "" {
+				diagnostic := addressField(fields.Get("Diagnostic-Code"))
+				statuses = append(statuses, RecipientStatus{
+					Recipient:  recipient,
+					Action:     strings.ToLower(strings.TrimSpace(fields.Get("Action"))),
+					Status:     strings.TrimSpace(fields.Get("Status")),
+					Diagnostic: diagnostic,
+					Code:       replyCode(diagnostic),
+				})
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	
+	if len(statuses) == 0 {
+		return nil, errors.New("delivery status has no recipients")
+	}
+	return statuses, nil
+}
+
+// normalizeBlankLines makes whitespace-only lines empty so they end a block
+func normalizeBlankLines(body []byte) []byte {
+	lines := strings.Split(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+		}
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+// addressField strips the type prefix of fields like "rfc822; user@example.com"
+func addressField(value string) string {
+	if semi := strings.Index(value, ";"); semi >= 0 {
+		value = value[semi+1:]
+	}
+	return strings.Trim(strings.TrimSpace(value), "<>")
+}
+
+// replyCode returns the SMTP reply code at the start of a diagnostic, or 0
+func replyCode(diagnostic string) int {
+	if len(diagnostic) < 3 {
+		return 0
+	}
+	code, err := strconv.Atoi(diagnostic[:3])
+	if err != nil || code < 200 || code > 599 {
+		return 0
+	}
+	return code
+}
+
+// Classify returns Hard, Soft or "" (not a failure) for a recipient status.
+// Permanent (5.x.x) statuses are hard bounces except a full mailbox (x.2.2),
+// which usually clears; delays and 4.x.x statuses are soft.
+func Classify(status RecipientStatus) string {
+	switch status.Action {
+	case "delivered", "relayed", "expanded":
+		return ""
+	case "delayed":
+		return Soft
+	}
+	
+	switch {
+	case strings.HasSuffix(status.Status, ".2.2"):
+		return Soft
+	case strings.HasPrefix(status.Status, "5."):
+		return Hard
+	case strings.HasPrefix(status.Status, "4."):
+		return Soft
+	case status.Code >= 500:
+		return Hard
+	case status.Code >= 400:
+		return Soft
+	case status.Action == "failed":
+		return Hard
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package bounce
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// Complaint is a bounce type for spam complaints from feedback loops
+const Complaint = "complaint"
+
+// ErrNotFeedbackReport means the message is not an RFC 5965 feedback report
+var ErrNotFeedbackReport = errors.New("message is not a feedback report")
+
+// FeedbackReport is the machine-readable part of an abuse feedback report (ARF)
+type FeedbackReport struct {
+	FeedbackType string // abuse, fraud, virus, not-spam, ...
+	Recipient    string // Original-Rcpt-To, often redacted or missing
+	UserAgent    string
+}
+
+// ParseFeedbackReport extracts the message/feedback-report part of a
+// multipart/report feedback loop message (RFC 5965)
+func ParseFeedbackReport(raw []byte) (*FeedbackReport, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+	
+	reportPart, err := findReportPart(msg.Header.Get("Content-Type"), msg.Body, "message/feedback-report")
+	if err == errNoReportPart {
+		return nil, ErrNotFeedbackReport
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(normalizeBlankLines(reportPart))))
+	fields, err := reader.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid feedback report: %w", err)
+	}
+	
+	report := &FeedbackReport{
+		FeedbackType: strings.ToLower(strings.TrimSpace(fields.Get("Feedback-Type"))),
+		Recipient:    addressField(fields.Get("Original-Rcpt-To")),
+		UserAgent:    strings.TrimSpace(fields.Get("User-Agent")),
+	}
+	if report.FeedbackType == "" {
+		return nil, errors.New("feedback report has no Feedback-Type")
+	}
+	return report, nil
+}
+
+// IsComplaint reports whether the feedback is a complaint about the message
+// (anything but a not-spam report)
+func (r *FeedbackReport) IsComplaint() bool {
+	return r.FeedbackType != "not-spam"
+}
@@ -0,0 +1,241 @@
+package bounce
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/Renespeare/mailpulse/relay/internal/webhooks"
+)
+
+// Bounce is a classified bounce for one recipient of an email
+type Bounce struct {
+	EmailID    string
+	ProjectID  string
+	Recipient  string
+	Type       string // Hard, Soft or Complaint
+	Status     string
+	Code       int
+	Diagnostic string
+}
+
+// Processor matches incoming bounces to emails and records them
+type Processor struct {
+	storage storage.Storage
+	domain  string
+	events  *webhooks.Dispatcher
+}
+
+// NewProcessor creates a bounce processor for VERP addresses on domain.
+// Bounces and complaints are reported to the project's webhooks through events.
+func NewProcessor(storage storage.Storage, domain string, events *webhooks.Dispatcher) *Processor {
+	return &Processor{storage: storage, domain: domain, events: events}
+}
+
+// Process handles a message received for a VERP return path. Hard bounces
+// mark the recipient bounced and the email "bounced"; soft bounces mark the
+// recipient deferred and, unless the email already bounced, the email
+// "deferred". The email's Error describes the bounce. Hard-bounced addresses
+// and spam complaints (feedback reports) are added to the project's
+// suppression list.
+func (p *Processor) Process(returnPath string, raw []byte) ([]Bounce, error) {
+	emailID := EmailIDFromAddress(returnPath, p.domain)
+	if emailID == "" {
+		return nil, fmt.Errorf("%s is not a bounce address", returnPath)
+	}
+	
+	email, err := p.storage.GetEmail(emailID)
+	if err != nil {
+		return nil, fmt.Errorf("bounce for unknown email %s: %w", emailID, err)
+	}
+	
+	if report, err := ParseFeedbackReport(raw); err == nil {
+		return p.processComplaint(email, report)
+	} else if err != ErrNotFeedbackReport {
+		return nil, err
+	}
+	
+	statuses, err := ParseDSN(raw)
+	if err != nil {
+		return nil, err
+	}
+	
+	var bounces []Bounce
+	for _, status := range statuses {
+		bounceType := Classify(status)
+		if bounceType == "" {
+			continue
+		}
+		
+		recipient := matchRecipient(email, status.Recipient)
+		if recipient == "" {
+			log.Printf("⚠️  Ignoring bounce for %s: not a recipient of email %s", status.Recipient, email.ID)
+			continue
+		}
+		
+		bounce := Bounce{
+			EmailID:    email.ID,
+			ProjectID:  email.ProjectID,
+			Recipient:  recipient,
+			Type:       bounceType,
+			Status:     status.Status,
+			Code:       status.Code,
+			Diagnostic: status.Diagnostic,
+		}
+		
+		recipientStatus := storage.RecipientStatusDeferred
+		if bounceType == Hard {
+			recipientStatus = storage.RecipientStatusBounced
+		}
+		var code *int
+		if bounce.Code > 0 {
+			code = &bounce.Code
+		}
+		text := describe(bounce)
+		if err := p.storage.UpdateRecipientStatus(email.ID, bounce.Recipient, recipientStatus, code, &text); err != nil {
+			log.Printf("⚠️  Failed to record bounce of %s for email %s: %v", bounce.Recipient, email.ID, err)
+		}
+		if bounceType == Hard {
+			p.suppress(bounce)
+		}
+		
+		bounces = append(bounces, bounce)
+	}
+	
+	if len(bounces) == 0 {
+		return nil, nil
+	}
+	
+	// A hard bounce decides the email status; otherwise report the soft bounce
+	decisive := bounces[0]
+	for _, bounce := range bounces {
+		if bounce.Type == Hard {
+			decisive = bounce
+			break
+		}
+	}
+	status := "bounced"
+	if decisive.Type == Soft {
+		if email.Status == "bounced" {
+			return bounces, nil
+		}
+		status = "deferred"
+	}
+	errorMsg := describe(decisive)
+	if err := p.storage.UpdateEmailStatus(email.ID, status, &errorMsg); err != nil {
+		return bounces, fmt.Errorf("failed to update email %s: %w", email.ID, err)
+	}
+	
+	eventType := webhooks.EventBounced
+	if status == "deferred" {
+		eventType = webhooks.EventDeferred
+	}
+	p.events.Emit(eventType, email.ProjectID, email, map[string]interface{}{
+		"status":  status,
+		"error":   errorMsg,
+		"bounces": eventBounces(bounces),
+	})
+	
+	log.Printf("📨 %s - email %s", describe(decisive), email.ID)
+	return bounces, nil
+}
+
+// processComplaint suppresses the recipient who reported an email as spam.
+// The email's status is left alone since it was delivered.
+func (p *Processor) processComplaint(email *storage.Email, report *FeedbackReport) ([]Bounce, error) {
+	if !report.IsComplaint() {
+		return nil, nil
+	}
+	
+	recipient := matchRecipient(email, report.Recipient)
+	if recipient == "" {
+		return nil, fmt.Errorf("complaint for email %s does not name one of its recipients", email.ID)
+	}
+	
+	complaint := Bounce{
+		EmailID:    email.ID,
+		ProjectID:  email.ProjectID,
+		Recipient:  recipient,
+		Type:       Complaint,
+		Diagnostic: report.FeedbackType,
+	}
+	p.suppress(complaint)
+	p.events.Emit(webhooks.EventComplaint, email.ProjectID, email, map[string]interface{}{
+		"recipient":    complaint.Recipient,
+		"feedbackType": report.FeedbackType,
+	})
+	
+	log.Printf("📨 %s - email %s", describe(complaint), email.ID)
+	return []Bounce{complaint}, nil
+}
+
+// suppress adds a hard-bounced or complaining recipient to the project's suppression list
+func (p *Processor) suppress(bounce Bounce) {
+	source := storage.SuppressionSourceBounce
+	if bounce.Type == Complaint {
+		source = storage.SuppressionSourceComplaint
+	}
+	
+	emailID := bounce.EmailID
+	suppression := &storage.Suppression{
+		ID:        fmt.Sprintf("sup_%d", time.Now().UnixNano()),
+		ProjectID: bounce.ProjectID,
+		Address:   bounce.Recipient,
+		Reason:    describe(bounce),
+		Source:    source,
+		EmailID:   &emailID,
+		CreatedAt: time.Now(),
+	}
+	if err := p.storage.AddSuppression(suppression); err != nil {
+		log.Printf("⚠️  Failed to suppress %s for project %s: %v", bounce.Recipient, bounce.ProjectID, err)
+	}
+}
+
+// eventBounces describes bounces for webhook events
+func eventBounces(bounces []Bounce) []map[string]interface{} {
+	described := make([]map[string]interface{}, 0, len(bounces))
+	for _, bounce := range bounces {
+		described = append(described, map[string]interface{}{
+			"recipient":  bounce.Recipient,
+			"type":       bounce.Type,
+			"status":     bounce.Status,
+			"code":       bounce.Code,
+			"diagnostic": bounce.Diagnostic,
+		})
+	}
+	return described
+}
+
+// matchRecipient maps a bounced address onto the email's recipient list,
+// ignoring case. A single-recipient email is assumed when the DSN names an
+// address we didn't send to (e.g. after forwarding); otherwise such an
+// address is not ours to record or suppress and "" is returned.
+func matchRecipient(email *storage.Email, recipient string) string {
+	for _, to := range email.To {
+		if strings.EqualFold(to, recipient) {
+			return to
+		}
+	}
+	if len(email.To) == 1 {
+		return email.To[0]
+	}
+	return ""
+}
+
+// describe summarises a bounce for the email's Error and the recipient response
+func describe(bounce Bounce) string {
+	kind := bounce.Type + " bounce"
+	if bounce.Type == Complaint {
+		kind = "spam complaint"
+	}
+	summary := fmt.Sprintf("%s for %s", kind, bounce.Recipient)
+	if bounce.Status != "" {
+		summary += ": " + bounce.Status
+	}
+	if bounce.Diagnostic != "" {
+		summary += " (" + bounce.Diagnostic + ")"
+	}
+	return strings.ToUpper(summary[:1]) + summary[1:]
+}
@@ -0,0 +1,164 @@
+package bounce
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// maxBounceSize limits the size of an accepted bounce message
+const maxBounceSize = 10 * 1024 * 1024
+
+// Server is a minimal inbound SMTP endpoint that accepts mail only for VERP
+// return paths on the bounce domain and hands it to the processor. Point the
+// bounce domain's MX record at it.
+type Server struct {
+	Address   string
+	Domain    string
+	Hostname  string
+	Processor *Processor
+}
+
+// NewServer creates an inbound bounce server
+func NewServer(address, domain string, processor *Processor) *Server {
+	return &Server{Address: address, Domain: domain, Hostname: domain, Processor: processor}
+}
+
+// Start listens for bounce messages (blocking)
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.Address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.Address, err)
+	}
+	defer listener.Close()
+	
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Printf("⚠️  Bounce server accept failed: %v", err)
+			continue
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle runs one SMTP session
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	
+	reply := func(format string, args ...interface{}) bool {
+		conn.SetDeadline(time.Now().Add(5 * time.Minute))
+		return text.PrintfLine(format, args...) == nil
+	}
+	
+	if !reply("220 %s ESMTP MailPulse bounce processor", s.Hostname) {
+		return
+	}
+	
+	var rcpts []string
+	for {
+		conn.SetDeadline(time.Now().Add(5 * time.Minute))
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		
+		verb, arg := line, ""
+		if space := strings.IndexByte(line, ' '); space >= 0 {
+			verb, arg = line[:space], line[space+1:]
+		}
+		
+		switch strings.ToUpper(verb) {
+		case "HELO", "EHLO":
+			reply("250 %s", s.Hostname)
+		case "MAIL":
+			rcpts = nil
+			reply("250 OK")
+		case "RCPT":
+			to := pathArgument(arg)
+			if EmailIDFromAddress(to, s.Domain) == "" {
+				reply("550 5.1.1 No such mailbox")
+				continue
+			}
+			rcpts = append(rcpts, to)
+			reply("250 OK")
+		case "DATA":
+			if len(rcpts) == 0 {
+				reply("503 5.5.1 No valid recipients")
+				continue
+			}
+			if !reply("354 End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			data, err := readData(text)
+			if err != nil {
+				reply("552 5.3.4 %v", err)
+				continue
+			}
+			for _, rcpt := range rcpts {
+				if _, err := s.Processor.Process(rcpt, data); err != nil {
+					log.Printf("⚠️  Bounce for %s not processed: %v", rcpt, err)
+				}
+			}
+			rcpts = nil
+			reply("250 OK")
+		case "RSET":
+			rcpts = nil
+			reply("250 OK")
+		case "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 5.5.2 Command not implemented")
+		}
+	}
+}
+
+// readData reads a dot-terminated message, enforcing the size limit
+func readData(text *textproto.Conn) ([]byte, error) {
+	reader := bufio.NewReader(text.DotReader())
+	data := make([]byte, 0, 16*1024)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := reader.Read(buf)
+		data = append(data, buf[:n]...)
+		if len(data) > maxBounceSize {
+			// Drain the rest so the session stays in sync
+			for err == nil {
+				_, err = reader.Read(buf)
+			}
+			return nil, fmt.Errorf("message too large")
+		}
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// pathArgument extracts the address from a "TO:<address>" argument
+func pathArgument(arg string) string {
+	if colon := strings.IndexByte(arg, ':'); colon >= 0 {
+		arg = arg[colon+1:]
+	}
+	arg = strings.TrimSpace(arg)
+	if start := strings.IndexByte(arg, '<'); start >= 0 {
+		if end := strings.IndexByte(arg[start:], '>'); end >= 0 {
+			return strings.TrimSpace(arg[start+1 : start+end])
+		}
+	}
+	if fields := strings.Fields(arg); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
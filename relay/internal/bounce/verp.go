@@ -0,0 +1,60 @@
+package bounce
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"strings"
+
+	"github.com/Renespeare/mailpulse/relay/internal/crypto"
+)
+
+// LocalPart prefixes the VERP return path of every email
+const LocalPart = "bounces"
+
+// signatureLength is how many bytes of the HMAC a return path carries
+const signatureLength = 8
+
+// ReturnPath returns the VERP envelope sender for an email, so bounces sent
+// back to it identify the email: bounces+<emailID>-<signature>@<domain>. The
+// signature stops anyone who learns an email ID from forging bounces for it.
+func ReturnPath(emailID, domain string) string {
+	return LocalPart + "+" + emailID + "-" + addressSignature(emailID) + "@" + domain
+}
+
+// addressSignature signs an email ID, hex encoded since MTAs may change the
+// case of local parts
+func addressSignature(emailID string) string {
+	return hex.EncodeToString(crypto.SignBounceAddress(emailID)[:signatureLength])
+}
+
+// EmailIDFromAddress extracts the email ID from a VERP return path on domain.
+// It returns "" when the address is not one of ours or its signature is wrong.
+func EmailIDFromAddress(address, domain string) string {
+	at := strings.LastIndex(address, "@")
+	if at < 0 || !strings.EqualFold(address[at+1:], domain) {
+		return ""
+	}
+	
+	prefix := LocalPart + "+"
+	local := address[:at]
+	if len(local) <= len(prefix) || !strings.EqualFold(local[:len(prefix)], prefix) {
+		return ""
+	}
+	
+	emailID, signature, found := cutLast(local[len(prefix):], "-")
+	if !found || emailID == "" {
+		return ""
+	}
+	if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(addressSignature(emailID))) {
+		return ""
+	}
+	return emailID
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (string, string, bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
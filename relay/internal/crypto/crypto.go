@@ -3,12 +3,16 @@ package crypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 )
 
 // Encrypt encrypts plaintext using AES-256-GCM
@@ -92,6 +96,84 @@ func DecryptAPIKey(ciphertext string) (string, error) {
 	return DecryptSMTPPassword(ciphertext) // Use same decryption method
 }
 
+// EncryptDKIMKey encrypts a PEM encoded DKIM private key using AES-256-GCM
+func EncryptDKIMKey(plaintext string) (string, error) {
+	return EncryptSMTPPassword(plaintext) // Use same encryption method
+}
+
+// DecryptDKIMKey decrypts a DKIM private key using AES-256-GCM
+func DecryptDKIMKey(ciphertext string) (string, error) {
+	return DecryptSMTPPassword(ciphertext) // Use same decryption method
+}
+
+// EncryptOAuthSecret encrypts an OAuth client secret or refresh token using AES-256-GCM
+func EncryptOAuthSecret(plaintext string) (string, error) {
+	return EncryptSMTPPassword(plaintext) // Use same encryption method
+}
+
+// DecryptOAuthSecret decrypts an OAuth client secret or refresh token using AES-256-GCM
+func DecryptOAuthSecret(ciphertext string) (string, error) {
+	return DecryptSMTPPassword(ciphertext) // Use same decryption method
+}
+
+// EncryptTransportSecret encrypts an HTTP transport API key or secret using AES-256-GCM
+func EncryptTransportSecret(plaintext string) (string, error) {
+	return EncryptSMTPPassword(plaintext) // Use same encryption method
+}
+
+// DecryptTransportSecret decrypts an HTTP transport API key or secret using AES-256-GCM
+func DecryptTransportSecret(ciphertext string) (string, error) {
+	return DecryptSMTPPassword(ciphertext) // Use same decryption method
+}
+
+// EncryptWebhookSecret encrypts a webhook signing secret using AES-256-GCM
+func EncryptWebhookSecret(plaintext string) (string, error) {
+	return EncryptSMTPPassword(plaintext) // Use same encryption method
+}
+
+// DecryptWebhookSecret decrypts a webhook signing secret using AES-256-GCM
+func DecryptWebhookSecret(ciphertext string) (string, error) {
+	return DecryptSMTPPassword(ciphertext) // Use same decryption method
+}
+
+// SignTrackingToken returns the keyed HMAC-SHA256 of an open or click
+// tracking payload, so tracking URLs cannot be forged
+func SignTrackingToken(payload string) []byte {
+	mac := hmac.New(sha256.New, getHMACKey())
+	mac.Write([]byte("tracking:" + payload))
+	return mac.Sum(nil)
+}
+
+// SignBounceAddress returns the HMAC-SHA256 signature of an email ID for its
+// VERP return path, so bounce addresses cannot be forged
+func SignBounceAddress(emailID string) []byte {
+	mac := hmac.New(sha256.New, getHMACKey())
+	mac.Write([]byte("bounce:" + emailID))
+	return mac.Sum(nil)
+}
+
+// HashAPIKey returns the keyed HMAC-SHA256 digest of an API key (hex encoded).
+// Keys are matched case-insensitively, so the key is lowercased before hashing.
+func HashAPIKey(apiKey string) string {
+	mac := hmac.New(sha256.New, getHMACKey())
+	mac.Write([]byte(strings.ToLower(apiKey)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CompareAPIKeyDigest compares two API key digests in constant time
+func CompareAPIKeyDigest(a, b string) bool {
+	return hmac.Equal([]byte(a), []byte(b))
+}
+
+// getHMACKey gets the key used for API key digests.
+// Falls back to the encryption key so existing deployments keep working.
+func getHMACKey() []byte {
+	if key := os.Getenv("API_KEY_HMAC_SECRET"); key != "" {
+		return []byte(key)
+	}
+	return getEncryptionKey()
+}
+
 // getEncryptionKey gets the encryption key from environment variable
 func getEncryptionKey() []byte {
 	key := os.Getenv("ENCRYPTION_KEY")
@@ -0,0 +1,370 @@
+package dkim
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supported signing algorithms
+const (
+	AlgorithmRSASHA256     = "rsa-sha256"
+	AlgorithmEd25519SHA256 = "ed25519-sha256"
+)
+
+// rsaKeyBits is the size of generated RSA keys
+const rsaKeyBits = 2048
+
+// DefaultHeaders are the header fields signed when no list is configured
+var DefaultHeaders = []string{
+	"From", "To", "Cc", "Subject", "Date", "Message-ID", "Reply-To",
+	"MIME-Version", "Content-Type", "Content-Transfer-Encoding",
+	"List-Unsubscribe", "List-Unsubscribe-Post",
+}
+
+// Signer signs messages for one domain and selector (RFC 6376, relaxed/relaxed)
+type Signer struct {
+	Domain    string
+	Selector  string
+	Algorithm string
+	Headers   []string
+	key       crypto.Signer
+}
+
+// NewSigner creates a signer from a PEM encoded private key
+func NewSigner(domain, selector, privateKeyPEM string, headers []string) (*Signer, error) {
+	key, algorithm, err := ParsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if len(headers) == 0 {
+		headers = DefaultHeaders
+	}
+
+	return &Signer{
+		Domain:    domain,
+		Selector:  selector,
+		Algorithm: algorithm,
+		Headers:   headers,
+		key:       key,
+	}, nil
+}
+
+// GenerateKey creates a new key pair for the algorithm and returns the
+// PEM encoded (PKCS#8) private key and the base64 public key for DNS
+func GenerateKey(algorithm string) (string, string, error) {
+	var key crypto.Signer
+	switch algorithm {
+	case AlgorithmRSASHA256:
+		rsaKey, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to generate RSA key: %w", err)
+		}
+		key = rsaKey
+	case AlgorithmEd25519SHA256:
+		_, edKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to generate Ed25519 key: %w", err)
+		}
+		key = edKey
+	default:
+		return "", "", fmt.Errorf("unsupported DKIM algorithm: %s", algorithm)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode private key: %w", err)
+	}
+	privateKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	publicKey, err := PublicKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	return privateKeyPEM, publicKey, nil
+}
+
+// ParsePrivateKey decodes a PEM encoded RSA or Ed25519 private key (PKCS#1 or PKCS#8)
+// and returns it along with its DKIM algorithm
+func ParsePrivateKey(privateKeyPEM string) (crypto.Signer, string, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, "", errors.New("private key is not PEM encoded")
+	}
+
+	var parsed interface{}
+	var err error
+	if block.Type == "RSA PRIVATE KEY" {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < 1024 {
+			return nil, "", errors.New("RSA keys must be at least 1024 bits")
+		}
+		return key, AlgorithmRSASHA256, nil
+	case ed25519.PrivateKey:
+		return key, AlgorithmEd25519SHA256, nil
+	default:
+		return nil, "", errors.New("unsupported private key type (use RSA or Ed25519)")
+	}
+}
+
+// PublicKey returns the base64 public key published in the DNS record:
+// SubjectPublicKeyInfo for RSA, the raw 32 byte key for Ed25519 (RFC 8463)
+func PublicKey(key crypto.Signer) (string, error) {
+	switch pub := key.Public().(type) {
+	case *rsa.PublicKey:
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode public key: %w", err)
+		}
+		return base64.StdEncoding.EncodeToString(der), nil
+	case ed25519.PublicKey:
+		return base64.StdEncoding.EncodeToString(pub), nil
+	default:
+		return "", errors.New("unsupported public key type")
+	}
+}
+
+// RecordName returns the DNS name for a selector's key record
+func RecordName(selector, domain string) string {
+	return selector + "._domainkey." + domain
+}
+
+// RecordValue returns the TXT record value publishing a public key
+func RecordValue(algorithm, publicKey string) string {
+	keyType := "rsa"
+	if algorithm == AlgorithmEd25519SHA256 {
+		keyType = "ed25519"
+	}
+	return fmt.Sprintf("v=DKIM1; k=%s; p=%s", keyType, publicKey)
+}
+
+// Sign returns the message with a DKIM-Signature header prepended.
+// Bare LF line endings are converted to CRLF first, since the signature
+// covers the message exactly as it goes on the wire.
+func (s *Signer) Sign(message []byte) ([]byte, error) {
+	message = normalizeLineEndings(message)
+
+	header, body := splitMessage(message)
+	fields := parseHeaderFields(header)
+
+	bodyHash := sha256.Sum256(canonicalBody(body))
+
+	// Sign only the configured headers that are present, bottom-most instance first
+	var signed []string
+	var names []string
+	used := map[string]int{}
+	for _, name := range s.Headers {
+		key := strings.ToLower(name)
+		field, ok := lastUnused(fields, key, used)
+		if !ok {
+			continue
+		}
+		signed = append(signed, field)
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, errors.New("message has none of the headers to sign")
+	}
+
+	sigValue := fmt.Sprintf("v=1; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%d; h=%s; bh=%s; b=",
+		s.Algorithm, s.Domain, s.Selector, time.Now().Unix(),
+		strings.Join(names, ":"), base64.StdEncoding.EncodeToString(bodyHash[:]))
+
+	var hashed bytes.Buffer
+	for _, field := range signed {
+		hashed.WriteString(canonicalHeader(field))
+		hashed.WriteString("\r\n")
+	}
+	// The signature header itself is hashed without its trailing CRLF
+	hashed.WriteString(canonicalHeader("DKIM-Signature: " + sigValue))
+
+	digest := sha256.Sum256(hashed.Bytes())
+	var signature []byte
+	var err error
+	switch key := s.key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(key, digest[:])
+	default:
+		err = errors.New("unsupported private key type")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(foldHeader("DKIM-Signature: " + sigValue + base64.StdEncoding.EncodeToString(signature)))
+	out.Write(message)
+	return out.Bytes(), nil
+}
+
+// normalizeLineEndings converts bare LF line endings to CRLF
+func normalizeLineEndings(message []byte) []byte {
+	message = bytes.ReplaceAll(message, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(message, []byte("\n"), []byte("\r\n"))
+}
+
+// splitMessage separates the header block (with its final CRLF) from the body
+func splitMessage(message []byte) (string, []byte) {
+	if bytes.HasPrefix(message, []byte("\r\n")) {
+		return "", message[2:]
+	}
+	if i := bytes.Index(message, []byte("\r\n\r\n")); i >= 0 {
+		return string(message[:i+2]), message[i+4:]
+	}
+	return string(message), nil
+}
+
+// parseHeaderFields splits a header block into fields, keeping folded lines together
+func parseHeaderFields(header string) []string {
+	var fields []string
+	for _, line := range strings.SplitAfter(header, "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1] += line
+			continue
+		}
+		fields = append(fields, line)
+	}
+	for i, field := range fields {
+		fields[i] = strings.TrimSuffix(field, "\r\n")
+	}
+	return fields
+}
+
+// lastUnused finds the bottom-most instance of a header field not yet signed
+func lastUnused(fields []string, name string, used map[string]int) (string, bool) {
+	skip := used[name]
+	for i := len(fields) - 1; i >= 0; i-- {
+		colon := strings.IndexByte(fields[i], ':')
+		if colon < 0 || strings.ToLower(strings.TrimSpace(fields[i][:colon])) != name {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		used[name]++
+		return fields[i], true
+	}
+	return "", false
+}
+
+// canonicalHeader applies the "relaxed" header canonicalization (RFC 6376 3.4.2)
+func canonicalHeader(field string) string {
+	colon := strings.IndexByte(field, ':')
+	if colon < 0 {
+		return strings.ToLower(field)
+	}
+	name := strings.ToLower(strings.TrimSpace(field[:colon]))
+	value := field[colon+1:]
+	value = strings.ReplaceAll(value, "\r\n", "")
+	value = strings.TrimSpace(collapseWhitespace(value))
+	return name + ":" + value
+}
+
+// canonicalBody applies the "relaxed" body canonicalization (RFC 6376 3.4.4)
+func canonicalBody(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(collapseWhitespace(line), " ")
+	}
+	// Drop trailing empty lines
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+// collapseWhitespace reduces runs of spaces and tabs to a single space
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// foldHeader puts each tag of the signature on its own line and wraps the
+// b= value. Verifiers unfold and collapse whitespace (and ignore it inside b=),
+// so the folded header canonicalizes to the form that was hashed.
+func foldHeader(field string) string {
+	const width = 72
+	tags := strings.Split(field, "; ")
+	last := tags[len(tags)-1]
+	if strings.HasPrefix(last, "b=") {
+		var wrapped []string
+		for len(last) > width {
+			wrapped = append(wrapped, last[:width])
+			last = last[width:]
+		}
+		tags[len(tags)-1] = strings.Join(append(wrapped, last), "\r\n\t")
+	}
+	return strings.Join(tags, ";\r\n\t") + "\r\n"
+}
+
+// ParseHeaderList splits a comma or colon separated header list (e.g. from config)
+func ParseHeaderList(list string) []string {
+	var headers []string
+	for _, name := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ':' }) {
+		if name = strings.TrimSpace(name); name != "" {
+			headers = append(headers, name)
+		}
+	}
+	return headers
+}
+
+// ValidSelector reports whether a selector is a valid DNS label sequence
+func ValidSelector(selector string) bool {
+	if selector == "" || len(selector) > 63 {
+		return false
+	}
+	for _, label := range strings.Split(selector, ".") {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
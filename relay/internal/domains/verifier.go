@@ -0,0 +1,201 @@
+package domains
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+// RecordPrefix is the label under which the verification TXT record is published
+const RecordPrefix = "_mailpulse"
+
+// tokenPrefix prefixes the verification token inside the TXT record value
+const tokenPrefix = "mailpulse-verification="
+
+// Resolver looks up DNS TXT records (satisfied by *net.Resolver)
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// NewResolver returns a resolver that queries the given DNS server (host:port),
+// or the system resolver when server is empty
+func NewResolver(server string) *net.Resolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+	
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := net.Dialer{Timeout: 5 * time.Second}
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// Verifier checks domain ownership by looking for a TXT record containing the
+// domain's verification token
+type Verifier struct {
+	storage  storage.Storage
+	resolver Resolver
+	timeout  time.Duration
+}
+
+// NewVerifier creates a new domain verifier
+func NewVerifier(storage storage.Storage, resolver Resolver) *Verifier {
+	return &Verifier{
+		storage:  storage,
+		resolver: resolver,
+		timeout:  10 * time.Second,
+	}
+}
+
+// NormalizeDomain validates a domain name and returns it lowercased
+func NormalizeDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	
+	if len(domain) > 253 || !strings.Contains(domain, ".") {
+		return "", fmt.Errorf("invalid domain %q", domain)
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return "", fmt.Errorf("invalid domain %q", domain)
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return "", fmt.Errorf("invalid domain %q", domain)
+			}
+		}
+	}
+	
+	return domain, nil
+}
+
+// GenerateToken creates a random verification token
+func GenerateToken() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// RecordName returns the DNS name where the verification record must be published
+func RecordName(domain string) string {
+	return RecordPrefix + "." + domain
+}
+
+// RecordValue returns the TXT record value expected for a token
+func RecordValue(token string) string {
+	return tokenPrefix + token
+}
+
+// ErrRecordMissing means DNS answered but the expected token wasn't published
+var ErrRecordMissing = errors.New("verification TXT record not found")
+
+// lookup checks DNS for the domain's verification record. A nil error means
+// the record is present; ErrRecordMissing means it is definitely absent; any
+// other error is a transient lookup failure.
+func (v *Verifier) lookup(domain *storage.SenderDomain) error {
+	ctx, cancel := context.WithTimeout(context.Background(), v.timeout)
+	defer cancel()
+	
+	records, err := v.resolver.LookupTXT(ctx, RecordName(domain.Domain))
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return ErrRecordMissing
+		}
+		return fmt.Errorf("DNS lookup failed: %w", err)
+	}
+	
+	expected := RecordValue(domain.VerificationToken)
+	for _, record := range records {
+		if strings.TrimSpace(record) == expected {
+			return nil
+		}
+	}
+	
+	return ErrRecordMissing
+}
+
+// Verify checks a domain now and stores the resulting status. Like Recheck,
+// only a missing record fails the domain; a transient DNS error is returned
+// with the status left unchanged.
+func (v *Verifier) Verify(domain *storage.SenderDomain) (string, error) {
+	err := v.lookup(domain)
+	if err != nil && !errors.Is(err, ErrRecordMissing) {
+		return domain.Status, err
+	}
+	
+	status := storage.DomainStatusVerified
+	var lastError *string
+	if err != nil {
+		status = storage.DomainStatusFailed
+		msg := err.Error()
+		lastError = &msg
+	}
+	
+	if updateErr := v.storage.UpdateSenderDomainStatus(domain.ID, status, lastError); updateErr != nil {
+		return "", updateErr
+	}
+	
+	return status, err
+}
+
+// Recheck re-verifies every claimed domain. Verified domains whose record has
+// disappeared are suspended (marked failed); transient DNS errors leave the
+// status unchanged. Pending domains are promoted once their record appears.
+func (v *Verifier) Recheck() {
+	domains, err := v.storage.ListAllSenderDomains()
+	if err != nil {
+		log.Printf("⚠️  Domain re-check: failed to list domains: %v", err)
+		return
+	}
+	
+	for _, domain := range domains {
+		err := v.lookup(domain)
+		
+		switch {
+		case err == nil && domain.Status != storage.DomainStatusVerified:
+			if err := v.storage.UpdateSenderDomainStatus(domain.ID, storage.DomainStatusVerified, nil); err != nil {
+				log.Printf("⚠️  Domain re-check: failed to update %s: %v", domain.Domain, err)
+				continue
+			}
+			log.Printf("✅ Domain %s verified for project %s", domain.Domain, domain.ProjectID)
+		case errors.Is(err, ErrRecordMissing) && domain.Status == storage.DomainStatusVerified:
+			msg := "verification TXT record disappeared - domain suspended"
+			if err := v.storage.UpdateSenderDomainStatus(domain.ID, storage.DomainStatusFailed, &msg); err != nil {
+				log.Printf("⚠️  Domain re-check: failed to update %s: %v", domain.Domain, err)
+				continue
+			}
+			log.Printf("❌ Domain %s suspended for project %s: verification record removed", domain.Domain, domain.ProjectID)
+		case err != nil && !errors.Is(err, ErrRecordMissing):
+			log.Printf("⚠️  Domain re-check: %s: %v", domain.Domain, err)
+		}
+	}
+}
+
+// Start runs Recheck periodically in the background
+func (v *Verifier) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		
+		for range ticker.C {
+			v.Recheck()
+		}
+	}()
+}
@@ -0,0 +1,175 @@
+package domains
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+// stubResolver answers TXT lookups from a map; names without records are NXDOMAIN
+type stubResolver struct {
+	txt    map[string][]string
+	errors map[string]error
+}
+
+func (r *stubResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if err, ok := r.errors[name]; ok {
+		return nil, err
+	}
+	if records, ok := r.txt[name]; ok {
+		return records, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+// domainStore keeps sender domains in memory; other Storage methods are not used
+type domainStore struct {
+	storage.Storage
+	domains []*storage.SenderDomain
+	updates int
+}
+
+func (s *domainStore) ListAllSenderDomains() ([]*storage.SenderDomain, error) {
+	copies := make([]*storage.SenderDomain, 0, len(s.domains))
+	for _, domain := range s.domains {
+		copied := *domain
+		copies = append(copies, &copied)
+	}
+	return copies, nil
+}
+
+func (s *domainStore) UpdateSenderDomainStatus(id string, status string, lastError *string) error {
+	for _, domain := range s.domains {
+		if domain.ID == id {
+			domain.Status = status
+			domain.LastError = lastError
+			s.updates++
+			return nil
+		}
+	}
+	return errors.New("sender domain not found: " + id)
+}
+
+func (s *domainStore) domain(id string) *storage.SenderDomain {
+	for _, domain := range s.domains {
+		if domain.ID == id {
+			return domain
+		}
+	}
+	return nil
+}
+
+func senderDomain(id, name, token, status string) *storage.SenderDomain {
+	return &storage.SenderDomain{ID: id, ProjectID: "project-1", Domain: name, VerificationToken: token, Status: status}
+}
+
+func TestVerifyMarksDomainVerified(t *testing.T) {
+	store := &domainStore{domains: []*storage.SenderDomain{senderDomain("d1", "example.com", "token-1", storage.DomainStatusPending)}}
+	resolver := &stubResolver{txt: map[string][]string{
+		"_mailpulse.example.com": {"v=spf1 -all", "  " + RecordValue("token-1") + " "},
+	}}
+	
+	status, err := NewVerifier(store, resolver).Verify(store.domain("d1"))
+	if err != nil || status != storage.DomainStatusVerified {
+		t.Fatalf("Verify = %q, %v, want verified", status, err)
+	}
+	if got := store.domain("d1"); got.Status != storage.DomainStatusVerified || got.LastError != nil {
+		t.Errorf("stored domain %+v, want verified without error", got)
+	}
+}
+
+func TestVerifyMarksDomainFailed(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver *stubResolver
+		wantErr  string
+	}{
+		{"no record", &stubResolver{}, "verification TXT record not found"},
+		{"wrong token", &stubResolver{txt: map[string][]string{"_mailpulse.example.com": {RecordValue("other-token")}}}, "verification TXT record not found"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &domainStore{domains: []*storage.SenderDomain{senderDomain("d1", "example.com", "token-1", storage.DomainStatusPending)}}
+			
+			status, err := NewVerifier(store, tt.resolver).Verify(store.domain("d1"))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || status != storage.DomainStatusFailed {
+				t.Fatalf("Verify = %q, %v, want failed with %q", status, err, tt.wantErr)
+			}
+			got := store.domain("d1")
+			if got.Status != storage.DomainStatusFailed || got.LastError == nil || *got.LastError != err.Error() {
+				t.Errorf("stored domain %+v, want failed with the lookup error", got)
+			}
+		})
+	}
+}
+
+func TestVerifyKeepsStatusOnLookupError(t *testing.T) {
+	for _, status := range []string{storage.DomainStatusPending, storage.DomainStatusVerified} {
+		t.Run(status, func(t *testing.T) {
+			store := &domainStore{domains: []*storage.SenderDomain{senderDomain("d1", "example.com", "token-1", status)}}
+			resolver := &stubResolver{errors: map[string]error{
+				"_mailpulse.example.com": &net.DNSError{Err: "server misbehaving", Name: "_mailpulse.example.com"},
+			}}
+			
+			got, err := NewVerifier(store, resolver).Verify(store.domain("d1"))
+			if err == nil || errors.Is(err, ErrRecordMissing) || !strings.Contains(err.Error(), "DNS lookup failed") {
+				t.Fatalf("Verify error = %v, want a transient lookup failure", err)
+			}
+			if got != status || store.domain("d1").Status != status || store.updates != 0 {
+				t.Errorf("Verify = %q, stored %q after %d updates, want %q unchanged", got, store.domain("d1").Status, store.updates, status)
+			}
+		})
+	}
+}
+
+func TestRecheck(t *testing.T) {
+	store := &domainStore{domains: []*storage.SenderDomain{
+		senderDomain("pending-published", "a.example", "token-a", storage.DomainStatusPending),
+		senderDomain("pending-missing", "b.example", "token-b", storage.DomainStatusPending),
+		senderDomain("verified-kept", "c.example", "token-c", storage.DomainStatusVerified),
+		senderDomain("verified-removed", "d.example", "token-d", storage.DomainStatusVerified),
+		senderDomain("verified-dns-down", "e.example", "token-e", storage.DomainStatusVerified),
+		senderDomain("failed-fixed", "f.example", "token-f", storage.DomainStatusFailed),
+	}}
+	resolver := &stubResolver{
+		txt: map[string][]string{
+			"_mailpulse.a.example": {RecordValue("token-a")},
+			"_mailpulse.c.example": {RecordValue("token-c")},
+			"_mailpulse.d.example": {"unrelated"},
+			"_mailpulse.f.example": {RecordValue("token-f")},
+		},
+		errors: map[string]error{
+			"_mailpulse.e.example": &net.DNSError{Err: "i/o timeout", Name: "_mailpulse.e.example", IsTimeout: true},
+		},
+	}
+	
+	NewVerifier(store, resolver).Recheck()
+	
+	want := map[string]string{
+		"pending-published": storage.DomainStatusVerified,
+		"pending-missing":   storage.DomainStatusPending,
+		"verified-kept":     storage.DomainStatusVerified,
+		"verified-removed":  storage.DomainStatusFailed,
+		"verified-dns-down": storage.DomainStatusVerified,
+		"failed-fixed":      storage.DomainStatusVerified,
+	}
+	for id, status := range want {
+		if got := store.domain(id).Status; got != status {
+			t.Errorf("%s: status %q, want %q", id, got, status)
+		}
+	}
+	
+	suspended := store.domain("verified-removed")
+	if suspended.LastError == nil || !strings.Contains(*suspended.LastError, "suspended") {
+		t.Errorf("suspended domain error = %v, want a suspension message", suspended.LastError)
+	}
+	// Only the three domains whose status changed are written
+	if store.updates != 3 {
+		t.Errorf("%d status updates, want 3", store.updates)
+	}
+}
@@ -0,0 +1,191 @@
+package jobs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+// progressEvery is how many emails are processed between progress updates
+const progressEvery = 100
+
+// heartbeat is the longest a running job goes without updating its progress
+const heartbeat = 30 * time.Second
+
+// staleAfter is how long a running job may go without a progress update
+// before it is considered interrupted
+const staleAfter = 5 * time.Minute
+
+// eligibleStatuses lists the email statuses each job type applies to
+var eligibleStatuses = map[string][]string{
+	storage.JobTypeRetry:  {"failed", "deferred"},
+	storage.JobTypeCancel: {storage.EmailStatusQueued, storage.EmailStatusScheduled},
+}
+
+// Runner runs bulk email operations as background jobs and records their
+// progress. Retried emails are put back in the delivery queue, which wake
+// (may be nil) is called to process.
+type Runner struct {
+	storage storage.Storage
+	wake    func()
+}
+
+// NewRunner creates a new job runner
+func NewRunner(storage storage.Storage, wake func()) *Runner {
+	return &Runner{
+		storage: storage,
+		wake:    wake,
+	}
+}
+
+// Start periodically fails jobs left running by a relay that stopped
+// (checked every interval) until the process exits
+func (r *Runner) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		
+		for {
+			failed, err := r.storage.FailStaleJobs(staleAfter, "Interrupted: the relay running the job stopped")
+			if err != nil {
+				log.Printf("⚠️  Failed to check for interrupted jobs: %v", err)
+			} else if failed > 0 {
+				log.Printf("⚠️  Marked %d interrupted job(s) as failed", failed)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Run starts a job of jobType on the emails matching filter. An empty
+// filter.Statuses selects every status the job type applies to; other
+// statuses are rejected.
+func (r *Runner) Run(jobType string, filter storage.EmailFilter) (*storage.Job, error) {
+	eligible, ok := eligibleStatuses[jobType]
+	if !ok {
+		return nil, fmt.Errorf("unknown job type %q", jobType)
+	}
+	for _, status := range filter.Statuses {
+		if !Eligible(jobType, status) {
+			return nil, fmt.Errorf("a %s job cannot apply to %s emails", jobType, status)
+		}
+	}
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = eligible
+	}
+	
+	job := &storage.Job{
+		ID:        newID("job_"),
+		Type:      jobType,
+		Filter:    filter,
+		Status:    storage.JobStatusRunning,
+		CreatedAt: time.Now(),
+	}
+	job.UpdatedAt = job.CreatedAt
+	if filter.ProjectID != "" {
+		job.ProjectID = &filter.ProjectID
+	}
+	if err := r.storage.CreateJob(job); err != nil {
+		return nil, err
+	}
+	
+	running := *job
+	go r.run(&running)
+	return job, nil
+}
+
+// run applies a job to each matching email, recording progress as it goes
+func (r *Runner) run(job *storage.Job) {
+	ids, err := r.storage.ListEmailIDs(job.Filter)
+	if err != nil {
+		r.finish(job, err)
+		return
+	}
+	job.Total = len(ids)
+	log.Printf("⏳ Job %s: %s of %d email(s) started", job.ID, job.Type, job.Total)
+	
+	lastUpdate := time.Now()
+	for _, id := range ids {
+		applied, err := r.apply(job.Type, id)
+		if err != nil {
+			r.finish(job, err)
+			return
+		}
+		job.Processed++
+		if applied {
+			job.Succeeded++
+		}
+		
+		if job.Processed%progressEvery == 0 || time.Since(lastUpdate) > heartbeat {
+			if err := r.storage.UpdateJobProgress(job.ID, job.Total, job.Processed, job.Succeeded); err != nil {
+				log.Printf("⚠️  Failed to record progress of job %s: %v", job.ID, err)
+			}
+			lastUpdate = time.Now()
+			
+			if job.Type == storage.JobTypeRetry && r.wake != nil {
+				r.wake() // Start delivering what is already queued
+			}
+		}
+	}
+	
+	r.finish(job, nil)
+}
+
+// apply runs the job's operation on one email. It returns false when the
+// email's status changed since it was selected.
+func (r *Runner) apply(jobType string, emailID string) (bool, error) {
+	switch jobType {
+	case storage.JobTypeRetry:
+		return r.storage.RequeueEmail(emailID)
+	case storage.JobTypeCancel:
+		return r.storage.CancelEmail(emailID)
+	}
+	return false, fmt.Errorf("unknown job type %q", jobType)
+}
+
+// finish records the final progress and status of a job
+func (r *Runner) finish(job *storage.Job, jobErr error) {
+	if err := r.storage.UpdateJobProgress(job.ID, job.Total, job.Processed, job.Succeeded); err != nil {
+		log.Printf("⚠️  Failed to record progress of job %s: %v", job.ID, err)
+	}
+	
+	status := storage.JobStatusCompleted
+	var errorMsg *string
+	if jobErr != nil {
+		status = storage.JobStatusFailed
+		message := jobErr.Error()
+		errorMsg = &message
+		log.Printf("❌ Job %s failed after %d of %d email(s): %v", job.ID, job.Processed, job.Total, jobErr)
+	} else {
+		log.Printf("✅ Job %s: %s applied to %d of %d email(s)", job.ID, job.Type, job.Succeeded, job.Total)
+	}
+	
+	if err := r.storage.FinishJob(job.ID, status, errorMsg); err != nil {
+		log.Printf("⚠️  Failed to finish job %s: %v", job.ID, err)
+	}
+	
+	if job.Type == storage.JobTypeRetry && job.Succeeded > 0 && r.wake != nil {
+		r.wake()
+	}
+}
+
+// Eligible reports whether a job of jobType can apply to emails with status
+func Eligible(jobType string, status string) bool {
+	for _, eligible := range eligibleStatuses[jobType] {
+		if status == eligible {
+			return true
+		}
+	}
+	return false
+}
+
+// newID generates a random ID with the given prefix
+func newID(prefix string) string {
+	bytes := make([]byte, 12)
+	rand.Read(bytes)
+	return prefix + hex.EncodeToString(bytes)
+}
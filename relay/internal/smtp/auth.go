@@ -0,0 +1,286 @@
+package smtp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/crypto"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+// tokenRefreshMargin renews access tokens shortly before they expire
+const tokenRefreshMargin = 60 * time.Second
+
+// ValidateUpstreamAuth checks a project's upstream authentication settings
+func ValidateUpstreamAuth(settings storage.UpstreamAuthSettings) error {
+	switch settings.Mechanism {
+	case storage.UpstreamAuthPlain, storage.UpstreamAuthLogin, storage.UpstreamAuthCRAMMD5:
+		return nil
+	case storage.UpstreamAuthXOAUTH2:
+	default:
+		return fmt.Errorf("invalid upstream auth mechanism %q (use plain, login, cram-md5 or xoauth2)", settings.Mechanism)
+	}
+	
+	if settings.OAuthTokenURL == nil || *settings.OAuthTokenURL == "" {
+		return errors.New("xoauth2 requires an OAuth token URL")
+	}
+	parsed, err := url.Parse(*settings.OAuthTokenURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return errors.New("OAuth token URL must be an http(s) URL")
+	}
+	if settings.OAuthClientID == nil || *settings.OAuthClientID == "" {
+		return errors.New("xoauth2 requires an OAuth client ID")
+	}
+	if settings.OAuthRefreshTokenEnc == nil || *settings.OAuthRefreshTokenEnc == "" {
+		return errors.New("xoauth2 requires an OAuth refresh token")
+	}
+	
+	return nil
+}
+
+// upstreamAuth returns the smtp.Auth for the project's mechanism
+func (f *EmailForwarder) upstreamAuth(project *storage.Project, host, user, pass string) (smtp.Auth, error) {
+	switch project.UpstreamAuth.Mechanism {
+	case storage.UpstreamAuthLogin:
+		return &loginAuth{username: user, password: pass, host: host}, nil
+	case storage.UpstreamAuthCRAMMD5:
+		return smtp.CRAMMD5Auth(user, pass), nil
+	case storage.UpstreamAuthXOAUTH2:
+		token, err := f.tokens.accessToken(project)
+		if err != nil {
+			return nil, err
+		}
+		return &xoauth2Auth{username: user, token: token, host: host}, nil
+	default:
+		return smtp.PlainAuth("", user, pass, host), nil
+	}
+}
+
+// isLocalhost reports whether credentials may be sent in the clear to host
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// loginAuth implements the LOGIN mechanism. Like smtp.PlainAuth it refuses
+// to send credentials over an unencrypted connection except to localhost.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	
+	prompt := strings.ToLower(strings.TrimSpace(string(fromServer)))
+	switch {
+	case strings.HasPrefix(prompt, "username"):
+		return []byte(a.username), nil
+	case strings.HasPrefix(prompt, "password"):
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected LOGIN prompt %q", fromServer)
+	}
+}
+
+// xoauth2Auth implements the XOAUTH2 mechanism used by Gmail and Microsoft 365
+type xoauth2Auth struct {
+	username string
+	token    string
+	host     string
+}
+
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "XOAUTH2", []byte("user=" + a.username + "\x01auth=Bearer " + a.token + "\x01\x01"), nil
+}
+
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		// The server sent a JSON error challenge; an empty reply makes it
+		// finish with the final failure response
+		return []byte{}, nil
+	}
+	return nil, nil
+}
+
+// cachedToken is an access token obtained with a particular refresh token
+type cachedToken struct {
+	accessToken     string
+	expiresAt       time.Time
+	refreshTokenEnc string
+}
+
+// oauthTokens refreshes and caches XOAUTH2 access tokens per project
+type oauthTokens struct {
+	mu         sync.Mutex // guards tokens and refreshing, never held during a refresh
+	tokens     map[string]cachedToken
+	refreshing map[string]*sync.Mutex // per-project locks so only one refresh runs at a time
+	client     *http.Client
+	storage    storage.Storage
+}
+
+// newOAuthTokens creates an empty token cache
+func newOAuthTokens(storage storage.Storage) *oauthTokens {
+	return &oauthTokens{
+		tokens:     make(map[string]cachedToken),
+		refreshing: make(map[string]*sync.Mutex),
+		client:     &http.Client{Timeout: 15 * time.Second},
+		storage:    storage,
+	}
+}
+
+// refreshLock returns the lock serialising token refreshes of a project
+func (t *oauthTokens) refreshLock(projectID string) *sync.Mutex {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	lock, ok := t.refreshing[projectID]
+	if !ok {
+		lock = &sync.Mutex{}
+		t.refreshing[projectID] = lock
+	}
+	return lock
+}
+
+// cached returns the project's access token if it was obtained with the
+// current refresh token and is not about to expire
+func (t *oauthTokens) cached(projectID, refreshTokenEnc string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	cached, ok := t.tokens[projectID]
+	if ok && cached.refreshTokenEnc == refreshTokenEnc && time.Now().Add(tokenRefreshMargin).Before(cached.expiresAt) {
+		return cached.accessToken, true
+	}
+	return "", false
+}
+
+// tokenResponse is the token endpoint reply (RFC 6749 section 5.1)
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// accessToken returns a valid access token for the project, refreshing it
+// against the configured token endpoint when missing or about to expire.
+// Rotated refresh tokens are stored back on the project. A slow token
+// endpoint only holds up deliveries of its own project.
+func (t *oauthTokens) accessToken(project *storage.Project) (string, error) {
+	settings := project.UpstreamAuth
+	if err := ValidateUpstreamAuth(settings); err != nil {
+		return "", err
+	}
+	
+	lock := t.refreshLock(project.ID)
+	lock.Lock()
+	defer lock.Unlock()
+	
+	// A concurrent delivery may have refreshed the token while we waited
+	if token, ok := t.cached(project.ID, *settings.OAuthRefreshTokenEnc); ok {
+		return token, nil
+	}
+	
+	refreshToken, err := crypto.DecryptOAuthSecret(*settings.OAuthRefreshTokenEnc)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt OAuth refresh token: %w", err)
+	}
+	
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {*settings.OAuthClientID},
+	}
+	if settings.OAuthClientSecretEnc != nil && *settings.OAuthClientSecretEnc != "" {
+		secret, err := crypto.DecryptOAuthSecret(*settings.OAuthClientSecretEnc)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt OAuth client secret: %w", err)
+		}
+		form.Set("client_secret", secret)
+	}
+	if settings.OAuthScope != nil && *settings.OAuthScope != "" {
+		form.Set("scope", *settings.OAuthScope)
+	}
+	
+	resp, err := t.client.PostForm(*settings.OAuthTokenURL, form)
+	if err != nil {
+		return "", fmt.Errorf("OAuth token refresh failed: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	var token tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("OAuth token refresh failed: invalid response (HTTP %d)", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || token.AccessToken == "" {
+		if token.Error != "" {
+			return "", fmt.Errorf("OAuth token refresh failed: %s", strings.TrimSpace(token.Error+" "+token.ErrorDescription))
+		}
+		return "", fmt.Errorf("OAuth token refresh failed: HTTP %d", resp.StatusCode)
+	}
+	
+	expiresIn := time.Duration(token.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = time.Hour
+	}
+	
+	refreshTokenEnc := *settings.OAuthRefreshTokenEnc
+	if token.RefreshToken != "" && token.RefreshToken != refreshToken {
+		encrypted, err := crypto.EncryptOAuthSecret(token.RefreshToken)
+		if err != nil {
+			return "", fmt.Errorf("failed to encrypt rotated refresh token: %w", err)
+		}
+		if err := t.storage.UpdateOAuthRefreshToken(project.ID, encrypted); err != nil {
+			log.Printf("⚠️  Failed to store rotated refresh token for project %s: %v", project.ID, err)
+		} else {
+			refreshTokenEnc = encrypted
+			project.UpstreamAuth.OAuthRefreshTokenEnc = &encrypted
+		}
+	}
+	
+	t.mu.Lock()
+	t.tokens[project.ID] = cachedToken{
+		accessToken:     token.AccessToken,
+		expiresAt:       time.Now().Add(expiresIn),
+		refreshTokenEnc: refreshTokenEnc,
+	}
+	t.mu.Unlock()
+	log.Printf("🔑 Refreshed OAuth access token for project %s (expires in %s)", project.ID, expiresIn)
+	
+	return token.AccessToken, nil
+}
+
+// invalidate drops a project's cached access token, e.g. after the upstream rejected it
+func (t *oauthTokens) invalidate(projectID string) {
+	t.mu.Lock()
+	delete(t.tokens, projectID)
+	t.mu.Unlock()
+}
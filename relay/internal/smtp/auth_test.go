@@ -0,0 +1,257 @@
+package smtp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/crypto"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+// refreshTokenStore records rotated refresh tokens; other Storage methods are not used
+type refreshTokenStore struct {
+	storage.Storage
+	saved map[string]string
+	err   error
+}
+
+func (s *refreshTokenStore) UpdateOAuthRefreshToken(projectID string, refreshTokenEnc string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.saved[projectID] = refreshTokenEnc
+	return nil
+}
+
+// tokenServer is a mock OAuth token endpoint replying with the queued responses
+type tokenServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	forms     []url.Values
+	status    int
+	responses []map[string]interface{}
+}
+
+func newTokenServer(t *testing.T, status int, responses ...map[string]interface{}) *tokenServer {
+	t.Helper()
+	server := &tokenServer{status: status, responses: responses}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		server.forms = append(server.forms, r.PostForm)
+		response := server.responses[0]
+		if len(server.responses) > 1 {
+			server.responses = server.responses[1:]
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(server.status)
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *tokenServer) requests() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]url.Values(nil), s.forms...)
+}
+
+func oauthProject(t *testing.T, tokenURL, refreshToken string) *storage.Project {
+	t.Helper()
+	refreshTokenEnc, err := crypto.EncryptOAuthSecret(refreshToken)
+	if err != nil {
+		t.Fatalf("encrypt refresh token: %v", err)
+	}
+	clientSecretEnc, err := crypto.EncryptOAuthSecret("client-secret")
+	if err != nil {
+		t.Fatalf("encrypt client secret: %v", err)
+	}
+	return &storage.Project{
+		ID: "project-1",
+		UpstreamAuth: storage.UpstreamAuthSettings{
+			Mechanism:            storage.UpstreamAuthXOAUTH2,
+			OAuthTokenURL:        &tokenURL,
+			OAuthClientID:        stringPtr("client-id"),
+			OAuthClientSecretEnc: &clientSecretEnc,
+			OAuthRefreshTokenEnc: &refreshTokenEnc,
+			OAuthScope:           stringPtr("https://mail.example.test/"),
+		},
+	}
+}
+
+func TestOAuthAccessTokenRefreshesAndCaches(t *testing.T) {
+	server := newTokenServer(t, http.StatusOK,
+		map[string]interface{}{"access_token": "access-1", "expires_in": 3600},
+		map[string]interface{}{"access_token": "access-2", "expires_in": 3600})
+	store := &refreshTokenStore{saved: map[string]string{}}
+	tokens := newOAuthTokens(store)
+	project := oauthProject(t, server.URL, "refresh-1")
+	
+	for i := 0; i < 3; i++ {
+		token, err := tokens.accessToken(project)
+		if err != nil {
+			t.Fatalf("accessToken: %v", err)
+		}
+		if token != "access-1" {
+			t.Errorf("call %d: token = %q, want the cached access-1", i, token)
+		}
+	}
+	
+	forms := server.requests()
+	if len(forms) != 1 {
+		t.Fatalf("token endpoint called %d times, want 1", len(forms))
+	}
+	form := forms[0]
+	if form.Get("grant_type") != "refresh_token" || form.Get("refresh_token") != "refresh-1" ||
+		form.Get("client_id") != "client-id" || form.Get("client_secret") != "client-secret" ||
+		form.Get("scope") != "https://mail.example.test/" {
+		t.Errorf("unexpected token request %v", form)
+	}
+	if len(store.saved) != 0 {
+		t.Errorf("refresh token saved although it was not rotated: %v", store.saved)
+	}
+}
+
+func TestOAuthAccessTokenRefreshesBeforeExpiry(t *testing.T) {
+	// Tokens expiring within the refresh margin are renewed on the next use
+	server := newTokenServer(t, http.StatusOK,
+		map[string]interface{}{"access_token": "access-1", "expires_in": int(tokenRefreshMargin / time.Second)},
+		map[string]interface{}{"access_token": "access-2", "expires_in": 3600})
+	tokens := newOAuthTokens(&refreshTokenStore{saved: map[string]string{}})
+	project := oauthProject(t, server.URL, "refresh-1")
+	
+	first, err := tokens.accessToken(project)
+	if err != nil {
+		t.Fatalf("accessToken: %v", err)
+	}
+	second, err := tokens.accessToken(project)
+	if err != nil {
+		t.Fatalf("accessToken: %v", err)
+	}
+	if first != "access-1" || second != "access-2" || len(server.requests()) != 2 {
+		t.Errorf("tokens %q then %q after %d requests, want a refresh before expiry", first, second, len(server.requests()))
+	}
+	
+	tokens.invalidate(project.ID)
+	if _, err := tokens.accessToken(project); err != nil || len(server.requests()) != 3 {
+		t.Errorf("invalidated token not refreshed (err %v, %d requests)", err, len(server.requests()))
+	}
+}
+
+func TestOAuthAccessTokenStoresRotatedRefreshToken(t *testing.T) {
+	server := newTokenServer(t, http.StatusOK,
+		map[string]interface{}{"access_token": "access-1", "expires_in": 3600, "refresh_token": "refresh-2"})
+	store := &refreshTokenStore{saved: map[string]string{}}
+	tokens := newOAuthTokens(store)
+	project := oauthProject(t, server.URL, "refresh-1")
+	
+	if _, err := tokens.accessToken(project); err != nil {
+		t.Fatalf("accessToken: %v", err)
+	}
+	
+	saved, ok := store.saved[project.ID]
+	if !ok {
+		t.Fatal("rotated refresh token was not stored")
+	}
+	if decrypted, err := crypto.DecryptOAuthSecret(saved); err != nil || decrypted != "refresh-2" {
+		t.Errorf("stored refresh token decrypts to %q (%v), want refresh-2", decrypted, err)
+	}
+	if *project.UpstreamAuth.OAuthRefreshTokenEnc != saved {
+		t.Error("project still holds the old refresh token")
+	}
+	
+	// The cached access token is still valid for the rotated refresh token
+	if token, err := tokens.accessToken(project); err != nil || token != "access-1" || len(server.requests()) != 1 {
+		t.Errorf("token %q (%v) after %d requests, want the cached token", token, err, len(server.requests()))
+	}
+}
+
+func TestOAuthAccessTokenKeepsCacheWhenRotationCannotBeStored(t *testing.T) {
+	server := newTokenServer(t, http.StatusOK,
+		map[string]interface{}{"access_token": "access-1", "expires_in": 3600, "refresh_token": "refresh-2"})
+	tokens := newOAuthTokens(&refreshTokenStore{saved: map[string]string{}, err: errors.New("database is down")})
+	project := oauthProject(t, server.URL, "refresh-1")
+	original := *project.UpstreamAuth.OAuthRefreshTokenEnc
+	
+	token, err := tokens.accessToken(project)
+	if err != nil || token != "access-1" {
+		t.Fatalf("accessToken = %q, %v", token, err)
+	}
+	if *project.UpstreamAuth.OAuthRefreshTokenEnc != original {
+		t.Error("project refresh token replaced although it was not stored")
+	}
+}
+
+func TestOAuthAccessTokenReturnsEndpointError(t *testing.T) {
+	server := newTokenServer(t, http.StatusBadRequest,
+		map[string]interface{}{"error": "invalid_grant", "error_description": "Token has been expired or revoked."})
+	tokens := newOAuthTokens(&refreshTokenStore{saved: map[string]string{}})
+	project := oauthProject(t, server.URL, "refresh-1")
+	
+	_, err := tokens.accessToken(project)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "invalid_grant Token has been expired or revoked.") {
+		t.Errorf("error = %q, want the endpoint's error and description", err)
+	}
+	
+	// Failures are not cached
+	if _, err := tokens.accessToken(project); err == nil || len(server.requests()) != 2 {
+		t.Errorf("second call err %v after %d requests, want another refresh attempt", err, len(server.requests()))
+	}
+}
+
+func TestOAuthAccessTokenRejectsInvalidResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	}))
+	defer server.Close()
+	tokens := newOAuthTokens(&refreshTokenStore{saved: map[string]string{}})
+	
+	_, err := tokens.accessToken(oauthProject(t, server.URL, "refresh-1"))
+	if err == nil || !strings.Contains(err.Error(), "HTTP 502") {
+		t.Errorf("error = %v, want the HTTP status", err)
+	}
+}
+
+func TestOAuthAccessTokenSlowEndpointOnlyBlocksItsProject(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "slow", "expires_in": 3600})
+	}))
+	defer slow.Close()
+	defer close(release)
+	fast := newTokenServer(t, http.StatusOK, map[string]interface{}{"access_token": "fast", "expires_in": 3600})
+	tokens := newOAuthTokens(&refreshTokenStore{saved: map[string]string{}})
+	
+	go tokens.accessToken(oauthProject(t, slow.URL, "refresh-slow"))
+	time.Sleep(50 * time.Millisecond) // let the slow refresh start
+	
+	other := oauthProject(t, fast.URL, "refresh-fast")
+	other.ID = "project-2"
+	done := make(chan string, 1)
+	go func() {
+		token, _ := tokens.accessToken(other)
+		done <- token
+	}()
+	
+	select {
+	case token := <-done:
+		if token != "fast" {
+			t.Errorf("token = %q, want fast", token)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("refresh for another project waited on the slow token endpoint")
+	}
+}
@@ -0,0 +1,112 @@
+package smtp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+)
+
+// MessageContent is the readable content of a MIME message
+type MessageContent struct {
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Attachment is a decoded attachment (or inline part that is not a body)
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// ParseMessageContent extracts the text and HTML bodies and the attachments
+// from a message, decoding base64 and quoted-printable parts
+func ParseMessageContent(raw []byte) (*MessageContent, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+	
+	content := &MessageContent{}
+	if err := walkPart(content, msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"),
+		msg.Header.Get("Content-Disposition"), msg.Body); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// walkPart adds one MIME part to content, descending into multiparts
+func walkPart(content *MessageContent, contentType, encoding, disposition string, body io.Reader) error {
+	if contentType == "" {
+		contentType = "text/plain; charset=UTF-8"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read MIME part: %w", err)
+			}
+			if err := walkPart(content, part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"),
+				part.Header.Get("Content-Disposition"), part); err != nil {
+				return err
+			}
+		}
+	}
+	
+	data, err := io.ReadAll(decodeTransfer(encoding, body))
+	if err != nil {
+		return fmt.Errorf("failed to decode MIME part: %w", err)
+	}
+	
+	dispositionType, dispositionParams, _ := mime.ParseMediaType(disposition)
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	isAttachment := dispositionType == "attachment" || filename != ""
+	
+	switch {
+	case mediaType == "text/plain" && !isAttachment && content.Text == "":
+		content.Text = string(data)
+	case mediaType == "text/html" && !isAttachment && content.HTML == "":
+		content.HTML = string(data)
+	default:
+		if filename == "" {
+			filename = fmt.Sprintf("part-%d", len(content.Attachments)+1)
+		}
+		content.Attachments = append(content.Attachments, Attachment{
+			Filename:    filename,
+			ContentType: mediaType,
+			Data:        data,
+		})
+	}
+	return nil
+}
+
+// decodeTransfer undoes a Content-Transfer-Encoding
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body) // line breaks are ignored
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
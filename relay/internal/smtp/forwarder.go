@@ -1,132 +1,325 @@
 package smtp
 
 import (
+	"bytes"
 	"fmt"
 	"log"
-	"net/smtp"
+	"mime"
+	"net/mail"
+	"os"
 	"strings"
 
 	"github.com/Renespeare/mailpulse/relay/internal/auth"
+	"github.com/Renespeare/mailpulse/relay/internal/bounce"
 	"github.com/Renespeare/mailpulse/relay/internal/crypto"
+	"github.com/Renespeare/mailpulse/relay/internal/dkim"
+	"github.com/Renespeare/mailpulse/relay/internal/domains"
 	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/Renespeare/mailpulse/relay/internal/webhooks"
 )
 
 // EmailForwarder handles forwarding emails to upstream SMTP servers
 type EmailForwarder struct {
-	authManager auth.AuthManager
-	storage     storage.Storage
+	authManager  auth.AuthManager
+	storage      storage.Storage
+	dkimHeaders  []string
+	mx           *MXDeliverer
+	breaker      *circuitBreaker
+	pool         *upstreamPool
+	throttle     *domainThrottle
+	tokens       *oauthTokens
+	bounceDomain string
+	trackingURL  string
+	events       *webhooks.Dispatcher
+	wake         chan struct{}
 }
 
-// NewEmailForwarder creates a new email forwarder
-func NewEmailForwarder(authManager auth.AuthManager, storage storage.Storage) *EmailForwarder {
+// NewEmailForwarder creates a new email forwarder. Status changes are
+// reported to the project's webhooks through events (which may be nil).
+func NewEmailForwarder(authManager auth.AuthManager, storage storage.Storage, events *webhooks.Dispatcher) *EmailForwarder {
 	return &EmailForwarder{
-		authManager: authManager,
-		storage:     storage,
+		authManager:  authManager,
+		storage:      storage,
+		dkimHeaders:  dkim.ParseHeaderList(os.Getenv("DKIM_SIGNED_HEADERS")),
+		mx:           NewMXDeliverer(domains.NewResolver(os.Getenv("DNS_RESOLVER"))),
+		breaker:      newCircuitBreakerFromEnv(),
+		pool:         newUpstreamPoolFromEnv(),
+		throttle:     newDomainThrottleFromEnv(),
+		tokens:       newOAuthTokens(storage),
+		bounceDomain: os.Getenv("BOUNCE_DOMAIN"),
+		trackingURL:  os.Getenv("TRACKING_BASE_URL"),
+		events:       events,
+		wake:         make(chan struct{}, 1),
 	}
 }
 
-// ForwardEmail forwards an email using the project's SMTP settings
-func (f *EmailForwarder) ForwardEmail(email *storage.Email, projectID string) error {
+// ForwardEmail forwards an email to the given recipients using the project's
+// delivery settings. Results are nil when the message failed as a whole.
+func (f *EmailForwarder) ForwardEmail(email *storage.Email, projectID string, recipients []string) ([]RecipientResult, error) {
 	// Get project details from database
 	project, err := f.storage.GetProject(projectID)
 	if err != nil {
-		return fmt.Errorf("failed to get project configuration: %w", err)
+		return nil, fmt.Errorf("failed to get project configuration: %w", err)
 	}
 	
 	// Check if project is active
 	if project.Status != "active" {
-		return fmt.Errorf("project %s is not active", projectID)
+		return nil, fmt.Errorf("project %s is not active", projectID)
 	}
 	
-	// Check if project has SMTP configuration for real forwarding
-	if project.SMTPHost != nil && *project.SMTPHost != "" && 
-	   project.SMTPUser != nil && *project.SMTPUser != "" && 
-	   project.SMTPPasswordEnc != nil && *project.SMTPPasswordEnc != "" {
+	// The one-click unsubscribe URL names its recipient, so each recipient
+	// gets a message of their own
+	if len(recipients) > 1 && project.ListUnsubscribe && f.trackingURL != "" {
+		var results []RecipientResult
+		for _, rcpt := range recipients {
+			rcptResults, err := f.deliver(email, project, []string{rcpt})
+			if rcptResults == nil {
+				rcptResults = allRecipients([]string{rcpt}, RecipientFailed, 0, err.Error(), "")
+			}
+			results = append(results, rcptResults...)
+		}
+		return results, resultsError(results)
+	}
+	
+	return f.deliver(email, project, recipients)
+}
+
+// deliver sends one message to the recipients using the project's delivery
+// mode. Results are nil when the message failed as a whole.
+func (f *EmailForwarder) deliver(email *storage.Email, project *storage.Project, recipients []string) ([]RecipientResult, error) {
+	projectID := project.ID
+	
+	// Sandbox projects capture messages instead of sending them
+	if project.DeliveryMode == storage.DeliveryModeSandbox {
+		log.Printf("📤 [SANDBOX] Capturing email %s for project %s (%s)", email.ID, project.Name, projectID)
+		
+		message := f.outgoingMessage(email, project, recipients)
+		return f.deliverSandbox(email, recipients, message)
+	}
+	
+	// Direct-to-MX delivery needs no upstream SMTP host
+	if project.DeliveryMode == storage.DeliveryModeMX {
+		log.Printf("📤 Direct MX delivery of email %s for project %s (%s)", email.ID, project.Name, projectID)
 		
-		// Decrypt SMTP password
-		smtpPassword, err := crypto.DecryptSMTPPassword(*project.SMTPPasswordEnc)
+		message := f.outgoingMessage(email, project, recipients)
+		results := f.mx.Deliver(f.returnPath(email), recipients, message)
+		return results, resultsError(results)
+	}
+	
+	// HTTP email APIs (webhook, SES, SendGrid)
+	if IsTransportMode(project.DeliveryMode) {
+		transport, err := transportFor(project)
 		if err != nil {
-			log.Printf("⚠️  Failed to decrypt SMTP password for project %s: %v", projectID, err)
-			return fmt.Errorf("failed to decrypt SMTP password: %w", err)
+			return nil, err
 		}
+		log.Printf("📤 Delivering email %s for project %s (%s) via %s", email.ID, project.Name, projectID, transport.Name())
 		
-		smtpHost := *project.SMTPHost
-		smtpPort := 587 // default
-		if project.SMTPPort != nil && *project.SMTPPort > 0 {
-			smtpPort = *project.SMTPPort
+		message := f.outgoingMessage(email, project, recipients)
+		results, err := transport.Send(email, recipients, message)
+		for _, result := range results {
+			if result.Status == RecipientDelivered {
+				if err := f.storage.SetEmailUpstream(email.ID, transport.Name(), nil, nil); err != nil {
+					log.Printf("⚠️  Failed to record transport for email %s: %v", email.ID, err)
+				}
+				break
+			}
 		}
-		smtpUser := *project.SMTPUser
+		return results, err
+	}
+	
+	// Relay through the project's upstream SMTP hosts, failing over between them
+	upstreams, err := f.upstreamsFor(project)
+	if err != nil {
+		return nil, err
+	}
+	if len(upstreams) > 0 {
+		log.Printf("📤 Real SMTP forwarding email %s for project %s (%s) via %d upstream(s)", 
+			email.ID, project.Name, projectID, len(upstreams))
 		
-		log.Printf("📤 Real SMTP forwarding email %s for project %s (%s) via %s:%d", 
-			email.ID, project.Name, projectID, smtpHost, smtpPort)
+		// Build the outgoing message and DKIM sign it for the sender domain
+		message := f.outgoingMessage(email, project, recipients)
 		
-		// Use real SMTP forwarding
-		return f.realSMTPForwarding(email, smtpHost, smtpPort, smtpUser, smtpPassword)
+		return f.relayWithFailover(email, project, upstreams, recipients, message)
 	}
 	
-	// Fallback to simulation mode if no SMTP configuration
-	log.Printf("📤 [SIMULATION MODE] No SMTP config found for project %s - simulating forwarding", projectID)
-	return f.simulateSMTPForwarding(email, "smtp.gmail.com", 587, "simulation@example.com", "simulation-password")
+	// No upstream configured - fail the delivery
+	log.Printf("❌ No upstream SMTP relay configured for project %s", projectID)
+	return nil, fmt.Errorf("project %s has no upstream SMTP relay configured", projectID)
+}
+
+// Deliver forwards a stored email to all of its recipients
+func (f *EmailForwarder) Deliver(email *storage.Email) error {
+	return f.DeliverTo(email, email.To)
 }
 
-// simulateSMTPForwarding simulates actual SMTP forwarding
-func (f *EmailForwarder) simulateSMTPForwarding(email *storage.Email, host string, port int, _, _ string) error {
-	log.Printf("📤 [SIMULATION] Attempting to forward email %s via %s:%d", email.ID, host, port)
-	log.Printf("   From: %s", email.From)
-	log.Printf("   To: %v", email.To)
-	log.Printf("   Subject: %s", email.Subject)
-	log.Printf("   ⚠️  NOTE: This is simulated - not connecting to real SMTP server")
+// DeliverTo forwards a stored email to the given recipients, records each
+// recipient's outcome and updates the email status from all its recipients.
+// Recipients whose domain is at its throttle limit are left pending and the
+// email is queued again for them.
+func (f *EmailForwarder) DeliverTo(email *storage.Email, recipients []string) error {
+	project, err := f.storage.GetProject(email.ProjectID)
 	
-	// Simulate connection and sending
-	// In real implementation, you would:
-	// 1. Connect to upstream SMTP server with real credentials
-	// 2. Authenticate with project SMTP settings
-	// 3. Send the actual email content
-	// 4. Handle responses and errors
+	// Paused projects keep their mail in the queue until delivery is resumed
+	if err == nil && project.DeliveryPaused {
+		f.hold(email)
+		return nil
+	}
 	
-	// For demo, simulate realistic success/failure scenarios:
+	// Sandbox projects send nothing, so recipient domains are not throttled
+	var throttled []string
+	if err == nil && project.DeliveryMode != storage.DeliveryModeSandbox {
+		var release func()
+		recipients, throttled, release = f.throttle.acquire(recipients)
+		defer release()
+		
+		if len(recipients) == 0 {
+			f.requeueThrottled(email, throttled)
+			return nil
+		}
+	}
 	
-	// Simulate different failure scenarios
-	if strings.Contains(strings.ToLower(email.Subject), "fail") {
-		return fmt.Errorf("[SIMULATED] recipient mailbox full")
+	results, err := f.ForwardEmail(email, email.ProjectID, recipients)
+	if results == nil {
+		// No per-recipient replies - the outcome applies to everyone
+		if err == nil {
+			results = allRecipients(recipients, RecipientDelivered, 250, "accepted", "")
+		} else {
+			results = allRecipients(recipients, RecipientFailed, 0, err.Error(), "")
+		}
 	}
+	f.recordRecipients(email.ID, results)
 	
-	if len(email.To) > 5 {
-		return fmt.Errorf("[SIMULATED] too many recipients")
+	// The outcome is not final until the throttled recipients are sent to
+	if len(throttled) > 0 {
+		f.requeueThrottled(email, throttled)
+		return nil
 	}
 	
-	// Simulate network timeout for emails ending in 0
-	if email.ID[len(email.ID)-1:] == "0" {
-		return fmt.Errorf("[SIMULATED] SMTP connection timeout - would need real SMTP credentials")
+	if err == nil {
+		// Success - mark as delivered unless earlier recipients are still outstanding
+		status, outstanding := f.emailStatus(email.ID, results)
+		if status == RecipientDelivered {
+			f.storage.UpdateEmailStatus(email.ID, "delivered", nil)
+			f.emitStatus(email, "delivered", nil, results)
+			log.Printf("✅ Email %s forwarded successfully via SMTP", email.ID)
+			return nil
+		}
+		errorMsg := fmt.Sprintf("%d recipient(s) not delivered", outstanding)
+		f.storage.UpdateEmailStatus(email.ID, status, &errorMsg)
+		f.emitStatus(email, status, &errorMsg, results)
+		return nil
 	}
 	
-	// Simulate auth failure for emails ending in 1  
-	if email.ID[len(email.ID)-1:] == "1" {
-		return fmt.Errorf("[SIMULATED] SMTP authentication failed - invalid credentials")
+	// Only temporary failures - mark as deferred so it can be retried
+	if status, _ := f.emailStatus(email.ID, results); status == RecipientDeferred {
+		errorMsg := fmt.Sprintf("Delivery deferred: %s", err.Error())
+		f.storage.UpdateEmailStatus(email.ID, "deferred", &errorMsg)
+		f.emitStatus(email, "deferred", &errorMsg, results)
+		log.Printf("⏳ Email %s delivery deferred: %s", email.ID, err.Error())
+		return err
 	}
 	
-	// Otherwise simulate success
-	log.Printf("✅ [SIMULATION] Email %s would be forwarded successfully to upstream SMTP", email.ID)
-	return nil
+	// Failed - mark as failed with error
+	errorMsg := fmt.Sprintf("SMTP forwarding failed: %s", err.Error())
+	f.storage.UpdateEmailStatus(email.ID, "failed", &errorMsg)
+	f.emitStatus(email, "failed", &errorMsg, results)
+	log.Printf("❌ Email %s forwarding failed: %s", email.ID, err.Error())
+	return err
 }
 
-// realSMTPForwarding implements actual SMTP forwarding
-func (f *EmailForwarder) realSMTPForwarding(email *storage.Email, host string, port int, user, pass string) error {
-	// 1. Connect to SMTP server
-	addr := fmt.Sprintf("%s:%d", host, port)
-	auth := smtp.PlainAuth("", user, pass, host)
+// emitStatus reports an email's new status and the recipient results of the
+// attempt to the project's webhooks
+func (f *EmailForwarder) emitStatus(email *storage.Email, status string, errorMsg *string, results []RecipientResult) {
+	eventType := webhooks.EventFailed
+	switch status {
+	case RecipientDelivered:
+		eventType = webhooks.EventDelivered
+	case RecipientDeferred:
+		eventType = webhooks.EventDeferred
+	case RecipientBounced:
+		eventType = webhooks.EventBounced
+	}
 	
-	// 2. Prepare email content
-	to := email.To
-	subject := email.Subject
+	recipients := make([]map[string]interface{}, 0, len(results))
+	for _, result := range results {
+		recipients = append(recipients, map[string]interface{}{
+			"recipient": result.Recipient,
+			"status":    result.Status,
+			"code":      result.Code,
+			"message":   result.Message,
+		})
+	}
+	data := map[string]interface{}{
+		"status":     status,
+		"recipients": recipients,
+	}
+	if errorMsg != nil {
+		data["error"] = *errorMsg
+	}
 	
-	// Build proper RFC 822 email message
+	f.events.Emit(eventType, email.ProjectID, email, data)
+}
+
+// Events returns the webhook dispatcher status changes are reported to
+func (f *EmailForwarder) Events() *webhooks.Dispatcher {
+	return f.events
+}
+
+// recordRecipients stores the result of a delivery attempt for each recipient
+func (f *EmailForwarder) recordRecipients(emailID string, results []RecipientResult) {
+	for _, result := range results {
+		var code *int
+		if result.Code > 0 {
+			code = &result.Code
+		}
+		message := result.Message
+		if err := f.storage.UpdateRecipientStatus(emailID, result.Recipient, result.Status, code, &message); err != nil {
+			log.Printf("⚠️  Failed to record status of %s for email %s: %v", result.Recipient, emailID, err)
+		}
+	}
+}
+
+// emailStatus derives the overall email status from every tracked recipient:
+// delivered when all are delivered, failed when any failed permanently, and
+// deferred otherwise. It also returns the number of undelivered recipients.
+func (f *EmailForwarder) emailStatus(emailID string, results []RecipientResult) (string, int) {
+	statuses := make([]string, 0, len(results))
+	if tracked, err := f.storage.ListEmailRecipients(emailID); err == nil && len(tracked) > 0 {
+		for _, recipient := range tracked {
+			statuses = append(statuses, recipient.Status)
+		}
+	} else {
+		for _, result := range results {
+			statuses = append(statuses, result.Status)
+		}
+	}
+	
+	status := RecipientDelivered
+	outstanding := 0
+	for _, s := range statuses {
+		switch s {
+		case RecipientDelivered:
+			continue
+		case RecipientBounced, RecipientFailed:
+			status = RecipientFailed
+		default:
+			if status == RecipientDelivered {
+				status = RecipientDeferred
+			}
+		}
+		outstanding++
+	}
+	return status, outstanding
+}
+
+// buildMessage builds the RFC 822 message sent upstream for a stored email
+func buildMessage(email *storage.Email) []byte {
 	var message strings.Builder
 	message.WriteString(fmt.Sprintf("From: %s\r\n", email.From))
-	message.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(to, ", ")))
-	message.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
+	message.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(email.To, ", ")))
+	message.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject)))
 	message.WriteString("MIME-Version: 1.0\r\n")
-	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	message.WriteString(mimeHeaders(email.ContentEnc))
 	message.WriteString("\r\n") // Empty line between headers and body
 	
 	// Add email body - parse and clean the original content
@@ -137,21 +330,189 @@ func (f *EmailForwarder) realSMTPForwarding(email *storage.Email, host string, p
 		message.WriteString("This email was forwarded through MailPulse SMTP relay.\r\n")
 	}
 	
-	body := message.String()
+	return []byte(message.String())
+}
+
+// outgoingMessage builds the message sent for an email to recipients, adds
+// the project's tracking and List-Unsubscribe headers and DKIM signs it
+func (f *EmailForwarder) outgoingMessage(email *storage.Email, project *storage.Project, recipients []string) []byte {
+	message := f.trackMessage(buildMessage(email), email, project)
+	message = f.addListUnsubscribe(message, email, project, recipients)
+	return f.signMessage(message, email)
+}
+
+// signMessage adds a DKIM signature using the project's key for the sender domain.
+// Messages are sent unsigned when no key matches or signing fails.
+func (f *EmailForwarder) signMessage(message []byte, email *storage.Email) []byte {
+	key := f.dkimKeyFor(email.ProjectID, email.From)
+	if key == nil {
+		return message
+	}
 	
-	log.Printf("📤 Connecting to SMTP server %s:%d as %s", host, port, user)
-	log.Printf("📧 Email details - From: %s, To: %v, Subject: %s", email.From, to, subject)
+	privateKey, err := crypto.DecryptDKIMKey(key.PrivateKeyEnc)
+	if err != nil {
+		log.Printf("⚠️  Failed to decrypt DKIM key %s for %s: %v", key.Selector, key.Domain, err)
+		return message
+	}
 	
-	// 3. Send email
-	err := smtp.SendMail(addr, auth, email.From, to, []byte(body))
+	signer, err := dkim.NewSigner(key.Domain, key.Selector, privateKey, f.dkimHeaders)
+	if err != nil {
+		log.Printf("⚠️  Invalid DKIM key %s for %s: %v", key.Selector, key.Domain, err)
+		return message
+	}
+	
+	signed, err := signer.Sign(message)
+	if err != nil {
+		log.Printf("⚠️  DKIM signing failed for email %s: %v", email.ID, err)
+		return message
+	}
+	
+	log.Printf("🔏 DKIM signed email %s (d=%s, s=%s)", email.ID, key.Domain, key.Selector)
+	return signed
+}
+
+// dkimKeyFor picks the newest key whose domain is the sender's domain or the
+// closest parent domain of it
+func (f *EmailForwarder) dkimKeyFor(projectID, from string) *storage.DKIMKey {
+	domain := ""
+	from = envelopeAddress(from)
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.ToLower(strings.TrimSpace(from[at+1:]))
+	}
+	if domain == "" {
+		return nil
+	}
+	
+	keys, err := f.storage.ListDKIMKeys(projectID)
+	if err != nil {
+		log.Printf("⚠️  Failed to load DKIM keys for project %s: %v", projectID, err)
+		return nil
+	}
+	
+	var best *storage.DKIMKey
+	for _, key := range keys {
+		if domain != key.Domain && !strings.HasSuffix(domain, "."+key.Domain) {
+			continue
+		}
+		// Keys are newest first, so only replace on a more specific domain
+		if best == nil || len(key.Domain) > len(best.Domain) {
+			best = key
+		}
+	}
+	return best
+}
+
+// envelopeAddress extracts the bare address from a From value like "Name <user@example.com>"
+func envelopeAddress(from string) string {
+	if addr, err := mail.ParseAddress(from); err == nil {
+		return addr.Address
+	}
+	return strings.TrimSpace(from)
+}
+
+// returnPath is the envelope sender for an email: a per-email VERP address on
+// BOUNCE_DOMAIN so bounces can be matched back, or the sender's own address
+func (f *EmailForwarder) returnPath(email *storage.Email) string {
+	if f.bounceDomain != "" {
+		return bounce.ReturnPath(email.ID, f.bounceDomain)
+	}
+	return envelopeAddress(email.From)
+}
+
+// upstreamSession describes how a message reached an upstream relay
+type upstreamSession struct {
+	TLSVersion *string
+	TLSCipher  *string
+}
+
+// realSMTPForwarding implements actual SMTP forwarding, reporting the
+// upstream reply for each recipient. Authenticated sessions are taken from
+// the connection pool and returned to it afterwards.
+func (f *EmailForwarder) realSMTPForwarding(email *storage.Email, project *storage.Project, recipients []string, message []byte, host string, port int, user, pass string) ([]RecipientResult, *upstreamSession, error) {
+	log.Printf("📧 Email details - From: %s, To: %v, Subject: %s", email.From, recipients, email.Subject)
+	
+	// 1. Get a connected, authenticated session
+	key := poolKey(host, port, user, pass, project.UpstreamTLS, project.UpstreamAuth.Mechanism)
+	conn, err := f.pool.acquire(key, fmt.Sprintf("%s:%d", host, port), func() (*pooledConn, error) {
+		return f.dialSession(project, host, port, user, pass)
+	})
 	if err != nil {
 		log.Printf("❌ SMTP forwarding failed for email %s: %v", email.ID, err)
-		log.Printf("🔍 Debug - Host: %s, Port: %d, User: %s", host, port, user)
-		return fmt.Errorf("SMTP forwarding failed: %w", err)
+		return nil, nil, fmt.Errorf("SMTP forwarding failed: %w", err)
+	}
+	
+	// 2. Send email
+	results, err := sendTransaction(conn.client, f.returnPath(email), recipients, message, host)
+	f.pool.release(conn, err == nil)
+	if err != nil {
+		log.Printf("❌ SMTP forwarding failed for email %s: %v", email.ID, err)
+		return nil, nil, fmt.Errorf("SMTP forwarding failed: %w", err)
+	}
+	
+	if err := resultsError(results); err != nil {
+		log.Printf("⚠️  Upstream rejected recipients of email %s: %v", email.ID, err)
+		return results, conn.session, err
+	}
+	
+	log.Printf("✅ Successfully forwarded email %s via real SMTP to %v", email.ID, recipients)
+	return results, conn.session, nil
+}
+
+// dialSession opens a new upstream session using the project's TLS policy
+// and authenticates it with the project's mechanism. Upstreams without a
+// user accept mail unauthenticated (e.g. IP-restricted smarthosts).
+func (f *EmailForwarder) dialSession(project *storage.Project, host string, port int, user, pass string) (*pooledConn, error) {
+	log.Printf("📤 Connecting to SMTP server %s:%d as %s (TLS %s, auth %s)", 
+		host, port, user, project.UpstreamTLS.Mode, project.UpstreamAuth.Mechanism)
+	
+	client, conn, err := dialUpstream(host, port, project.UpstreamTLS)
+	if err != nil {
+		return nil, err
 	}
 	
-	log.Printf("✅ Successfully forwarded email %s via real SMTP to %v", email.ID, to)
-	return nil
+	if user != "" {
+		auth, err := f.upstreamAuth(project, host, user, pass)
+		if err != nil {
+			client.Close()
+			return nil, fmt.Errorf("authentication failed: %w", err)
+		}
+		if err := client.Auth(auth); err != nil {
+			client.Close()
+			if project.UpstreamAuth.Mechanism == storage.UpstreamAuthXOAUTH2 {
+				// The access token may have been revoked - fetch a fresh one next time
+				f.tokens.invalidate(project.ID)
+			}
+			log.Printf("🔍 Debug - Host: %s, Port: %d, User: %s", host, port, user)
+			return nil, fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+	
+	session := &upstreamSession{}
+	session.TLSVersion, session.TLSCipher = negotiatedTLS(client)
+	
+	return &pooledConn{client: client, conn: conn, session: session}, nil
+}
+
+// mimeHeaders returns the original Content-Type and Content-Transfer-Encoding
+// headers so multipart and HTML bodies survive forwarding
+func mimeHeaders(rawContent []byte) string {
+	contentType := "text/plain; charset=UTF-8"
+	transferEncoding := ""
+	
+	if len(rawContent) > 0 {
+		if msg, err := mail.ReadMessage(bytes.NewReader(rawContent)); err == nil {
+			if ct := msg.Header.Get("Content-Type"); ct != "" {
+				contentType = ct
+			}
+			transferEncoding = msg.Header.Get("Content-Transfer-Encoding")
+		}
+	}
+	
+	headers := fmt.Sprintf("Content-Type: %s\r\n", contentType)
+	if transferEncoding != "" {
+		headers += fmt.Sprintf("Content-Transfer-Encoding: %s\r\n", transferEncoding)
+	}
+	return headers
 }
 
 // parseEmailBody extracts just the body content from raw SMTP DATA
@@ -186,7 +547,8 @@ func cleanBodyContent(content string) string {
 		content = strings.TrimSuffix(content, ".")
 	}
 	
-	// Ensure proper line endings
+	// Ensure proper line endings (normalise first so CRLF input isn't doubled)
+	content = strings.ReplaceAll(content, "\r\n", "\n")
 	content = strings.ReplaceAll(content, "\n", "\r\n")
 	
 	return content + "\r\n"
@@ -0,0 +1,322 @@
+package smtp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+// Per-recipient delivery states
+const (
+	RecipientDelivered = storage.RecipientStatusDelivered
+	RecipientDeferred  = storage.RecipientStatusDeferred
+	RecipientBounced   = storage.RecipientStatusBounced
+	RecipientFailed    = storage.RecipientStatusFailed
+)
+
+// RecipientResult is the outcome of delivering to one recipient
+type RecipientResult struct {
+	Recipient string
+	Status    string
+	Code      int
+	Message   string
+	MXHost    string
+}
+
+// DeliveryError reports recipients that were not delivered
+type DeliveryError struct {
+	Results []RecipientResult
+}
+
+// Error summarises the failed recipients
+func (e *DeliveryError) Error() string {
+	var failures []string
+	for _, result := range e.Results {
+		if result.Status == RecipientDelivered {
+			continue
+		}
+		failures = append(failures, fmt.Sprintf("%s %s (%s)", result.Recipient, result.Status, result.Message))
+	}
+	return strings.Join(failures, "; ")
+}
+
+// Deferred reports whether every failure is temporary, so the email may be retried
+func (e *DeliveryError) Deferred() bool {
+	for _, result := range e.Results {
+		if result.Status == RecipientBounced || result.Status == RecipientFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// resultsError returns a DeliveryError when any recipient was not delivered
+func resultsError(results []RecipientResult) error {
+	for _, result := range results {
+		if result.Status != RecipientDelivered {
+			return &DeliveryError{Results: results}
+		}
+	}
+	return nil
+}
+
+// MXResolver looks up DNS MX and address records (satisfied by *net.Resolver)
+type MXResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// MXDeliverer delivers messages straight to the recipients' mail exchangers
+type MXDeliverer struct {
+	Resolver MXResolver
+	Port     int
+	HeloName string
+	Timeout  time.Duration
+}
+
+// NewMXDeliverer creates a direct-to-MX deliverer using the given resolver.
+// The HELO name comes from MX_HELO_NAME, falling back to the host name.
+func NewMXDeliverer(resolver MXResolver) *MXDeliverer {
+	heloName := os.Getenv("MX_HELO_NAME")
+	if heloName == "" {
+		heloName, _ = os.Hostname()
+	}
+	if heloName == "" {
+		heloName = "localhost"
+	}
+	
+	return &MXDeliverer{
+		Resolver: resolver,
+		Port:     25,
+		HeloName: heloName,
+		Timeout:  30 * time.Second,
+	}
+}
+
+// Deliver sends the message to every recipient, grouping recipients by domain
+// so each mail exchanger receives a single transaction
+func (d *MXDeliverer) Deliver(from string, recipients []string, message []byte) []RecipientResult {
+	var domains []string
+	byDomain := map[string][]string{}
+	var results []RecipientResult
+	
+	for _, rcpt := range recipients {
+		at := strings.LastIndex(rcpt, "@")
+		if at < 0 || at == len(rcpt)-1 {
+			results = append(results, RecipientResult{Recipient: rcpt, Status: RecipientBounced, Code: 553, Message: "invalid recipient address"})
+			continue
+		}
+		domain := strings.ToLower(rcpt[at+1:])
+		if _, ok := byDomain[domain]; !ok {
+			domains = append(domains, domain)
+		}
+		byDomain[domain] = append(byDomain[domain], rcpt)
+	}
+	
+	for _, domain := range domains {
+		results = append(results, d.deliverDomain(domain, from, byDomain[domain], message)...)
+	}
+	return results
+}
+
+// deliverDomain tries each mail exchanger for a domain in preference order
+// until one gives a definite answer
+func (d *MXDeliverer) deliverDomain(domain, from string, recipients []string, message []byte) []RecipientResult {
+	hosts, err := d.lookupHosts(domain)
+	if err != nil {
+		status := RecipientDeferred
+		code := 451
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			status, code = RecipientBounced, 550
+		}
+		return allRecipients(recipients, status, code, err.Error(), "")
+	}
+	
+	lastErr := "no mail exchanger reachable"
+	for _, host := range hosts {
+		results, err := d.deliverHost(host, from, recipients, message)
+		if err == nil {
+			return results
+		}
+		log.Printf("⚠️  MX %s for %s failed: %v", host, domain, err)
+		lastErr = fmt.Sprintf("%s: %v", host, err)
+	}
+	
+	return allRecipients(recipients, RecipientDeferred, 451, lastErr, "")
+}
+
+// lookupHosts returns the domain's mail exchangers by preference, falling back
+// to the domain itself when it has no MX records (RFC 5321 5.1)
+func (d *MXDeliverer) lookupHosts(domain string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout)
+	defer cancel()
+	
+	records, err := d.Resolver.LookupMX(ctx, domain)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound && len(records) == 0 {
+			// Implicit MX: the address record of the domain itself
+			if _, hostErr := d.Resolver.LookupHost(ctx, domain); hostErr != nil {
+				return nil, fmt.Errorf("domain %s has no mail server: %w", domain, hostErr)
+			}
+			return []string{domain}, nil
+		}
+		return nil, fmt.Errorf("MX lookup for %s failed: %w", domain, err)
+	}
+	
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Pref < records[j].Pref })
+	
+	var hosts []string
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Host, ".")
+		if host == "" {
+			// Null MX (RFC 7505): the domain accepts no mail
+			return nil, &net.DNSError{Err: "domain does not accept mail (null MX)", Name: domain, IsNotFound: true}
+		}
+		hosts = append(hosts, host)
+	}
+	if len(hosts) == 0 {
+		return []string{domain}, nil
+	}
+	return hosts, nil
+}
+
+// deliverHost runs one SMTP transaction. A non-nil error means the host could
+// not be used and the next mail exchanger should be tried.
+func (d *MXDeliverer) deliverHost(host, from string, recipients []string, message []byte) ([]RecipientResult, error) {
+	client, err := d.connect(host, true)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	
+	results, err := sendTransaction(client, from, recipients, message, host)
+	if err == nil {
+		client.Quit()
+	}
+	return results, err
+}
+
+// sendTransaction runs MAIL, RCPT and DATA on an open session and maps the
+// replies to per-recipient results. A non-nil error means the session broke
+// down without a definite SMTP answer. The session is left open so callers
+// can QUIT or reuse it.
+func sendTransaction(client *smtp.Client, from string, recipients []string, message []byte, host string) ([]RecipientResult, error) {
+	if err := client.Mail(from); err != nil {
+		return replyResults(recipients, err, host)
+	}
+	
+	var results []RecipientResult
+	var accepted []string
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			rejected, connErr := replyResults([]string{rcpt}, err, host)
+			if connErr != nil {
+				return nil, connErr
+			}
+			results = append(results, rejected...)
+			continue
+		}
+		accepted = append(accepted, rcpt)
+	}
+	
+	if len(accepted) == 0 {
+		return results, nil
+	}
+	
+	if err := sendData(client, message); err != nil {
+		rejected, connErr := replyResults(accepted, err, host)
+		if connErr != nil {
+			return nil, connErr
+		}
+		return append(results, rejected...), nil
+	}
+	
+	log.Printf("✅ Delivered to %v via %s", accepted, host)
+	return append(results, allRecipients(accepted, RecipientDelivered, 250, "accepted", host)...), nil
+}
+
+// connect opens an SMTP session, upgrading with STARTTLS when offered. If the
+// TLS handshake fails the session is reopened in plaintext (opportunistic TLS).
+func (d *MXDeliverer) connect(host string, tryTLS bool) (*smtp.Client, error) {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, fmt.Sprint(d.Port)), d.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(d.Timeout))
+	
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := client.Hello(d.HeloName); err != nil {
+		client.Close()
+		return nil, err
+	}
+	
+	if ok, _ := client.Extension("STARTTLS"); ok && tryTLS {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			client.Close()
+			log.Printf("⚠️  STARTTLS with %s failed, retrying without TLS: %v", host, err)
+			return d.connect(host, false)
+		}
+	}
+	
+	return client, nil
+}
+
+// sendData writes the message body in a DATA command
+func sendData(client *smtp.Client, message []byte) error {
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// replyResults maps an SMTP reply to recipient states: 4xx defers and 5xx
+// bounces. Errors that are not SMTP replies are returned as connection errors.
+func replyResults(recipients []string, err error, host string) ([]RecipientResult, error) {
+	var reply *textproto.Error
+	if !errors.As(err, &reply) {
+		return nil, err
+	}
+	
+	status := RecipientDeferred
+	if reply.Code >= 500 {
+		status = RecipientBounced
+	}
+	return allRecipients(recipients, status, reply.Code, reply.Msg, host), nil
+}
+
+// allRecipients gives every recipient the same result
+func allRecipients(recipients []string, status string, code int, message, host string) []RecipientResult {
+	results := make([]RecipientResult, 0, len(recipients))
+	for _, rcpt := range recipients {
+		results = append(results, RecipientResult{
+			Recipient: rcpt,
+			Status:    status,
+			Code:      code,
+			Message:   message,
+			MXHost:    host,
+		})
+	}
+	return results
+}
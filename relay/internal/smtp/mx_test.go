@@ -0,0 +1,288 @@
+package smtp
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubResolver answers MX and address lookups from maps; missing names are NXDOMAIN
+type stubResolver struct {
+	mx    map[string][]*net.MX
+	hosts map[string][]string
+}
+
+func (r *stubResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if records, ok := r.mx[name]; ok {
+		return records, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *stubResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addrs, ok := r.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+// fakeMX is a minimal SMTP server. RCPT replies come from rcptReplies
+// (default 250) and STARTTLS, when advertised, is always refused.
+type fakeMX struct {
+	listener    net.Listener
+	rcptReplies map[string]string
+	startTLS    bool
+
+	mu          sync.Mutex
+	sessions    int
+	tlsAttempts int
+	messages    []string
+}
+
+func newFakeMX(t *testing.T, rcptReplies map[string]string, startTLS bool) *fakeMX {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &fakeMX{listener: listener, rcptReplies: rcptReplies, startTLS: startTLS}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (s *fakeMX) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeMX) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeMX) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	s.mu.Lock()
+	s.sessions++
+	s.mu.Unlock()
+	
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 fake.test ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"):
+			if s.startTLS {
+				text.PrintfLine("250-fake.test")
+				text.PrintfLine("250 STARTTLS")
+			} else {
+				text.PrintfLine("250 fake.test")
+			}
+		case command == "STARTTLS":
+			s.mu.Lock()
+			s.tlsAttempts++
+			s.mu.Unlock()
+			text.PrintfLine("454 4.7.0 TLS not available")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			rcpt := strings.Trim(line[len("RCPT TO:"):], "<> ")
+			if reply, ok := s.rcptReplies[rcpt]; ok {
+				text.PrintfLine("%s", reply)
+			} else {
+				text.PrintfLine("250 2.1.5 OK")
+			}
+		case command == "DATA":
+			text.PrintfLine("354 go ahead")
+			body, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, string(body))
+			s.mu.Unlock()
+			text.PrintfLine("250 2.0.0 queued")
+		case command == "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("250 OK")
+		}
+	}
+}
+
+// received returns the messages the server accepted
+func (s *fakeMX) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+func newTestDeliverer(resolver MXResolver, port int) *MXDeliverer {
+	return &MXDeliverer{Resolver: resolver, Port: port, HeloName: "relay.test", Timeout: 5 * time.Second}
+}
+
+func resultsByRecipient(results []RecipientResult) map[string]RecipientResult {
+	byRecipient := make(map[string]RecipientResult, len(results))
+	for _, result := range results {
+		byRecipient[result.Recipient] = result
+	}
+	return byRecipient
+}
+
+func TestMXDelivererPerRecipientResults(t *testing.T) {
+	server := newFakeMX(t, map[string]string{
+		"busy@example.test":    "450 4.2.1 mailbox busy",
+		"unknown@example.test": "550 5.1.1 no such user",
+	}, false)
+	resolver := &stubResolver{mx: map[string][]*net.MX{
+		"example.test": {{Host: "127.0.0.1.", Pref: 10}},
+	}}
+	
+	results := newTestDeliverer(resolver, server.port()).Deliver("sender@relay.test",
+		[]string{"ok@example.test", "busy@example.test", "unknown@example.test"}, []byte("Subject: hi\r\n\r\nhello\r\n"))
+	
+	byRecipient := resultsByRecipient(results)
+	tests := []struct {
+		rcpt   string
+		status string
+		code   int
+	}{
+		{"ok@example.test", RecipientDelivered, 250},
+		{"busy@example.test", RecipientDeferred, 450},
+		{"unknown@example.test", RecipientBounced, 550},
+	}
+	for _, tt := range tests {
+		result, ok := byRecipient[tt.rcpt]
+		if !ok {
+			t.Errorf("no result for %s", tt.rcpt)
+			continue
+		}
+		if result.Status != tt.status || result.Code != tt.code || result.MXHost != "127.0.0.1" {
+			t.Errorf("%s: got %+v, want %s %d via 127.0.0.1", tt.rcpt, result, tt.status, tt.code)
+		}
+	}
+	if messages := server.received(); len(messages) != 1 || !strings.Contains(messages[0], "hello") {
+		t.Errorf("messages = %q, want one delivered message", messages)
+	}
+}
+
+func TestMXDelivererTriesNextExchanger(t *testing.T) {
+	server := newFakeMX(t, nil, false)
+	// 127.0.0.2 is loopback but nothing listens there, so the connection is refused
+	resolver := &stubResolver{mx: map[string][]*net.MX{
+		"example.test": {{Host: "127.0.0.1.", Pref: 20}, {Host: "127.0.0.2.", Pref: 10}},
+	}}
+	
+	results := newTestDeliverer(resolver, server.port()).Deliver("sender@relay.test", []string{"ok@example.test"}, []byte("hello\r\n"))
+	if len(results) != 1 || results[0].Status != RecipientDelivered || results[0].MXHost != "127.0.0.1" {
+		t.Errorf("results = %+v, want delivered via the second exchanger", results)
+	}
+}
+
+func TestMXDelivererImplicitMX(t *testing.T) {
+	server := newFakeMX(t, nil, false)
+	// No MX records: the domain's own address record is the mail server
+	resolver := &stubResolver{hosts: map[string][]string{"127.0.0.1": {"127.0.0.1"}}}
+	deliverer := newTestDeliverer(resolver, server.port())
+	
+	hosts, err := deliverer.lookupHosts("127.0.0.1")
+	if err != nil || len(hosts) != 1 || hosts[0] != "127.0.0.1" {
+		t.Fatalf("lookupHosts = %v, %v, want the domain itself", hosts, err)
+	}
+	
+	results := deliverer.Deliver("sender@relay.test", []string{"user@127.0.0.1"}, []byte("hello\r\n"))
+	if len(results) != 1 || results[0].Status != RecipientDelivered {
+		t.Errorf("results = %+v, want delivered", results)
+	}
+}
+
+func TestMXDelivererBouncesDomainWithoutMailServer(t *testing.T) {
+	deliverer := newTestDeliverer(&stubResolver{}, 25)
+	
+	results := deliverer.Deliver("sender@relay.test", []string{"user@missing.test"}, []byte("hello\r\n"))
+	if len(results) != 1 || results[0].Status != RecipientBounced || results[0].Code != 550 {
+		t.Errorf("results = %+v, want a 550 bounce", results)
+	}
+}
+
+func TestMXDelivererRejectsNullMX(t *testing.T) {
+	server := newFakeMX(t, nil, false)
+	resolver := &stubResolver{
+		mx:    map[string][]*net.MX{"nomail.test": {{Host: ".", Pref: 0}}},
+		hosts: map[string][]string{"nomail.test": {"127.0.0.1"}},
+	}
+	deliverer := newTestDeliverer(resolver, server.port())
+	
+	if _, err := deliverer.lookupHosts("nomail.test"); err == nil || !strings.Contains(err.Error(), "null MX") {
+		t.Errorf("lookupHosts error = %v, want null MX", err)
+	}
+	
+	results := deliverer.Deliver("sender@relay.test", []string{"user@nomail.test"}, []byte("hello\r\n"))
+	if len(results) != 1 || results[0].Status != RecipientBounced || results[0].Code != 550 {
+		t.Errorf("results = %+v, want a 550 bounce", results)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.sessions != 0 {
+		t.Errorf("made %d connections to a null MX domain", server.sessions)
+	}
+}
+
+func TestMXDelivererFallsBackToPlaintextWhenSTARTTLSFails(t *testing.T) {
+	server := newFakeMX(t, nil, true)
+	resolver := &stubResolver{mx: map[string][]*net.MX{
+		"example.test": {{Host: "127.0.0.1.", Pref: 10}},
+	}}
+	
+	results := newTestDeliverer(resolver, server.port()).Deliver("sender@relay.test", []string{"ok@example.test"}, []byte("hello\r\n"))
+	if len(results) != 1 || results[0].Status != RecipientDelivered {
+		t.Fatalf("results = %+v, want delivered", results)
+	}
+	
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.tlsAttempts != 1 || server.sessions != 2 || len(server.messages) != 1 {
+		t.Errorf("tls attempts %d, sessions %d, messages %d; want 1 refused STARTTLS then one plaintext delivery",
+			server.tlsAttempts, server.sessions, len(server.messages))
+	}
+}
+
+func TestReplyResults(t *testing.T) {
+	tests := []struct {
+		err    error
+		status string
+	}{
+		{&textproto.Error{Code: 421, Msg: "try later"}, RecipientDeferred},
+		{&textproto.Error{Code: 452, Msg: "too many recipients"}, RecipientDeferred},
+		{&textproto.Error{Code: 550, Msg: "rejected"}, RecipientBounced},
+		{&textproto.Error{Code: 554, Msg: "spam"}, RecipientBounced},
+	}
+	for _, tt := range tests {
+		results, err := replyResults([]string{"a@example.test", "b@example.test"}, tt.err, "mx.example.test")
+		if err != nil {
+			t.Fatalf("replyResults(%v) error: %v", tt.err, err)
+		}
+		for _, result := range results {
+			if result.Status != tt.status || result.MXHost != "mx.example.test" {
+				t.Errorf("replyResults(%v) = %+v, want %s", tt.err, result, tt.status)
+			}
+		}
+	}
+	
+	if _, err := replyResults([]string{"a@example.test"}, net.ErrClosed, "mx.example.test"); err == nil {
+		t.Error("a connection error should be returned, not mapped to recipients")
+	}
+}
@@ -0,0 +1,244 @@
+package smtp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+// upstreamIOTimeout bounds a single message exchange on a pooled connection
+const upstreamIOTimeout = 5 * time.Minute
+
+// pooledConn is an authenticated upstream SMTP session
+type pooledConn struct {
+	key      string
+	host     string
+	client   *smtp.Client
+	conn     net.Conn
+	session  *upstreamSession
+	messages int
+	lastUsed time.Time
+}
+
+// close ends the session, politely when it is still usable
+func (c *pooledConn) close(polite bool) {
+	if polite {
+		c.conn.SetDeadline(time.Now().Add(5 * time.Second))
+		c.client.Quit()
+	}
+	c.client.Close()
+}
+
+// upstreamPool keeps authenticated sessions to upstream relays so several
+// messages can share one connection (reset with RSET between messages).
+// Sessions are keyed by host, credentials, auth mechanism and TLS policy; the number of
+// open connections is capped per upstream host.
+type upstreamPool struct {
+	mu          sync.Mutex
+	cond        *sync.Cond
+	idle        map[string][]*pooledConn
+	open        map[string]int
+	maxPerHost  int
+	maxMessages int
+	idleTimeout time.Duration
+}
+
+// newUpstreamPool creates a pool and starts evicting idle sessions
+func newUpstreamPool(maxPerHost, maxMessages int, idleTimeout time.Duration) *upstreamPool {
+	p := &upstreamPool{
+		idle:        make(map[string][]*pooledConn),
+		open:        make(map[string]int),
+		maxPerHost:  maxPerHost,
+		maxMessages: maxMessages,
+		idleTimeout: idleTimeout,
+	}
+	p.cond = sync.NewCond(&p.mu)
+	
+	go p.evictIdle()
+	return p
+}
+
+// newUpstreamPoolFromEnv reads UPSTREAM_POOL_MAX_CONNS (default 5),
+// UPSTREAM_POOL_MAX_MESSAGES (default 100) and UPSTREAM_POOL_IDLE_TIMEOUT (default 30s)
+func newUpstreamPoolFromEnv() *upstreamPool {
+	maxPerHost := 5
+	if value := os.Getenv("UPSTREAM_POOL_MAX_CONNS"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			maxPerHost = parsed
+		}
+	}
+	
+	maxMessages := 100
+	if value := os.Getenv("UPSTREAM_POOL_MAX_MESSAGES"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			maxMessages = parsed
+		}
+	}
+	
+	idleTimeout := 30 * time.Second
+	if value := os.Getenv("UPSTREAM_POOL_IDLE_TIMEOUT"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			idleTimeout = parsed
+		}
+	}
+	
+	return newUpstreamPool(maxPerHost, maxMessages, idleTimeout)
+}
+
+// poolKey identifies sessions that can be shared: same upstream, credentials,
+// auth mechanism and TLS policy
+func poolKey(host string, port int, user, pass string, policy storage.UpstreamTLSPolicy, mechanism string) string {
+	caBundle, serverName := "", ""
+	if policy.CABundle != nil {
+		caBundle = *policy.CABundle
+	}
+	if policy.ServerName != nil {
+		serverName = *policy.ServerName
+	}
+	
+	digest := sha256.Sum256([]byte(strings.Join([]string{
+		user, pass, mechanism, policy.Mode, policy.MinVersion, caBundle, serverName,
+	}, "\x00")))
+	return fmt.Sprintf("%s:%d/%s", host, port, hex.EncodeToString(digest[:8]))
+}
+
+// acquire returns a ready session for key, reusing an idle one (after RSET)
+// or dialing a new one when the host is below its connection cap. It waits
+// for a free connection otherwise.
+func (p *upstreamPool) acquire(key, host string, dial func() (*pooledConn, error)) (*pooledConn, error) {
+	p.mu.Lock()
+	for {
+		if conn := p.popIdle(key); conn != nil {
+			p.mu.Unlock()
+			conn.conn.SetDeadline(time.Now().Add(upstreamIOTimeout))
+			if err := conn.client.Reset(); err == nil {
+				return conn, nil
+			}
+			// Stale session - drop it and try again
+			conn.close(false)
+			p.mu.Lock()
+			p.open[host]--
+			p.cond.Broadcast()
+			continue
+		}
+	
+		if p.open[host] < p.maxPerHost {
+			p.open[host]++
+			p.mu.Unlock()
+	
+			conn, err := dial()
+			if err != nil {
+				p.mu.Lock()
+				p.open[host]--
+				p.cond.Broadcast()
+				p.mu.Unlock()
+				return nil, err
+			}
+			conn.key, conn.host = key, host
+			conn.conn.SetDeadline(time.Now().Add(upstreamIOTimeout))
+			return conn, nil
+		}
+	
+		// At the cap: free a slot held by an idle session with other credentials
+		if conn := p.popIdleForHost(host); conn != nil {
+			p.open[host]--
+			p.mu.Unlock()
+			conn.close(true)
+			p.mu.Lock()
+			continue
+		}
+	
+		p.cond.Wait()
+	}
+}
+
+// release returns a session to the pool, or closes it when it is broken or
+// has carried its maximum number of messages
+func (p *upstreamPool) release(conn *pooledConn, healthy bool) {
+	conn.messages++
+	if !healthy || conn.messages >= p.maxMessages {
+		conn.close(healthy)
+		p.mu.Lock()
+		p.open[conn.host]--
+		p.cond.Broadcast()
+		p.mu.Unlock()
+		return
+	}
+	
+	conn.lastUsed = time.Now()
+	p.mu.Lock()
+	p.idle[conn.key] = append(p.idle[conn.key], conn)
+	p.cond.Broadcast()
+	p.mu.Unlock()
+}
+
+// popIdle takes the most recently used idle session for key. Caller holds p.mu.
+func (p *upstreamPool) popIdle(key string) *pooledConn {
+	conns := p.idle[key]
+	if len(conns) == 0 {
+		return nil
+	}
+	conn := conns[len(conns)-1]
+	p.idle[key] = conns[:len(conns)-1]
+	return conn
+}
+
+// popIdleForHost takes any idle session to host. Caller holds p.mu.
+func (p *upstreamPool) popIdleForHost(host string) *pooledConn {
+	for key, conns := range p.idle {
+		if len(conns) > 0 && conns[0].host == host {
+			return p.popIdle(key)
+		}
+	}
+	return nil
+}
+
+// evictIdle periodically closes sessions that have been idle too long
+func (p *upstreamPool) evictIdle() {
+	ticker := time.NewTicker(p.idleTimeout / 2)
+	defer ticker.Stop()
+	
+	for range ticker.C {
+		cutoff := time.Now().Add(-p.idleTimeout)
+		var expired []*pooledConn
+	
+		p.mu.Lock()
+		for key, conns := range p.idle {
+			kept := conns[:0]
+			for _, conn := range conns {
+				if conn.lastUsed.Before(cutoff) {
+					expired = append(expired, conn)
+					p.open[conn.host]--
+					continue
+				}
+				kept = append(kept, conn)
+			}
+			if len(kept) == 0 {
+				delete(p.idle, key)
+			} else {
+				p.idle[key] = kept
+			}
+		}
+		if len(expired) > 0 {
+			p.cond.Broadcast()
+		}
+		p.mu.Unlock()
+	
+		for _, conn := range expired {
+			conn.close(true)
+		}
+		if len(expired) > 0 {
+			log.Printf("🧹 Closed %d idle upstream connection(s)", len(expired))
+		}
+	}
+}
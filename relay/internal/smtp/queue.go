@@ -0,0 +1,124 @@
+package smtp
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+// queueBatchSize limits how many queued emails are claimed at a time
+const queueBatchSize = 100
+
+// StartQueue releases due scheduled emails and forwards queued ones every
+// interval (and whenever the queue is woken) until the process exits. The
+// queue lives in the database, so pending emails survive restarts.
+func (f *EmailForwarder) StartQueue(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			f.sendDueScheduled()
+			f.sendQueued()
+			select {
+			case <-ticker.C:
+			case <-f.wake:
+			}
+		}
+	}()
+}
+
+// WakeQueue makes the queue check for emails to send now
+func (f *EmailForwarder) WakeQueue() {
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// sendQueued forwards the queued emails of every project whose delivery is
+// not paused to their pending recipients. Each email is claimed at most once
+// per pass, so emails throttled back into the queue wait for the next one.
+func (f *EmailForwarder) sendQueued() {
+	claimedThisPass := make(map[string]bool)
+	for {
+		emails, err := f.storage.ListQueuedEmails(queueBatchSize)
+		if err != nil {
+			log.Printf("❌ Failed to list queued emails: %v", err)
+			return
+		}
+		
+		claimed := 0
+		for _, email := range emails {
+			if claimedThisPass[email.ID] {
+				continue
+			}
+			ok, err := f.storage.ClaimQueuedEmail(email.ID)
+			if err != nil {
+				log.Printf("❌ Failed to claim queued email %s: %v", email.ID, err)
+				continue
+			}
+			if !ok {
+				continue // Cancelled meanwhile or claimed by another instance
+			}
+			claimed++
+			claimedThisPass[email.ID] = true
+			
+			email.Status = "processed"
+			go f.deliverQueued(email)
+		}
+		
+		if claimed == 0 || len(emails) < queueBatchSize {
+			return
+		}
+	}
+}
+
+// deliverQueued forwards a claimed email to the recipients still pending.
+// Retries and resends reset undelivered recipients to pending.
+func (f *EmailForwarder) deliverQueued(email *storage.Email) {
+	recipients := email.To
+	if tracked, err := f.storage.ListEmailRecipients(email.ID); err == nil && len(tracked) > 0 {
+		recipients = nil
+		for _, recipient := range tracked {
+			if recipient.Status == storage.RecipientStatusPending {
+				recipients = append(recipients, recipient.Recipient)
+			}
+		}
+	}
+	if len(recipients) == 0 {
+		status, outstanding := f.emailStatus(email.ID, nil)
+		var errorMsg *string
+		if outstanding > 0 {
+			message := fmt.Sprintf("%d recipient(s) not delivered", outstanding)
+			errorMsg = &message
+		}
+		f.storage.UpdateEmailStatus(email.ID, status, errorMsg)
+		return
+	}
+	
+	log.Printf("📤 Sending queued email %s to %d recipient(s)", email.ID, len(recipients))
+	f.DeliverTo(email, recipients)
+}
+
+// hold puts an email back in the queue because its project's delivery is paused
+func (f *EmailForwarder) hold(email *storage.Email) {
+	errorMsg := "Delivery paused for this project"
+	if err := f.storage.UpdateEmailStatus(email.ID, storage.EmailStatusQueued, &errorMsg); err != nil {
+		log.Printf("❌ Failed to hold email %s: %v", email.ID, err)
+		return
+	}
+	log.Printf("⏸️  Delivery paused for project %s, email %s held in the queue", email.ProjectID, email.ID)
+}
+
+// requeueThrottled puts an email back in the queue for the recipients whose
+// domain was at its throttle limit
+func (f *EmailForwarder) requeueThrottled(email *storage.Email, throttled []string) {
+	errorMsg := fmt.Sprintf("Throttled: %d recipient(s) waiting for domain limits", len(throttled))
+	if err := f.storage.UpdateEmailStatus(email.ID, storage.EmailStatusQueued, &errorMsg); err != nil {
+		log.Printf("❌ Failed to requeue throttled email %s: %v", email.ID, err)
+		return
+	}
+	log.Printf("⏳ Email %s: %d recipient(s) throttled by domain limits, left in the queue", email.ID, len(throttled))
+}
@@ -0,0 +1,86 @@
+package smtp
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+// SandboxDomain is the domain of the magic sandbox recipient addresses
+const SandboxDomain = "sandbox"
+
+// Magic sandbox mailboxes (a +tag is allowed, e.g. bounce+signup@sandbox)
+const (
+	SandboxBounce  = "bounce"  // bounced with 550
+	SandboxDefer   = "defer"   // deferred with 451
+	SandboxTimeout = "timeout" // deferred as if the connection timed out
+)
+
+// sandboxResult decides the outcome for one recipient of a sandbox project.
+// Only the magic addresses fail; every other recipient is delivered.
+func sandboxResult(recipient string) RecipientResult {
+	mailbox, domain := recipient, ""
+	if at := strings.LastIndex(recipient, "@"); at >= 0 {
+		mailbox, domain = recipient[:at], recipient[at+1:]
+	}
+	if plus := strings.Index(mailbox, "+"); plus >= 0 {
+		mailbox = mailbox[:plus]
+	}
+	
+	if strings.EqualFold(domain, SandboxDomain) {
+		switch strings.ToLower(mailbox) {
+		case SandboxBounce:
+			return RecipientResult{Recipient: recipient, Status: RecipientBounced, Code: 550,
+				Message: "5.1.1 [SANDBOX] mailbox does not exist", MXHost: SandboxDomain}
+		case SandboxDefer:
+			return RecipientResult{Recipient: recipient, Status: RecipientDeferred, Code: 451,
+				Message: "4.3.0 [SANDBOX] temporary failure, try again later", MXHost: SandboxDomain}
+		case SandboxTimeout:
+			return RecipientResult{Recipient: recipient, Status: RecipientDeferred,
+				Message: "[SANDBOX] connection timed out", MXHost: SandboxDomain}
+		}
+	}
+	
+	return RecipientResult{Recipient: recipient, Status: RecipientDelivered, Code: 250,
+		Message: "2.0.0 [SANDBOX] message captured", MXHost: SandboxDomain}
+}
+
+// deliverSandbox captures the message for the accepted recipients instead of
+// sending it and reports the sandbox outcome for each recipient
+func (f *EmailForwarder) deliverSandbox(email *storage.Email, recipients []string, message []byte) ([]RecipientResult, error) {
+	results := make([]RecipientResult, 0, len(recipients))
+	var accepted []string
+	for _, rcpt := range recipients {
+		result := sandboxResult(rcpt)
+		if result.Status == RecipientDelivered {
+			accepted = append(accepted, rcpt)
+		}
+		results = append(results, result)
+	}
+	
+	if len(accepted) > 0 {
+		captured := &storage.CapturedMessage{
+			ID:         fmt.Sprintf("%s-%d", email.ID, time.Now().UnixNano()),
+			ProjectID:  email.ProjectID,
+			EmailID:    email.ID,
+			From:       email.From,
+			Recipients: accepted,
+			Subject:    email.Subject,
+			Raw:        message,
+			CreatedAt:  time.Now(),
+		}
+		if err := f.storage.StoreCapturedMessage(captured); err != nil {
+			// Nothing was captured - fail the attempt so it can be resent
+			return nil, fmt.Errorf("failed to capture sandbox message: %w", err)
+		}
+		if err := f.storage.SetEmailUpstream(email.ID, SandboxDomain, nil, nil); err != nil {
+			log.Printf("⚠️  Failed to record sandbox delivery for email %s: %v", email.ID, err)
+		}
+		log.Printf("📥 [SANDBOX] Captured email %s for %v", email.ID, accepted)
+	}
+	
+	return results, resultsError(results)
+}
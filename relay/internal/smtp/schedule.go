@@ -0,0 +1,84 @@
+package smtp
+
+import (
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// SendAtHeader schedules a message submitted over SMTP for later delivery
+const SendAtHeader = "X-MailPulse-Send-At"
+
+// MaxScheduleAhead is how far in the future an email may be scheduled
+const MaxScheduleAhead = 30 * 24 * time.Hour
+
+// scheduleBatchSize limits how many due emails are released per poll
+const scheduleBatchSize = 100
+
+// ParseSendAt parses a send-at time given as RFC 3339 or as an RFC 5322 date
+func ParseSendAt(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := mail.ParseDate(value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid send-at time %q", value)
+}
+
+// ScheduleTime validates a requested send-at time. It returns nil when the
+// time has already passed, meaning the email should be sent right away.
+func ScheduleTime(sendAt time.Time) (*time.Time, error) {
+	now := time.Now()
+	if !sendAt.After(now) {
+		return nil, nil
+	}
+	if sendAt.Sub(now) > MaxScheduleAhead {
+		return nil, fmt.Errorf("send-at time is more than %d days ahead", int(MaxScheduleAhead.Hours()/24))
+	}
+	
+	sendAt = sendAt.UTC()
+	return &sendAt, nil
+}
+
+// sendDueScheduled releases and delivers the scheduled emails that are due.
+// Projects over quota keep their emails scheduled until the next poll.
+func (f *EmailForwarder) sendDueScheduled() {
+	emails, err := f.storage.ListDueScheduledEmails(scheduleBatchSize)
+	if err != nil {
+		log.Printf("❌ Failed to list due scheduled emails: %v", err)
+		return
+	}
+	
+	// Keyed by project and sender, since warm-up plans can limit a single sender domain
+	overQuota := make(map[string]bool)
+	for _, email := range emails {
+		key := email.ProjectID + " " + email.From
+		if overQuota[key] {
+			continue
+		}
+		if err := f.storage.CheckQuotaLimits(email.ProjectID, email.From); err != nil {
+			log.Printf("⏳ Holding scheduled emails of project %s from %s: %v", email.ProjectID, email.From, err)
+			overQuota[key] = true
+			continue
+		}
+		
+		released, err := f.storage.ReleaseScheduledEmail(email.ID)
+		if err != nil {
+			log.Printf("❌ Failed to release scheduled email %s: %v", email.ID, err)
+			continue
+		}
+		if !released {
+			continue // Cancelled meanwhile or released by another instance
+		}
+		
+		email.Status = "processed"
+		email.SentAt = time.Now()
+		log.Printf("📤 Sending scheduled email %s (due %s)", email.ID, email.ScheduledAt.Format(time.RFC3339))
+		
+		go f.Deliver(email)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/Renespeare/mailpulse/relay/internal/auth"
 	"github.com/Renespeare/mailpulse/relay/internal/security"
 	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/Renespeare/mailpulse/relay/internal/webhooks"
 )
 
 // Server represents an SMTP server with authentication
@@ -138,6 +139,7 @@ type SMTPSession struct {
 	project       *auth.Project
 	mailFrom      string
 	rcptTo        []string
+	dropped       []string // suppressed recipients accepted but left out
 	data          []byte
 }
 
@@ -240,7 +242,7 @@ func (s *SMTPSession) handleAuth(parts []string, fullCommand string) error {
 // handleAuthPlain handles PLAIN authentication
 func (s *SMTPSession) handleAuthPlain(parts []string, fullCommand string) error {
 	// Check rate limit for auth attempts
-	clientIP := strings.Split(s.remoteAddr, ":")[0]
+	clientIP := remoteIP(s.remoteAddr)
 	if err := s.rateLimiter.CheckAuthAttempt(clientIP); err != nil {
 		log.Printf("Rate limit exceeded for auth attempts from %s: %v", clientIP, err)
 		return s.sendResponse("421 Too many authentication attempts")
@@ -289,7 +291,6 @@ func (s *SMTPSession) handleAuthPlain(parts []string, fullCommand string) error
 	
 	// Check IP allowlist if required
 	if project.RequireIPAllow {
-		clientIP := strings.Split(s.remoteAddr, ":")[0]
 		if !s.authManager.IsIPAllowed(project.ID, clientIP) {
 			log.Printf("IP %s not allowed for project %s", clientIP, project.ID)
 			
@@ -376,13 +377,41 @@ func (s *SMTPSession) handleMail(command string) error {
 		return s.sendResponse("501 Syntax error")
 	}
 	
-	from := strings.Trim(parts[1], "<> ")
+	from := parsePath(parts[1])
+	
+	// Enforce the project's allowed sender domains/addresses
+	if s.project != nil && !s.authManager.IsSenderAllowed(s.project.ID, from) {
+		log.Printf("❌ Sender %s not allowed for project %s", from, s.project.ID)
+		
+		s.recordAuditLog("smtp_sender_rejected", &s.project.ID, map[string]interface{}{
+			"from":   from,
+			"reason": "envelope_sender_not_allowed",
+		})
+		
+		return s.sendResponse("550 5.7.1 Sender address not allowed for this project")
+	}
+	
 	s.mailFrom = from
 	s.state = StateMail
 	
 	return s.sendResponse("250 OK")
 }
 
+// parsePath extracts the address from a MAIL FROM/RCPT TO argument,
+// ignoring any ESMTP parameters such as SIZE=
+func parsePath(arg string) string {
+	arg = strings.TrimSpace(arg)
+	if start := strings.Index(arg, "<"); start != -1 {
+		if end := strings.Index(arg[start:], ">"); end != -1 {
+			return strings.TrimSpace(arg[start+1 : start+end])
+		}
+	}
+	if fields := strings.Fields(arg); len(fields) > 0 {
+		return strings.Trim(fields[0], "<>")
+	}
+	return ""
+}
+
 // handleRcpt handles RCPT TO command
 func (s *SMTPSession) handleRcpt(command string) error {
 	if s.state != StateMail && s.state != StateRcpt {
@@ -395,13 +424,57 @@ func (s *SMTPSession) handleRcpt(command string) error {
 		return s.sendResponse("501 Syntax error")
 	}
 	
-	to := strings.Trim(parts[1], "<> ")
+	to := parsePath(parts[1])
+	
+	// Refuse (or silently drop) addresses on the project's suppression list
+	if s.project != nil {
+		suppression, mode, err := s.suppressionFor(to)
+		if err != nil {
+			log.Printf("Failed to check suppression list for project %s: %v", s.project.ID, err)
+			return s.sendResponse("451 4.3.0 Temporary server error")
+		}
+		if suppression != nil {
+			s.recordAuditLog("smtp_recipient_suppressed", &s.project.ID, map[string]interface{}{
+				"from":   s.mailFrom,
+				"to":     to,
+				"source": suppression.Source,
+				"mode":   mode,
+			})
+			
+			if mode != storage.SuppressionModeDrop {
+				log.Printf("❌ Recipient %s is suppressed for project %s (%s)", to, s.project.ID, suppression.Source)
+				return s.sendResponse("550 5.7.1 Recipient address is on the suppression list")
+			}
+			
+			log.Printf("⚠️  Dropping suppressed recipient %s for project %s (%s)", to, s.project.ID, suppression.Source)
+			s.dropped = append(s.dropped, to)
+			s.state = StateRcpt
+			return s.sendResponse("250 OK")
+		}
+	}
+	
 	s.rcptTo = append(s.rcptTo, to)
 	s.state = StateRcpt
 	
 	return s.sendResponse("250 OK")
 }
 
+// suppressionFor returns the suppression list entry for a recipient together
+// with the project's suppression mode, or nil when the address is not suppressed
+func (s *SMTPSession) suppressionFor(recipient string) (*storage.Suppression, string, error) {
+	suppressions, err := s.storage.FindSuppressions(s.project.ID, []string{recipient})
+	if err != nil || len(suppressions) == 0 {
+		return nil, "", err
+	}
+	
+	project, err := s.storage.GetProject(s.project.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	
+	return suppressions[0], project.SuppressionMode, nil
+}
+
 // handleData handles DATA command
 func (s *SMTPSession) handleData() error {
 	if s.state != StateRcpt {
@@ -444,17 +517,101 @@ func (s *SMTPSession) handleData() error {
 	
 	s.data = data
 	
+	// The header From: must satisfy the same sender rules as MAIL FROM
+	if rejected, ok := s.checkHeaderFrom(); !ok {
+		log.Printf("❌ From header %q not allowed for project %s", rejected, s.project.ID)
+		
+		s.recordAuditLog("smtp_sender_rejected", &s.project.ID, map[string]interface{}{
+			"from":        s.mailFrom,
+			"header_from": rejected,
+			"reason":      "header_from_not_allowed",
+		})
+		
+		return s.sendResponse("550 5.7.1 From header address not allowed for this project")
+	}
+	
+	// An X-MailPulse-Send-At header schedules the message for later
+	sendAt, err := s.sendAt()
+	if err != nil {
+		log.Printf("❌ Rejecting message for project %s: %v", s.project.ID, err)
+		return s.sendResponse("550 5.6.0 Invalid " + SendAtHeader + " header: " + err.Error())
+	}
+	
+	// Every recipient was suppressed and dropped: accept without sending
+	if len(s.rcptTo) == 0 {
+		log.Printf("⚠️  All recipients suppressed for project %s, message from %s discarded", s.project.ID, s.mailFrom)
+		return s.sendResponse("250 OK: Message accepted")
+	}
+	
 	// Process the email
-	if err := s.processEmail(); err != nil {
+	if err := s.processEmail(sendAt); err != nil {
 		log.Printf("Failed to process email: %v", err)
 		return s.sendResponse("550 Transaction failed")
 	}
 	
+	if sendAt != nil {
+		return s.sendResponse("250 OK: Message scheduled for " + sendAt.Format(time.RFC3339))
+	}
 	return s.sendResponse("250 OK: Message accepted")
 }
 
-// processEmail processes the received email data
-func (s *SMTPSession) processEmail() error {
+// sendAt returns the time the message is scheduled for by its send-at
+// header, or nil when it should be sent right away
+func (s *SMTPSession) sendAt() (*time.Time, error) {
+	msg, err := mail.ReadMessage(strings.NewReader(string(s.data)))
+	if err != nil {
+		return nil, nil
+	}
+	
+	value := msg.Header.Get(SendAtHeader)
+	if value == "" {
+		return nil, nil
+	}
+	
+	sendAt, err := ParseSendAt(value)
+	if err != nil {
+		return nil, err
+	}
+	return ScheduleTime(sendAt)
+}
+
+// checkHeaderFrom verifies every address in the From: header against the
+// project's sender rules. Returns the offending value when rejected.
+func (s *SMTPSession) checkHeaderFrom() (string, bool) {
+	msg, err := mail.ReadMessage(strings.NewReader(string(s.data)))
+	if err != nil {
+		// A header block that can't be parsed can't be checked either
+		if s.authManager.IsSenderAllowed(s.project.ID, "") {
+			return "", true // Unrestricted project
+		}
+		return "(unparseable header)", false
+	}
+	
+	fromHeader := msg.Header.Get("From")
+	if fromHeader == "" {
+		return "", true
+	}
+	
+	addresses, err := msg.Header.AddressList("From")
+	if err != nil {
+		if s.authManager.IsSenderAllowed(s.project.ID, "") {
+			return "", true // Unrestricted project
+		}
+		return fromHeader, false
+	}
+	
+	for _, address := range addresses {
+		if !s.authManager.IsSenderAllowed(s.project.ID, address.Address) {
+			return address.Address, false
+		}
+	}
+	
+	return "", true
+}
+
+// processEmail processes the received email data. A non-nil sendAt stores
+// the email as scheduled; quotas are then checked when it is released.
+func (s *SMTPSession) processEmail(sendAt *time.Time) error {
 	// Re-check project status before processing email (in case it was deactivated during session)
 	currentProject, err := s.storage.GetProject(s.project.ID)
 	if err != nil {
@@ -467,18 +624,20 @@ func (s *SMTPSession) processEmail() error {
 		return fmt.Errorf("project is not active")
 	}
 	
-	// Check email quotas before processing
-	if err := s.storage.CheckQuotaLimits(s.project.ID); err != nil {
-		log.Printf("Email quota exceeded for project %s: %v", s.project.ID, err)
-		
-		// Record audit log for quota exceeded
-		s.recordAuditLog("email_quota_exceeded", &s.project.ID, map[string]interface{}{
-			"from":    s.mailFrom,
-			"to":      s.rcptTo,
-			"reason":  "quota_limit_exceeded",
-		})
-		
-		return fmt.Errorf("quota exceeded: %w", err)
+	// Check email quotas before processing; scheduled emails are checked when they are due
+	if sendAt == nil {
+		if err := s.storage.CheckQuotaLimits(s.project.ID, s.mailFrom); err != nil {
+			log.Printf("Email quota exceeded for project %s: %v", s.project.ID, err)
+			
+			// Record audit log for quota exceeded
+			s.recordAuditLog("email_quota_exceeded", &s.project.ID, map[string]interface{}{
+				"from":    s.mailFrom,
+				"to":      s.rcptTo,
+				"reason":  "quota_limit_exceeded",
+			})
+			
+			return fmt.Errorf("quota exceeded: %w", err)
+		}
 	}
 	
 	// Generate unique message ID
@@ -521,6 +680,10 @@ func (s *SMTPSession) processEmail() error {
 		Attempts:  1,
 		SentAt:    time.Now(),
 	}
+	if sendAt != nil {
+		email.Status = storage.EmailStatusScheduled
+		email.ScheduledAt = sendAt
+	}
 	
 	// Store in database FIRST
 	if err := s.storage.StoreEmail(email); err != nil {
@@ -532,39 +695,40 @@ func (s *SMTPSession) processEmail() error {
 	log.Printf("✅ Email stored in database: %s", messageID)
 	
 	// Record audit log for successful email processing
-	s.recordAuditLog("email_processed", &s.project.ID, map[string]interface{}{
+	auditDetails := map[string]interface{}{
 		"message_id": messageID,
 		"from":       s.mailFrom,
 		"to":         s.rcptTo,
 		"subject":    subject,
 		"size":       len(s.data),
-	})
+	}
+	if len(s.dropped) > 0 {
+		auditDetails["suppressed"] = s.dropped
+	}
+	if sendAt != nil {
+		auditDetails["scheduled_at"] = sendAt.Format(time.RFC3339)
+	}
+	s.recordAuditLog("email_processed", &s.project.ID, auditDetails)
 	
-	// Only record quota usage AFTER successful database storage
-	if err := s.rateLimiter.RecordEmailSent(s.project.ID); err != nil {
-		log.Printf("⚠️  Warning: Email stored but failed to update quota tracking: %v", err)
-		// Don't fail the email send for quota tracking issues
-	} else {
-		log.Printf("✅ Quota counter updated for project %s", s.project.ID)
+	if s.server.forwarder != nil {
+		s.server.forwarder.Events().Emit(webhooks.EventAccepted, email.ProjectID, email, map[string]interface{}{
+			"source": "smtp",
+		})
 	}
 	
 	log.Printf("📧 Email processed successfully: %s from %s to %v (Project: %s)", 
 		messageID, s.mailFrom, s.rcptTo, s.project.ID)
 	
+	// The scheduler forwards scheduled emails once they are due
+	if sendAt != nil {
+		log.Printf("⏳ Email %s scheduled for %s", email.ID, sendAt.Format(time.RFC3339))
+		return nil
+	}
+	
 	// Forward to upstream SMTP server asynchronously
 	go func() {
 		if s.server.forwarder != nil {
-			err := s.server.forwarder.ForwardEmail(email, s.project.ID)
-			if err == nil {
-				// Success - mark as delivered
-				s.storage.UpdateEmailStatus(email.ID, "delivered", nil)
-				log.Printf("✅ Email %s forwarded successfully via SMTP", email.ID)
-			} else {
-				// Failed - mark as failed with error
-				errorMsg := fmt.Sprintf("SMTP forwarding failed: %s", err.Error())
-				s.storage.UpdateEmailStatus(email.ID, "failed", &errorMsg)
-				log.Printf("❌ Email %s forwarding failed: %s", email.ID, err.Error())
-			}
+			s.server.forwarder.Deliver(email)
 		} else {
 			log.Printf("⚠️  No email forwarder configured - email %s stored but not forwarded", email.ID)
 		}
@@ -584,6 +748,7 @@ func (s *SMTPSession) handleQuit() error {
 func (s *SMTPSession) handleReset() error {
 	s.mailFrom = ""
 	s.rcptTo = nil
+	s.dropped = nil
 	s.data = nil
 	s.state = StateHelo
 	return s.sendResponse("250 OK")
@@ -606,22 +771,8 @@ func (s *SMTPSession) recordAuditLog(action string, projectID *string, details m
 	// Generate unique audit log ID
 	auditID := generateAuditID()
 	
-	// Extract client IP from remote address
-	clientIP := s.remoteAddr
-	
-	// Clean up IP address for PostgreSQL INET type
-	// Handle IPv6 format like [::1]:port or IPv4 like 127.0.0.1:port
-	if strings.Contains(clientIP, ":") {
-		if strings.HasPrefix(clientIP, "[") {
-			// IPv6 format [::1]:port
-			if closeBracket := strings.Index(clientIP, "]"); closeBracket != -1 {
-				clientIP = clientIP[1:closeBracket]
-			}
-		} else {
-			// IPv4 format 127.0.0.1:port
-			clientIP = strings.Split(clientIP, ":")[0]
-		}
-	}
+	// Extract client IP from remote address (PostgreSQL INET type has no port)
+	clientIP := remoteIP(s.remoteAddr)
 	
 	auditLog := &storage.AuditLog{
 		ID:        auditID,
@@ -642,6 +793,14 @@ func (s *SMTPSession) recordAuditLog(action string, projectID *string, details m
 	}()
 }
 
+// remoteIP extracts the IP address from a host:port remote address (IPv4 or [IPv6])
+func remoteIP(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return strings.Trim(remoteAddr, "[]")
+}
+
 // generateAuditID generates a unique audit log ID
 func generateAuditID() string {
 	bytes := make([]byte, 8)
@@ -0,0 +1,277 @@
+package smtp
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DomainLimit caps outbound delivery to one recipient domain. Zero means no limit.
+type DomainLimit struct {
+	PerMinute   int // messages started per minute
+	Concurrency int // deliveries in progress at once
+}
+
+// defaultDomainLimits apply to the largest mailbox providers unless
+// DOMAIN_THROTTLE overrides them
+var defaultDomainLimits = map[string]DomainLimit{
+	"gmail.com":      {PerMinute: 120, Concurrency: 10},
+	"googlemail.com": {PerMinute: 120, Concurrency: 10},
+	"outlook.com":    {PerMinute: 60, Concurrency: 5},
+	"hotmail.com":    {PerMinute: 60, Concurrency: 5},
+	"live.com":       {PerMinute: 60, Concurrency: 5},
+	"msn.com":        {PerMinute: 60, Concurrency: 5},
+	"yahoo.com":      {PerMinute: 60, Concurrency: 5},
+}
+
+// DomainThrottleStatus describes the limits of a recipient domain and how
+// much of them is in use
+type DomainThrottleStatus struct {
+	Domain         string
+	PerMinute      int
+	Concurrency    int
+	SentLastMinute int
+	Active         int
+}
+
+// domainThrottle limits how fast and how many messages at once are sent to
+// each recipient domain. Recipients over a limit are left for a later attempt.
+type domainThrottle struct {
+	mu       sync.Mutex
+	limits   map[string]DomainLimit
+	fallback DomainLimit
+	sent     map[string][]time.Time
+	active   map[string]int
+}
+
+// newDomainThrottle creates a throttle with per-domain limits; fallback
+// applies to every other domain
+func newDomainThrottle(limits map[string]DomainLimit, fallback DomainLimit) *domainThrottle {
+	return &domainThrottle{
+		limits:   limits,
+		fallback: fallback,
+		sent:     make(map[string][]time.Time),
+		active:   make(map[string]int),
+	}
+}
+
+// newDomainThrottleFromEnv reads DOMAIN_THROTTLE (e.g. "gmail.com=120/10,example.org=30/2",
+// messages per minute / concurrent deliveries, added to or replacing the defaults)
+// and DOMAIN_THROTTLE_DEFAULT for other domains (default unlimited)
+func newDomainThrottleFromEnv() *domainThrottle {
+	limits := make(map[string]DomainLimit, len(defaultDomainLimits))
+	for domain, limit := range defaultDomainLimits {
+		limits[domain] = limit
+	}
+	if value := os.Getenv("DOMAIN_THROTTLE"); value != "" {
+		configured, err := parseDomainLimits(value)
+		if err != nil {
+			log.Printf("⚠️  Invalid DOMAIN_THROTTLE %q, using defaults: %v", value, err)
+		}
+		for domain, limit := range configured {
+			limits[domain] = limit
+		}
+	}
+	
+	var fallback DomainLimit
+	if value := os.Getenv("DOMAIN_THROTTLE_DEFAULT"); value != "" {
+		parsed, err := parseDomainLimit(value)
+		if err != nil {
+			log.Printf("⚠️  Invalid DOMAIN_THROTTLE_DEFAULT %q, other domains are not throttled: %v", value, err)
+		}
+		fallback = parsed
+	}
+	
+	return newDomainThrottle(limits, fallback)
+}
+
+// parseDomainLimits parses a comma-separated list of domain=perMinute/concurrency
+func parseDomainLimits(value string) (map[string]DomainLimit, error) {
+	limits := make(map[string]DomainLimit)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		
+		domain, limitValue, ok := strings.Cut(entry, "=")
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if !ok || domain == "" {
+			return limits, fmt.Errorf("expected domain=perMinute/concurrency, got %q", entry)
+		}
+		limit, err := parseDomainLimit(limitValue)
+		if err != nil {
+			return limits, fmt.Errorf("%s: %w", domain, err)
+		}
+		limits[domain] = limit
+	}
+	return limits, nil
+}
+
+// parseDomainLimit parses perMinute/concurrency, e.g. "120/10"; 0 is unlimited
+func parseDomainLimit(value string) (DomainLimit, error) {
+	perMinute, concurrency, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return DomainLimit{}, fmt.Errorf("expected perMinute/concurrency, got %q", value)
+	}
+	
+	var limit DomainLimit
+	var err error
+	if limit.PerMinute, err = strconv.Atoi(strings.TrimSpace(perMinute)); err != nil || limit.PerMinute < 0 {
+		return DomainLimit{}, fmt.Errorf("invalid messages per minute %q", perMinute)
+	}
+	if limit.Concurrency, err = strconv.Atoi(strings.TrimSpace(concurrency)); err != nil || limit.Concurrency < 0 {
+		return DomainLimit{}, fmt.Errorf("invalid concurrency %q", concurrency)
+	}
+	return limit, nil
+}
+
+// limitFor returns the limit of a recipient domain
+func (t *domainThrottle) limitFor(domain string) DomainLimit {
+	if limit, ok := t.limits[domain]; ok {
+		return limit
+	}
+	return t.fallback
+}
+
+// recentSends drops sends older than a minute and returns the rest
+func (t *domainThrottle) recentSends(domain string, now time.Time) []time.Time {
+	cutoff := now.Add(-time.Minute)
+	sent := t.sent[domain]
+	for len(sent) > 0 && !sent[0].After(cutoff) {
+		sent = sent[1:]
+	}
+	if len(sent) == 0 {
+		delete(t.sent, domain)
+		return nil
+	}
+	t.sent[domain] = sent
+	return sent
+}
+
+// acquire takes a delivery slot for each recipient domain that has one free
+// and splits the recipients into those that may be sent to now and those
+// that are throttled. release must be called once the delivery is over.
+func (t *domainThrottle) acquire(recipients []string) ([]string, []string, func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	now := time.Now()
+	var allowed, throttled, acquired []string
+	decided := map[string]bool{}
+	for _, rcpt := range recipients {
+		domain := recipientDomain(rcpt)
+		if ok, seen := decided[domain]; seen {
+			if ok {
+				allowed = append(allowed, rcpt)
+			} else {
+				throttled = append(throttled, rcpt)
+			}
+			continue
+		}
+		
+		limit := t.limitFor(domain)
+		ok := (limit.PerMinute == 0 || len(t.recentSends(domain, now)) < limit.PerMinute) &&
+			(limit.Concurrency == 0 || t.active[domain] < limit.Concurrency)
+		decided[domain] = ok
+		if !ok {
+			throttled = append(throttled, rcpt)
+			continue
+		}
+		
+		allowed = append(allowed, rcpt)
+		if limit.PerMinute > 0 {
+			t.sent[domain] = append(t.sent[domain], now)
+		}
+		if limit.Concurrency > 0 {
+			t.active[domain]++
+			acquired = append(acquired, domain)
+		}
+	}
+	
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			for _, domain := range acquired {
+				if t.active[domain]--; t.active[domain] <= 0 {
+					delete(t.active, domain)
+				}
+			}
+		})
+	}
+	return allowed, throttled, release
+}
+
+// snapshot returns the limits and current load of every configured or busy
+// domain, busiest first
+func (t *domainThrottle) snapshot() []DomainThrottleStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	now := time.Now()
+	domains := map[string]bool{}
+	for domain := range t.limits {
+		domains[domain] = true
+	}
+	for domain := range t.sent {
+		domains[domain] = true
+	}
+	for domain := range t.active {
+		domains[domain] = true
+	}
+	
+	statuses := make([]DomainThrottleStatus, 0, len(domains))
+	for domain := range domains {
+		statuses = append(statuses, t.status(domain, now))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Active != statuses[j].Active {
+			return statuses[i].Active > statuses[j].Active
+		}
+		if statuses[i].SentLastMinute != statuses[j].SentLastMinute {
+			return statuses[i].SentLastMinute > statuses[j].SentLastMinute
+		}
+		return statuses[i].Domain < statuses[j].Domain
+	})
+	return statuses
+}
+
+// status returns the limits and current load of one domain
+func (t *domainThrottle) status(domain string, now time.Time) DomainThrottleStatus {
+	limit := t.limitFor(domain)
+	return DomainThrottleStatus{
+		Domain:         domain,
+		PerMinute:      limit.PerMinute,
+		Concurrency:    limit.Concurrency,
+		SentLastMinute: len(t.recentSends(domain, now)),
+		Active:         t.active[domain],
+	}
+}
+
+// DomainThrottle returns the limits and current load of each configured or
+// recently used recipient domain
+func (f *EmailForwarder) DomainThrottle() []DomainThrottleStatus {
+	return f.throttle.snapshot()
+}
+
+// DomainThrottleFor returns the limits and current load of one recipient domain
+func (f *EmailForwarder) DomainThrottleFor(domain string) DomainThrottleStatus {
+	f.throttle.mu.Lock()
+	defer f.throttle.mu.Unlock()
+	return f.throttle.status(strings.ToLower(domain), time.Now())
+}
+
+// recipientDomain returns the lowercased domain of an address
+func recipientDomain(address string) string {
+	if at := strings.LastIndex(address, "@"); at >= 0 {
+		return strings.ToLower(address[at+1:])
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package smtp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+// upstreamDialTimeout bounds connecting to an upstream relay
+const upstreamDialTimeout = 30 * time.Second
+
+// tlsVersions maps configured minimum versions to crypto/tls constants
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ValidateUpstreamTLS checks a project's upstream TLS policy
+func ValidateUpstreamTLS(policy storage.UpstreamTLSPolicy) error {
+	switch policy.Mode {
+	case storage.UpstreamTLSNone, storage.UpstreamTLSOpportunistic, storage.UpstreamTLSStartTLS, storage.UpstreamTLSImplicit:
+	default:
+		return fmt.Errorf("invalid TLS mode %q (use none, opportunistic, starttls or implicit)", policy.Mode)
+	}
+	_, err := upstreamTLSConfig(policy, "")
+	return err
+}
+
+// upstreamTLSConfig builds the TLS client configuration for an upstream host
+func upstreamTLSConfig(policy storage.UpstreamTLSPolicy, host string) (*tls.Config, error) {
+	config := &tls.Config{ServerName: host}
+	
+	if policy.ServerName != nil && *policy.ServerName != "" {
+		config.ServerName = *policy.ServerName
+	}
+	
+	if policy.MinVersion != "" {
+		version, ok := tlsVersions[policy.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid minimum TLS version %q (use 1.0, 1.1, 1.2 or 1.3)", policy.MinVersion)
+		}
+		config.MinVersion = version
+	}
+	
+	if policy.CABundle != nil && *policy.CABundle != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(*policy.CABundle)) {
+			return nil, errors.New("CA bundle contains no valid PEM certificates")
+		}
+		config.RootCAs = pool
+	}
+	
+	return config, nil
+}
+
+// dialUpstream connects to an upstream relay according to the project's TLS
+// policy. The underlying connection is returned so callers can set deadlines.
+func dialUpstream(host string, port int, policy storage.UpstreamTLSPolicy) (*smtp.Client, net.Conn, error) {
+	config, err := upstreamTLSConfig(policy, host)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	addr := net.JoinHostPort(host, fmt.Sprint(port))
+	dialer := &net.Dialer{Timeout: upstreamDialTimeout}
+	
+	var conn net.Conn
+	if policy.Mode == storage.UpstreamTLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("implicit TLS connect: %w", err)
+		}
+	} else if conn, err = dialer.Dial("tcp", addr); err != nil {
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Now().Add(upstreamDialTimeout))
+	
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	
+	if policy.Mode == storage.UpstreamTLSNone || policy.Mode == storage.UpstreamTLSImplicit {
+		return client, conn, nil
+	}
+	
+	if ok, _ := client.Extension("STARTTLS"); !ok {
+		if policy.Mode == storage.UpstreamTLSStartTLS {
+			client.Close()
+			return nil, nil, errors.New("upstream does not offer STARTTLS but the TLS policy requires it")
+		}
+		return client, conn, nil
+	}
+	
+	if err := client.StartTLS(config); err != nil {
+		client.Close()
+		if policy.Mode == storage.UpstreamTLSOpportunistic {
+			// Opportunistic TLS never blocks delivery - reconnect in plaintext
+			log.Printf("⚠️  STARTTLS with %s failed, retrying without TLS: %v", addr, err)
+			plaintext := policy
+			plaintext.Mode = storage.UpstreamTLSNone
+			return dialUpstream(host, port, plaintext)
+		}
+		return nil, nil, fmt.Errorf("STARTTLS: %w", err)
+	}
+	return client, conn, nil
+}
+
+// negotiatedTLS returns the TLS version and cipher suite of a session, or nils for plaintext
+func negotiatedTLS(client *smtp.Client) (*string, *string) {
+	state, ok := client.TLSConnectionState()
+	if !ok {
+		return nil, nil
+	}
+	version := tls.VersionName(state.Version)
+	cipher := tls.CipherSuiteName(state.CipherSuite)
+	return &version, &cipher
+}
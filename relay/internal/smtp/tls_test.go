@@ -0,0 +1,35 @@
+package smtp
+
+import (
+	"testing"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+func TestDialUpstreamOpportunisticFallsBackToPlaintext(t *testing.T) {
+	server := newFakeMX(t, nil, true)
+	
+	client, _, err := dialUpstream("127.0.0.1", server.port(), storage.UpstreamTLSPolicy{Mode: storage.UpstreamTLSOpportunistic})
+	if err != nil {
+		t.Fatalf("dialUpstream: %v", err)
+	}
+	defer client.Close()
+	
+	if version, _ := negotiatedTLS(client); version != nil {
+		t.Errorf("negotiated TLS %s, want a plaintext session", *version)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.tlsAttempts != 1 || server.sessions != 2 {
+		t.Errorf("tls attempts %d, sessions %d; want one refused STARTTLS then a plaintext session", server.tlsAttempts, server.sessions)
+	}
+}
+
+func TestDialUpstreamRequiredSTARTTLSFails(t *testing.T) {
+	server := newFakeMX(t, nil, true)
+	
+	if client, _, err := dialUpstream("127.0.0.1", server.port(), storage.UpstreamTLSPolicy{Mode: storage.UpstreamTLSStartTLS}); err == nil {
+		client.Close()
+		t.Fatal("dialUpstream succeeded although the required STARTTLS failed")
+	}
+}
@@ -0,0 +1,213 @@
+package smtp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/Renespeare/mailpulse/relay/internal/tracking"
+)
+
+// trackMessage adds the project's open and click tracking to the HTML parts
+// of an outgoing message. It must run before DKIM signing.
+func (f *EmailForwarder) trackMessage(message []byte, email *storage.Email, project *storage.Project) []byte {
+	if !project.TrackOpens && !project.TrackClicks {
+		return message
+	}
+	if f.trackingURL == "" {
+		log.Printf("⚠️  Tracking is enabled for project %s but TRACKING_BASE_URL is not set", project.ID)
+		return message
+	}
+	
+	pixelURL := tracking.OpenURL(f.trackingURL, email.ID)
+	tracked, changed := rewriteHTML(message, func(html string) string {
+		if project.TrackClicks {
+			html = tracking.RewriteLinks(html, func(target string) string {
+				return tracking.ClickURL(f.trackingURL, email.ID, target)
+			})
+		}
+		if project.TrackOpens {
+			html = tracking.InjectPixel(html, pixelURL)
+		}
+		return html
+	})
+	if !changed {
+		return message // plain text only - nothing to track
+	}
+	return tracked
+}
+
+// rewriteHTML applies rewrite to every HTML body part of a message (or MIME
+// part), re-encoding it with its original transfer encoding. Other parts are
+// copied unchanged. It reports whether any part was rewritten.
+func rewriteHTML(raw []byte, rewrite func(string) string) ([]byte, bool) {
+	headerEnd, bodyStart := splitHeader(raw)
+	if headerEnd < 0 {
+		return raw, false
+	}
+	header, err := textproto.NewReader(bufio.NewReader(bytes.NewReader(raw[:bodyStart]))).ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return raw, false
+	}
+	
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return raw, false
+	}
+	body := raw[bodyStart:]
+	
+	var rewritten []byte
+	changed := false
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "":
+		rewritten, changed = rewriteMultipart(body, params["boundary"], rewrite)
+	case mediaType == "text/html":
+		disposition, _, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+		if disposition == "attachment" {
+			return raw, false
+		}
+		rewritten, changed = rewriteHTMLBody(body, header.Get("Content-Transfer-Encoding"), rewrite)
+	}
+	if !changed {
+		return raw, false
+	}
+	
+	out := make([]byte, 0, bodyStart+len(rewritten))
+	out = append(out, raw[:bodyStart]...)
+	return append(out, rewritten...), true
+}
+
+// splitHeader finds the blank line ending a header block. It returns the end
+// of the header fields and the start of the body, or -1 when there is none.
+func splitHeader(raw []byte) (int, int) {
+	if bytes.HasPrefix(raw, []byte("\r\n")) {
+		return 0, 2
+	}
+	if bytes.HasPrefix(raw, []byte("\n")) {
+		return 0, 1
+	}
+	crlf := bytes.Index(raw, []byte("\r\n\r\n"))
+	lf := bytes.Index(raw, []byte("\n\n"))
+	switch {
+	case crlf >= 0 && (lf < 0 || crlf < lf):
+		return crlf, crlf + 4
+	case lf >= 0:
+		return lf, lf + 2
+	}
+	return -1, -1
+}
+
+// rewriteMultipart rewrites the parts of a multipart body, keeping the
+// preamble, delimiters and epilogue byte for byte
+func rewriteMultipart(body []byte, boundary string, rewrite func(string) string) ([]byte, bool) {
+	delimiter := []byte("--" + boundary)
+	pos := indexDelimiter(body, delimiter, 0)
+	if pos < 0 {
+		return body, false
+	}
+	
+	var out bytes.Buffer
+	out.Write(body[:pos])
+	changed := false
+	for {
+		lineEnd := bytes.IndexByte(body[pos:], '\n')
+		if lineEnd < 0 {
+			out.Write(body[pos:])
+			break
+		}
+		out.Write(body[pos : pos+lineEnd+1])
+		if bytes.HasPrefix(body[pos+len(delimiter):], []byte("--")) {
+			// Closing delimiter - the rest is the epilogue
+			out.Write(body[pos+lineEnd+1:])
+			break
+		}
+		
+		start := pos + lineEnd + 1
+		next := indexDelimiter(body, delimiter, start)
+		if next < 0 {
+			out.Write(body[start:])
+			break
+		}
+		// The line break before a delimiter belongs to the delimiter
+		end := next
+		if end > start && body[end-1] == '\n' {
+			end--
+			if end > start && body[end-1] == '\r' {
+				end--
+			}
+		}
+		
+		part, partChanged := rewriteHTML(body[start:end], rewrite)
+		out.Write(part)
+		out.Write(body[end:next])
+		changed = changed || partChanged
+		pos = next
+	}
+	return out.Bytes(), changed
+}
+
+// indexDelimiter finds the next boundary delimiter line at or after from
+func indexDelimiter(body, delimiter []byte, from int) int {
+	for from <= len(body) {
+		i := bytes.Index(body[from:], delimiter)
+		if i < 0 {
+			return -1
+		}
+		i += from
+		atLineStart := i == 0 || body[i-1] == '\n'
+		rest := body[i+len(delimiter):]
+		if atLineStart && (len(rest) == 0 || rest[0] == '\r' || rest[0] == '\n' || rest[0] == '-' ||
+			rest[0] == ' ' || rest[0] == '\t') {
+			return i
+		}
+		from = i + 1
+	}
+	return -1
+}
+
+// rewriteHTMLBody decodes an HTML body, rewrites it and encodes it again
+func rewriteHTMLBody(body []byte, encoding string, rewrite func(string) string) ([]byte, bool) {
+	decoded, err := io.ReadAll(decodeTransfer(encoding, bytes.NewReader(body)))
+	if err != nil {
+		return body, false
+	}
+	html := rewrite(string(decoded))
+	if html == string(decoded) {
+		return body, false
+	}
+	
+	var out bytes.Buffer
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		encoded := base64.StdEncoding.EncodeToString([]byte(html))
+		for len(encoded) > 76 {
+			out.WriteString(encoded[:76] + "\r\n")
+			encoded = encoded[76:]
+		}
+		out.WriteString(encoded)
+	case "quoted-printable":
+		writer := quotedprintable.NewWriter(&out)
+		writer.Write([]byte(html))
+		writer.Close()
+	default:
+		return []byte(html), true
+	}
+	
+	// Keep the body's final line break (or its absence) as it was
+	encoded := bytes.TrimRight(out.Bytes(), "\r\n")
+	if bytes.HasSuffix(body, []byte("\n")) {
+		encoded = append(encoded, '\r', '\n')
+	}
+	return encoded, true
+}
@@ -0,0 +1,431 @@
+package smtp
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/crypto"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+// Default provider API endpoints
+const (
+	sendGridEndpoint    = "https://api.sendgrid.com/v3/mail/send"
+	sesEndpointTemplate = "https://email.%s.amazonaws.com/v2/email/outbound-emails"
+)
+
+// transportTimeout bounds a single API request
+const transportTimeout = 30 * time.Second
+
+// Transport delivers a message through an HTTP email API instead of SMTP
+type Transport interface {
+	// Name identifies the transport on the email record and in logs
+	Name() string
+	// Send delivers the signed message to the recipients
+	Send(email *storage.Email, recipients []string, message []byte) ([]RecipientResult, error)
+}
+
+// TransportFactory builds a transport from a project's settings and decrypted secret
+type TransportFactory func(settings storage.TransportSettings, secret string) (Transport, error)
+
+// transports maps delivery modes to the HTTP transports implementing them
+var transports = map[string]TransportFactory{
+	storage.DeliveryModeWebhook:  newWebhookTransport,
+	storage.DeliveryModeSES:      newSESTransport,
+	storage.DeliveryModeSendGrid: newSendGridTransport,
+}
+
+// RegisterTransport makes an HTTP transport available as a delivery mode
+func RegisterTransport(mode string, factory TransportFactory) {
+	transports[mode] = factory
+}
+
+// IsTransportMode reports whether a delivery mode is served by an HTTP transport
+func IsTransportMode(mode string) bool {
+	_, ok := transports[mode]
+	return ok
+}
+
+// ValidateTransport checks that a project has the settings its transport needs
+func ValidateTransport(mode string, settings storage.TransportSettings) error {
+	factory, ok := transports[mode]
+	if !ok {
+		return nil
+	}
+	secret := ""
+	if settings.SecretEnc != nil {
+		secret = "configured"
+	}
+	_, err := factory(settings, secret)
+	return err
+}
+
+// transportFor builds the project's HTTP transport, decrypting its secret
+func transportFor(project *storage.Project) (Transport, error) {
+	factory, ok := transports[project.DeliveryMode]
+	if !ok {
+		return nil, fmt.Errorf("unknown delivery mode %q", project.DeliveryMode)
+	}
+	
+	secret := ""
+	if project.Transport.SecretEnc != nil && *project.Transport.SecretEnc != "" {
+		decrypted, err := crypto.DecryptTransportSecret(*project.Transport.SecretEnc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt transport secret: %w", err)
+		}
+		secret = decrypted
+	}
+	
+	return factory(project.Transport, secret)
+}
+
+// endpointOr returns the configured endpoint, or the default
+func endpointOr(settings storage.TransportSettings, fallback string) (string, error) {
+	endpoint := fallback
+	if settings.Endpoint != nil && *settings.Endpoint != "" {
+		endpoint = *settings.Endpoint
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return "", fmt.Errorf("invalid transport endpoint %q", endpoint)
+	}
+	return endpoint, nil
+}
+
+// httpResults maps an API response to recipient results: 2xx delivers,
+// 429 and 5xx defer (retryable) and any other status fails the recipients
+func httpResults(recipients []string, status int, message, provider string) []RecipientResult {
+	switch {
+	case status >= 200 && status < 300:
+		return allRecipients(recipients, RecipientDelivered, status, message, provider)
+	case status == http.StatusTooManyRequests || status >= 500:
+		return allRecipients(recipients, RecipientDeferred, status, message, provider)
+	default:
+		return allRecipients(recipients, RecipientFailed, status, message, provider)
+	}
+}
+
+// postJSON sends a JSON request and returns the recipient results. Network
+// errors defer every recipient, since the API may accept the message later.
+func postJSON(client *http.Client, req *http.Request, recipients []string, provider string, accepted func(*http.Response, []byte) string) []RecipientResult {
+	req.Header.Set("Content-Type", "application/json")
+	
+	resp, err := client.Do(req)
+	if err != nil {
+		return allRecipients(recipients, RecipientDeferred, 0, err.Error(), provider)
+	}
+	defer resp.Body.Close()
+	
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	message := strings.TrimSpace(string(body))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		message = accepted(resp, body)
+	} else if message == "" {
+		message = resp.Status
+	}
+	return httpResults(recipients, resp.StatusCode, message, provider)
+}
+
+// webhookTransport POSTs each message as JSON to a generic HTTP endpoint,
+// with the API key (if any) as a bearer token
+type webhookTransport struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+// webhookPayload is the body sent to a webhook transport
+type webhookPayload struct {
+	ID         string   `json:"id"`
+	ProjectID  string   `json:"projectId"`
+	From       string   `json:"from"`
+	Recipients []string `json:"recipients"`
+	Subject    string   `json:"subject"`
+	Raw        string   `json:"raw"` // base64 encoded RFC 5322 message
+}
+
+func newWebhookTransport(settings storage.TransportSettings, secret string) (Transport, error) {
+	if settings.Endpoint == nil || *settings.Endpoint == "" {
+		return nil, errors.New("webhook transport requires an endpoint")
+	}
+	endpoint, err := endpointOr(settings, "")
+	if err != nil {
+		return nil, err
+	}
+	return &webhookTransport{endpoint: endpoint, apiKey: secret, client: &http.Client{Timeout: transportTimeout}}, nil
+}
+
+func (t *webhookTransport) Name() string {
+	return "webhook"
+}
+
+func (t *webhookTransport) Send(email *storage.Email, recipients []string, message []byte) ([]RecipientResult, error) {
+	payload, err := json.Marshal(webhookPayload{
+		ID:         email.ID,
+		ProjectID:  email.ProjectID,
+		From:       envelopeAddress(email.From),
+		Recipients: recipients,
+		Subject:    email.Subject,
+		Raw:        base64.StdEncoding.EncodeToString(message),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+	
+	results := postJSON(t.client, req, recipients, t.Name(), func(resp *http.Response, _ []byte) string {
+		return "accepted by webhook"
+	})
+	return results, resultsError(results)
+}
+
+// sendGridTransport sends through the SendGrid v3 mail/send API. SendGrid
+// builds the MIME message itself, so the text, HTML and attachments are
+// extracted from the message; each recipient gets its own personalization so
+// recipients don't see each other.
+type sendGridTransport struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridAttachment struct {
+	Content  string `json:"content"`
+	Type     string `json:"type,omitempty"`
+	Filename string `json:"filename"`
+}
+
+type sendGridRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	Attachments      []sendGridAttachment      `json:"attachments,omitempty"`
+	CustomArgs       map[string]string         `json:"custom_args,omitempty"`
+}
+
+func newSendGridTransport(settings storage.TransportSettings, secret string) (Transport, error) {
+	if secret == "" {
+		return nil, errors.New("sendgrid transport requires an API key")
+	}
+	endpoint, err := endpointOr(settings, sendGridEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &sendGridTransport{endpoint: endpoint, apiKey: secret, client: &http.Client{Timeout: transportTimeout}}, nil
+}
+
+func (t *sendGridTransport) Name() string {
+	return "sendgrid"
+}
+
+func (t *sendGridTransport) Send(email *storage.Email, recipients []string, message []byte) ([]RecipientResult, error) {
+	content, err := ParseMessageContent(message)
+	if err != nil {
+		return nil, err
+	}
+	
+	from := sendGridAddress{Email: envelopeAddress(email.From)}
+	if addr, err := mail.ParseAddress(email.From); err == nil {
+		from.Name = addr.Name
+	}
+	
+	request := sendGridRequest{
+		From:       from,
+		Subject:    email.Subject,
+		CustomArgs: map[string]string{"mailpulse_email_id": email.ID},
+	}
+	for _, rcpt := range recipients {
+		request.Personalizations = append(request.Personalizations, sendGridPersonalization{
+			To: []sendGridAddress{{Email: rcpt}},
+		})
+	}
+	// SendGrid requires text/plain before text/html
+	if content.Text != "" || content.HTML == "" {
+		request.Content = append(request.Content, sendGridContent{Type: "text/plain", Value: content.Text})
+	}
+	if content.HTML != "" {
+		request.Content = append(request.Content, sendGridContent{Type: "text/html", Value: content.HTML})
+	}
+	for _, attachment := range content.Attachments {
+		request.Attachments = append(request.Attachments, sendGridAttachment{
+			Content:  base64.StdEncoding.EncodeToString(attachment.Data),
+			Type:     attachment.ContentType,
+			Filename: attachment.Filename,
+		})
+	}
+	
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SendGrid request: %w", err)
+	}
+	
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SendGrid request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	
+	results := postJSON(t.client, req, recipients, t.Name(), func(resp *http.Response, _ []byte) string {
+		if id := resp.Header.Get("X-Message-Id"); id != "" {
+			return "accepted (message id " + id + ")"
+		}
+		return "accepted"
+	})
+	return results, resultsError(results)
+}
+
+// sesTransport sends the raw (DKIM signed) message through the Amazon SES v2
+// SendEmail API, signing requests with AWS Signature Version 4
+type sesTransport struct {
+	endpoint    string
+	region      string
+	accessKeyID string
+	secretKey   string
+	client      *http.Client
+	now         func() time.Time
+}
+
+type sesRequest struct {
+	FromEmailAddress string `json:"FromEmailAddress"`
+	Destination      struct {
+		ToAddresses []string `json:"ToAddresses"`
+	} `json:"Destination"`
+	Content struct {
+		Raw struct {
+			Data string `json:"Data"`
+		} `json:"Raw"`
+	} `json:"Content"`
+}
+
+func newSESTransport(settings storage.TransportSettings, secret string) (Transport, error) {
+	if settings.Region == nil || *settings.Region == "" {
+		return nil, errors.New("ses transport requires a region")
+	}
+	if settings.AccessKeyID == nil || *settings.AccessKeyID == "" || secret == "" {
+		return nil, errors.New("ses transport requires an access key ID and secret access key")
+	}
+	endpoint, err := endpointOr(settings, fmt.Sprintf(sesEndpointTemplate, *settings.Region))
+	if err != nil {
+		return nil, err
+	}
+	return &sesTransport{
+		endpoint:    endpoint,
+		region:      *settings.Region,
+		accessKeyID: *settings.AccessKeyID,
+		secretKey:   secret,
+		client:      &http.Client{Timeout: transportTimeout},
+		now:         time.Now,
+	}, nil
+}
+
+func (t *sesTransport) Name() string {
+	return "ses:" + t.region
+}
+
+func (t *sesTransport) Send(email *storage.Email, recipients []string, message []byte) ([]RecipientResult, error) {
+	var request sesRequest
+	request.FromEmailAddress = envelopeAddress(email.From)
+	request.Destination.ToAddresses = recipients
+	request.Content.Raw.Data = base64.StdEncoding.EncodeToString(message)
+	
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SES request: %w", err)
+	}
+	
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SES request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signV4(req, payload, t.accessKeyID, t.secretKey, t.region, "ses", t.now())
+	
+	results := postJSON(t.client, req, recipients, t.Name(), func(_ *http.Response, body []byte) string {
+		var reply struct {
+			MessageId string `json:"MessageId"`
+		}
+		if json.Unmarshal(body, &reply) == nil && reply.MessageId != "" {
+			return "accepted (message id " + reply.MessageId + ")"
+		}
+		return "accepted"
+	})
+	return results, resultsError(results)
+}
+
+// signV4 adds AWS Signature Version 4 headers to a request
+func signV4(req *http.Request, payload []byte, accessKeyID, secretKey, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	
+	payloadHash := sha256.Sum256(payload)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + hex.EncodeToString(payloadHash[:]) + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, req.URL.Query().Encode(), canonicalHeaders, signedHeaders, hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+	
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,330 @@
+package smtp
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+const testMessage = "From: Sender <sender@example.com>\r\n" +
+	"To: a@example.org\r\n" +
+	"Subject: Hello\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"Hello there\r\n"
+
+func testEmail() *storage.Email {
+	return &storage.Email{
+		ID:        "email-1",
+		ProjectID: "project-1",
+		From:      "Sender <sender@example.com>",
+		To:        []string{"a@example.org", "b@example.net"},
+		Subject:   "Hello",
+	}
+}
+
+// capturedRequest is what a test server received
+type capturedRequest struct {
+	header http.Header
+	host   string
+	path   string
+	body   []byte
+}
+
+// captureServer replies with status and body and records the request it got
+func captureServer(t *testing.T, status int, reply string, header http.Header) (*httptest.Server, *capturedRequest) {
+	t.Helper()
+	captured := &capturedRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		captured.header = r.Header.Clone()
+		captured.host = r.Host
+		captured.path = r.URL.Path
+		captured.body = body
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(server.Close)
+	return server, captured
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestWebhookTransportSendsBearerTokenAndPayload(t *testing.T) {
+	server, captured := captureServer(t, http.StatusAccepted, "", nil)
+	
+	transport, err := newWebhookTransport(storage.TransportSettings{Endpoint: stringPtr(server.URL + "/hook")}, "secret-key")
+	if err != nil {
+		t.Fatalf("newWebhookTransport: %v", err)
+	}
+	email := testEmail()
+	results, err := transport.Send(email, email.To, []byte(testMessage))
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	
+	if got := captured.header.Get("Authorization"); got != "Bearer secret-key" {
+		t.Errorf("Authorization = %q, want bearer token", got)
+	}
+	if got := captured.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	
+	var payload webhookPayload
+	if err := json.Unmarshal(captured.body, &payload); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if payload.ID != "email-1" || payload.ProjectID != "project-1" || payload.From != "sender@example.com" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+	if strings.Join(payload.Recipients, ",") != "a@example.org,b@example.net" {
+		t.Errorf("recipients = %v", payload.Recipients)
+	}
+	raw, err := base64.StdEncoding.DecodeString(payload.Raw)
+	if err != nil || string(raw) != testMessage {
+		t.Errorf("raw message not round-tripped: %v", err)
+	}
+	
+	for _, result := range results {
+		if result.Status != RecipientDelivered || result.Code != http.StatusAccepted {
+			t.Errorf("result %+v, want delivered", result)
+		}
+	}
+}
+
+func TestWebhookTransportWithoutSecretSendsNoAuthorization(t *testing.T) {
+	server, captured := captureServer(t, http.StatusOK, "", nil)
+	
+	transport, err := newWebhookTransport(storage.TransportSettings{Endpoint: stringPtr(server.URL)}, "")
+	if err != nil {
+		t.Fatalf("newWebhookTransport: %v", err)
+	}
+	if _, err := transport.Send(testEmail(), []string{"a@example.org"}, []byte(testMessage)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got := captured.header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want none", got)
+	}
+}
+
+func TestSendGridTransportBuildsPersonalizationsAndContent(t *testing.T) {
+	server, captured := captureServer(t, http.StatusAccepted, "", http.Header{"X-Message-Id": {"sg-123"}})
+	
+	transport, err := newSendGridTransport(storage.TransportSettings{Endpoint: stringPtr(server.URL)}, "SG.key")
+	if err != nil {
+		t.Fatalf("newSendGridTransport: %v", err)
+	}
+	email := testEmail()
+	results, err := transport.Send(email, email.To, []byte(testMessage))
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	
+	if got := captured.header.Get("Authorization"); got != "Bearer SG.key" {
+		t.Errorf("Authorization = %q", got)
+	}
+	
+	var request sendGridRequest
+	if err := json.Unmarshal(captured.body, &request); err != nil {
+		t.Fatalf("invalid request: %v", err)
+	}
+	if len(request.Personalizations) != 2 {
+		t.Fatalf("got %d personalizations, want one per recipient", len(request.Personalizations))
+	}
+	for i, rcpt := range email.To {
+		to := request.Personalizations[i].To
+		if len(to) != 1 || to[0].Email != rcpt {
+			t.Errorf("personalization %d = %+v, want only %s", i, to, rcpt)
+		}
+	}
+	if request.From.Email != "sender@example.com" || request.From.Name != "Sender" {
+		t.Errorf("from = %+v", request.From)
+	}
+	if request.Subject != "Hello" || request.CustomArgs["mailpulse_email_id"] != "email-1" {
+		t.Errorf("subject %q, custom args %v", request.Subject, request.CustomArgs)
+	}
+	if len(request.Content) != 1 || request.Content[0].Type != "text/plain" || strings.TrimSpace(request.Content[0].Value) != "Hello there" {
+		t.Errorf("content = %+v", request.Content)
+	}
+	
+	if results[0].Message != "accepted (message id sg-123)" {
+		t.Errorf("message = %q", results[0].Message)
+	}
+}
+
+func TestSendGridTransportOrdersTextBeforeHTML(t *testing.T) {
+	server, captured := captureServer(t, http.StatusAccepted, "", nil)
+	message := "From: sender@example.com\r\n" +
+		"Subject: Hello\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/alternative; boundary=b1\r\n" +
+		"\r\n" +
+		"--b1\r\nContent-Type: text/plain\r\n\r\nplain\r\n" +
+		"--b1\r\nContent-Type: text/html\r\n\r\n<p>html</p>\r\n" +
+		"--b1--\r\n"
+	
+	transport, err := newSendGridTransport(storage.TransportSettings{Endpoint: stringPtr(server.URL)}, "SG.key")
+	if err != nil {
+		t.Fatalf("newSendGridTransport: %v", err)
+	}
+	if _, err := transport.Send(testEmail(), []string{"a@example.org"}, []byte(message)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	
+	var request sendGridRequest
+	if err := json.Unmarshal(captured.body, &request); err != nil {
+		t.Fatalf("invalid request: %v", err)
+	}
+	if len(request.Content) != 2 || request.Content[0].Type != "text/plain" || request.Content[1].Type != "text/html" {
+		t.Errorf("content = %+v, want text/plain then text/html", request.Content)
+	}
+}
+
+func TestSESTransportSignsRequests(t *testing.T) {
+	server, captured := captureServer(t, http.StatusOK, `{"MessageId":"ses-1"}`, nil)
+	
+	transport, err := newSESTransport(storage.TransportSettings{
+		Endpoint:    stringPtr(server.URL + "/v2/email/outbound-emails"),
+		Region:      stringPtr("eu-west-1"),
+		AccessKeyID: stringPtr("AKIDEXAMPLE"),
+	}, "secret-access-key")
+	if err != nil {
+		t.Fatalf("newSESTransport: %v", err)
+	}
+	signedAt := time.Date(2024, 3, 9, 12, 30, 45, 0, time.UTC)
+	transport.(*sesTransport).now = func() time.Time { return signedAt }
+	
+	email := testEmail()
+	results, err := transport.Send(email, email.To, []byte(testMessage))
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	
+	if got := captured.header.Get("X-Amz-Date"); got != "20240309T123045Z" {
+		t.Errorf("X-Amz-Date = %q", got)
+	}
+	authorization := captured.header.Get("Authorization")
+	prefix := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20240309/eu-west-1/ses/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date, Signature="
+	if !strings.HasPrefix(authorization, prefix) {
+		t.Fatalf("Authorization = %q, want prefix %q", authorization, prefix)
+	}
+	if got, want := strings.TrimPrefix(authorization, prefix), expectedSignature(t, captured, "secret-access-key", "eu-west-1", "ses"); got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+	
+	var request sesRequest
+	if err := json.Unmarshal(captured.body, &request); err != nil {
+		t.Fatalf("invalid request: %v", err)
+	}
+	if request.FromEmailAddress != "sender@example.com" || len(request.Destination.ToAddresses) != 2 {
+		t.Errorf("unexpected request %+v", request)
+	}
+	if results[0].Message != "accepted (message id ses-1)" || transport.Name() != "ses:eu-west-1" {
+		t.Errorf("message %q, name %q", results[0].Message, transport.Name())
+	}
+}
+
+// expectedSignature verifies a request the way SES does: it rebuilds the
+// canonical request from what arrived and derives the signature from the secret
+func expectedSignature(t *testing.T, captured *capturedRequest, secretKey, region, service string) string {
+	t.Helper()
+	amzDate := captured.header.Get("X-Amz-Date")
+	payloadHash := sha256Hex(captured.body)
+	if got := captured.header.Get("X-Amz-Content-Sha256"); got != payloadHash {
+		t.Errorf("X-Amz-Content-Sha256 = %q, want %q", got, payloadHash)
+	}
+	
+	canonicalRequest := "POST\n" + captured.path + "\n\n" +
+		"content-type:" + captured.header.Get("Content-Type") + "\n" +
+		"host:" + captured.host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n\n" +
+		"content-type;host;x-amz-content-sha256;x-amz-date\n" + payloadHash
+	scope := amzDate[:8] + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{amzDate[:8], region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestTransportStatusMapping(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{http.StatusOK, RecipientDelivered},
+		{http.StatusAccepted, RecipientDelivered},
+		{http.StatusTooManyRequests, RecipientDeferred},
+		{http.StatusInternalServerError, RecipientDeferred},
+		{http.StatusServiceUnavailable, RecipientDeferred},
+		{http.StatusBadRequest, RecipientFailed},
+		{http.StatusUnauthorized, RecipientFailed},
+		{http.StatusForbidden, RecipientFailed},
+	}
+	
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.status), func(t *testing.T) {
+			server, _ := captureServer(t, tt.status, "provider says no", nil)
+			transport, err := newWebhookTransport(storage.TransportSettings{Endpoint: stringPtr(server.URL)}, "")
+			if err != nil {
+				t.Fatalf("newWebhookTransport: %v", err)
+			}
+			
+			results, err := transport.Send(testEmail(), []string{"a@example.org", "b@example.net"}, []byte(testMessage))
+			if (err == nil) != (tt.want == RecipientDelivered) {
+				t.Errorf("err = %v", err)
+			}
+			if len(results) != 2 {
+				t.Fatalf("got %d results, want 2", len(results))
+			}
+			for _, result := range results {
+				if result.Status != tt.want || result.Code != tt.status {
+					t.Errorf("result %+v, want %s with code %d", result, tt.want, tt.status)
+				}
+				if tt.want != RecipientDelivered && result.Message != "provider says no" {
+					t.Errorf("message = %q, want the response body", result.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestTransportNetworkErrorDefers(t *testing.T) {
+	server, _ := captureServer(t, http.StatusOK, "", nil)
+	endpoint := server.URL
+	server.Close()
+	
+	transport, err := newWebhookTransport(storage.TransportSettings{Endpoint: stringPtr(endpoint)}, "")
+	if err != nil {
+		t.Fatalf("newWebhookTransport: %v", err)
+	}
+	results, err := transport.Send(testEmail(), []string{"a@example.org"}, []byte(testMessage))
+	if err == nil || results[0].Status != RecipientDeferred {
+		t.Errorf("results %+v, err %v, want deferred", results, err)
+	}
+}
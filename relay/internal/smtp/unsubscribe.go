@@ -0,0 +1,36 @@
+package smtp
+
+import (
+	"log"
+	"strings"
+
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+	"github.com/Renespeare/mailpulse/relay/internal/tracking"
+)
+
+// addListUnsubscribe adds the project's List-Unsubscribe and RFC 8058
+// List-Unsubscribe-Post headers to an outgoing message. The one-click URL
+// names the recipient, so ForwardEmail sends such messages to one recipient each.
+func (f *EmailForwarder) addListUnsubscribe(message []byte, email *storage.Email, project *storage.Project, recipients []string) []byte {
+	if !project.ListUnsubscribe {
+		return message
+	}
+	if f.trackingURL == "" {
+		log.Printf("⚠️  List-Unsubscribe is enabled for project %s but TRACKING_BASE_URL is not set", project.ID)
+		return message
+	}
+	if len(recipients) != 1 {
+		log.Printf("⚠️  No List-Unsubscribe for email %s: one-click unsubscribe needs one recipient per message, not %d",
+			email.ID, len(recipients))
+		return message
+	}
+	
+	values := []string{"<" + tracking.UnsubscribeURL(f.trackingURL, email.ID, recipients[0]) + ">"}
+	if project.UnsubscribeEmail != nil && *project.UnsubscribeEmail != "" {
+		values = append(values, "<mailto:"+*project.UnsubscribeEmail+"?subject=unsubscribe>")
+	}
+	
+	headers := "List-Unsubscribe: " + strings.Join(values, ", ") + "\r\n" +
+		"List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n"
+	return append([]byte(headers), message...)
+}
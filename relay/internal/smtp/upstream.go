@@ -0,0 +1,362 @@
+package smtp
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/crypto"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+// Circuit breaker states for an upstream relay
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// UpstreamHealth describes the recent health of an upstream relay
+type UpstreamHealth struct {
+	State               string
+	ConsecutiveFailures int
+	LastError           string
+	LastFailureAt       *time.Time
+	LastSuccessAt       *time.Time
+	OpenUntil           *time.Time
+}
+
+// circuitBreaker stops sending to an upstream after repeated failures and
+// lets a single trial through once the cooldown has passed
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	health    map[string]*UpstreamHealth
+}
+
+// newCircuitBreaker creates a circuit breaker that opens after threshold
+// consecutive failures and stays open for cooldown
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		health:    make(map[string]*UpstreamHealth),
+	}
+}
+
+// newCircuitBreakerFromEnv reads UPSTREAM_FAILURE_THRESHOLD (default 3) and
+// UPSTREAM_COOLDOWN (default 1m)
+func newCircuitBreakerFromEnv() *circuitBreaker {
+	threshold := 3
+	if value := os.Getenv("UPSTREAM_FAILURE_THRESHOLD"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			threshold = parsed
+		}
+	}
+	
+	cooldown := time.Minute
+	if value := os.Getenv("UPSTREAM_COOLDOWN"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			cooldown = parsed
+		}
+	}
+	
+	return newCircuitBreaker(threshold, cooldown)
+}
+
+// entry returns the health record for an upstream, creating it if needed
+func (b *circuitBreaker) entry(id string) *UpstreamHealth {
+	h, ok := b.health[id]
+	if !ok {
+		h = &UpstreamHealth{State: CircuitClosed}
+		b.health[id] = h
+	}
+	return h
+}
+
+// allow reports whether an upstream may be tried now
+func (b *circuitBreaker) allow(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	
+	h := b.entry(id)
+	switch h.State {
+	case CircuitOpen:
+		if h.OpenUntil != nil && time.Now().Before(*h.OpenUntil) {
+			return false
+		}
+		// Cooldown over - let one trial through
+		h.State = CircuitHalfOpen
+		return true
+	case CircuitHalfOpen:
+		// A trial is already in flight
+		return false
+	default:
+		return true
+	}
+}
+
+// success closes the circuit for an upstream
+func (b *circuitBreaker) success(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	
+	now := time.Now()
+	h := b.entry(id)
+	h.State = CircuitClosed
+	h.ConsecutiveFailures = 0
+	h.OpenUntil = nil
+	h.LastSuccessAt = &now
+}
+
+// failure records a failed attempt and opens the circuit once the threshold
+// is reached (or immediately when a half-open trial fails)
+func (b *circuitBreaker) failure(id string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	
+	now := time.Now()
+	h := b.entry(id)
+	h.ConsecutiveFailures++
+	h.LastError = err.Error()
+	h.LastFailureAt = &now
+	
+	if h.State == CircuitHalfOpen || h.ConsecutiveFailures >= b.threshold {
+		openUntil := now.Add(b.cooldown)
+		h.State = CircuitOpen
+		h.OpenUntil = &openUntil
+		log.Printf("🔌 Circuit opened for upstream %s until %s: %v", id, openUntil.Format(time.RFC3339), err)
+	}
+}
+
+// snapshot returns a copy of an upstream's health
+func (b *circuitBreaker) snapshot(id string) UpstreamHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	
+	if h, ok := b.health[id]; ok {
+		return *h
+	}
+	return UpstreamHealth{State: CircuitClosed}
+}
+
+// UpstreamHealth returns the circuit breaker state of an upstream relay
+func (f *EmailForwarder) UpstreamHealth(upstreamID string) UpstreamHealth {
+	return f.breaker.snapshot(upstreamID)
+}
+
+// LegacyUpstreamID is the ID used for a project's single smtp_host setting
+func LegacyUpstreamID(projectID string) string {
+	return "project:" + projectID
+}
+
+// upstreamsFor returns the enabled upstream relays of a project. Projects
+// without any fall back to their smtp_host/smtp_port/smtp_user settings.
+func (f *EmailForwarder) upstreamsFor(project *storage.Project) ([]*storage.Upstream, error) {
+	configured, err := f.storage.ListUpstreams(project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upstream relays: %w", err)
+	}
+	
+	var upstreams []*storage.Upstream
+	for _, upstream := range configured {
+		if upstream.Enabled {
+			upstreams = append(upstreams, upstream)
+		}
+	}
+	if len(upstreams) > 0 {
+		return upstreams, nil
+	}
+	
+	// Check if project has SMTP configuration for real forwarding. XOAUTH2
+	// authenticates with an OAuth token instead of the SMTP password.
+	hasCredential := project.SMTPPasswordEnc != nil && *project.SMTPPasswordEnc != ""
+	if project.UpstreamAuth.Mechanism == storage.UpstreamAuthXOAUTH2 {
+		hasCredential = ValidateUpstreamAuth(project.UpstreamAuth) == nil
+	}
+	if project.SMTPHost != nil && *project.SMTPHost != "" && 
+	   project.SMTPUser != nil && *project.SMTPUser != "" && 
+	   hasCredential {
+		
+		smtpPort := 587 // default
+		if project.SMTPPort != nil && *project.SMTPPort > 0 {
+			smtpPort = *project.SMTPPort
+		}
+		
+		return []*storage.Upstream{{
+			ID:          LegacyUpstreamID(project.ID),
+			ProjectID:   project.ID,
+			Host:        *project.SMTPHost,
+			Port:        smtpPort,
+			Username:    project.SMTPUser,
+			PasswordEnc: project.SMTPPasswordEnc,
+			Weight:      1,
+			Enabled:     true,
+		}}, nil
+	}
+	
+	return nil, nil
+}
+
+// orderUpstreams sorts upstreams by priority and shuffles each priority
+// group so that upstreams with a higher weight tend to come first
+func orderUpstreams(upstreams []*storage.Upstream) []*storage.Upstream {
+	groups := map[int][]*storage.Upstream{}
+	var priorities []int
+	for _, upstream := range upstreams {
+		if _, ok := groups[upstream.Priority]; !ok {
+			priorities = append(priorities, upstream.Priority)
+		}
+		groups[upstream.Priority] = append(groups[upstream.Priority], upstream)
+	}
+	sort.Ints(priorities)
+	
+	ordered := make([]*storage.Upstream, 0, len(upstreams))
+	for _, priority := range priorities {
+		group := append([]*storage.Upstream(nil), groups[priority]...)
+		for len(group) > 0 {
+			total := 0
+			for _, upstream := range group {
+				total += upstreamWeight(upstream)
+			}
+			pick := rand.Intn(total)
+			for i, upstream := range group {
+				pick -= upstreamWeight(upstream)
+				if pick < 0 {
+					ordered = append(ordered, upstream)
+					group = append(group[:i], group[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	return ordered
+}
+
+// upstreamWeight returns an upstream's weight, treating non-positive weights as 1
+func upstreamWeight(upstream *storage.Upstream) int {
+	if upstream.Weight < 1 {
+		return 1
+	}
+	return upstream.Weight
+}
+
+// upstreamLabel identifies an upstream in logs and on the email record
+func upstreamLabel(upstream *storage.Upstream) string {
+	return fmt.Sprintf("%s:%d", upstream.Host, upstream.Port)
+}
+
+// relayWithFailover sends through the upstreams in order. Connection errors
+// and 4xx replies move the affected recipients on to the next upstream;
+// deliveries and 5xx bounces are final. When no upstream can be reached the
+// recipients are deferred for a later retry. The upstream that delivered the
+// message is recorded on the email.
+func (f *EmailForwarder) relayWithFailover(email *storage.Email, project *storage.Project, upstreams []*storage.Upstream, recipients []string, message []byte) ([]RecipientResult, error) {
+	pending := recipients
+	var final []RecipientResult
+	lastDeferred := map[string]RecipientResult{}
+	var lastErr, configErr error
+	deliveredVia := ""
+	var deliveredSession *upstreamSession
+	
+	for _, upstream := range orderUpstreams(upstreams) {
+		if len(pending) == 0 {
+			break
+		}
+		label := upstreamLabel(upstream)
+		
+		// Decrypt before asking the breaker, so a half-open trial is never
+		// taken without reporting its outcome
+		user, password := "", ""
+		if upstream.Username != nil {
+			user = *upstream.Username
+		}
+		if upstream.PasswordEnc != nil && *upstream.PasswordEnc != "" {
+			decrypted, err := crypto.DecryptSMTPPassword(*upstream.PasswordEnc)
+			if err != nil {
+				log.Printf("⚠️  Failed to decrypt password for upstream %s: %v", label, err)
+				configErr = fmt.Errorf("failed to decrypt SMTP password: %w", err)
+				continue
+			}
+			password = decrypted
+		}
+		
+		if !f.breaker.allow(upstream.ID) {
+			log.Printf("⏭️  Skipping upstream %s for email %s (circuit open)", label, email.ID)
+			continue
+		}
+		
+		results, session, err := f.realSMTPForwarding(email, project, pending, message, upstream.Host, upstream.Port, user, password)
+		if results == nil {
+			// Connection, TLS or authentication failure - try the next upstream
+			f.breaker.failure(upstream.ID, err)
+			lastErr = err
+			log.Printf("🔀 Upstream %s failed for email %s, failing over: %v", label, email.ID, err)
+			continue
+		}
+		
+		var retry []string
+		for _, result := range results {
+			if result.Status == RecipientDeferred {
+				retry = append(retry, result.Recipient)
+				lastDeferred[result.Recipient] = result
+				continue
+			}
+			if result.Status == RecipientDelivered {
+				deliveredVia = label
+				deliveredSession = session
+			}
+			final = append(final, result)
+		}
+		
+		if len(retry) == len(pending) {
+			// Nothing accepted or refused outright - treat as an upstream failure
+			f.breaker.failure(upstream.ID, fmt.Errorf("4xx reply: %s", lastDeferred[retry[0]].Message))
+			lastErr = resultsError(results)
+		} else {
+			f.breaker.success(upstream.ID)
+		}
+		if len(retry) > 0 {
+			log.Printf("🔀 Upstream %s deferred %d recipient(s) of email %s, failing over", label, len(retry), email.ID)
+		}
+		pending = retry
+	}
+	
+	if deliveredVia != "" {
+		if err := f.storage.SetEmailUpstream(email.ID, deliveredVia, deliveredSession.TLSVersion, deliveredSession.TLSCipher); err != nil {
+			log.Printf("⚠️  Failed to record upstream for email %s: %v", email.ID, err)
+		}
+	}
+	
+	if len(pending) > 0 {
+		if len(final) == 0 && len(lastDeferred) == 0 {
+			// No upstream gave an SMTP answer at all. Outages are retried;
+			// only a misconfigured upstream fails the delivery.
+			switch {
+			case lastErr != nil:
+				results := allRecipients(pending, RecipientDeferred, 451, lastErr.Error(), "")
+				return results, resultsError(results)
+			case configErr != nil:
+				return nil, configErr
+			default:
+				results := allRecipients(pending, RecipientDeferred, 451, "all upstream relays are unavailable (circuits open)", "")
+				return results, resultsError(results)
+			}
+		}
+		for _, rcpt := range pending {
+			if result, ok := lastDeferred[rcpt]; ok {
+				final = append(final, result)
+				continue
+			}
+			final = append(final, allRecipients([]string{rcpt}, RecipientFailed, 0, fmt.Sprint(lastErr), "")...)
+		}
+	}
+	
+	return final, resultsError(final)
+}
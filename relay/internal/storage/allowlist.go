@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"fmt"
+)
+
+// ListIPAllowlist retrieves the IP allowlist entries for a project
+func (s *PostgreSQLStorage) ListIPAllowlist(projectID string) ([]*IPAllowlistEntry, error) {
+	query := `
+		SELECT id, project_id, cidr, description, created_at
+		FROM project_ip_allowlist
+		WHERE project_id = $1
+		ORDER BY created_at ASC
+	`
+	
+	rows, err := s.db.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list IP allowlist: %w", err)
+	}
+	defer rows.Close()
+	
+	entries := []*IPAllowlistEntry{}
+	for rows.Next() {
+		entry := &IPAllowlistEntry{}
+		if err := rows.Scan(&entry.ID, &entry.ProjectID, &entry.CIDR, &entry.Description, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan IP allowlist entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	
+	return entries, nil
+}
+
+// AddIPAllowlistEntry adds an IP or CIDR range to a project's allowlist
+func (s *PostgreSQLStorage) AddIPAllowlistEntry(entry *IPAllowlistEntry) error {
+	query := `
+		INSERT INTO project_ip_allowlist (id, project_id, cidr, description, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	
+	_, err := s.db.Exec(query, entry.ID, entry.ProjectID, entry.CIDR, entry.Description, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add IP allowlist entry: %w", err)
+	}
+	
+	s.notifyProjectChanged(entry.ProjectID)
+	return nil
+}
+
+// DeleteIPAllowlistEntry removes an entry from a project's allowlist
+func (s *PostgreSQLStorage) DeleteIPAllowlistEntry(projectID, entryID string) error {
+	result, err := s.db.Exec(`DELETE FROM project_ip_allowlist WHERE id = $1 AND project_id = $2`, entryID, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete IP allowlist entry: %w", err)
+	}
+	
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("IP allowlist entry not found: %s", entryID)
+	}
+	
+	s.notifyProjectChanged(projectID)
+	return nil
+}
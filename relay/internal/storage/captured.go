@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"fmt"
+)
+
+// StoreCapturedMessage stores a message accepted by a sandbox project
+func (s *PostgreSQLStorage) StoreCapturedMessage(message *CapturedMessage) error {
+	query := `
+		INSERT INTO captured_messages (id, project_id, email_id, from_email, recipients, subject, raw, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	
+	_, err := s.db.Exec(query, message.ID, message.ProjectID, message.EmailID, message.From,
+		fmt.Sprintf("{%s}", joinStrings(message.Recipients, ",")),
+		message.Subject, message.Raw, message.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store captured message: %w", err)
+	}
+	
+	return nil
+}
+
+// ListCapturedMessages retrieves a project's captured messages, newest first,
+// without their raw content. A non-empty recipient only returns messages
+// captured for that address (case-insensitive).
+func (s *PostgreSQLStorage) ListCapturedMessages(projectID, recipient string, limit, offset int) ([]*CapturedMessage, int, error) {
+	where := `WHERE project_id = $1 AND ($2 = '' OR EXISTS (
+		SELECT 1 FROM unnest(recipients) AS r WHERE lower(r) = lower($2)))`
+	
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM captured_messages `+where, projectID, recipient).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count captured messages: %w", err)
+	}
+	
+	query := `
+		SELECT id, project_id, email_id, from_email, recipients, subject, octet_length(raw), created_at
+		FROM captured_messages ` + where + `
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	
+	rows, err := s.db.Query(query, projectID, recipient, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list captured messages: %w", err)
+	}
+	defer rows.Close()
+	
+	messages := []*CapturedMessage{}
+	for rows.Next() {
+		message := &CapturedMessage{}
+		var recipients string
+		err := rows.Scan(&message.ID, &message.ProjectID, &message.EmailID, &message.From,
+			&recipients, &message.Subject, &message.Size, &message.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan captured message: %w", err)
+		}
+		message.Recipients = parseArrayString(recipients)
+		messages = append(messages, message)
+	}
+	
+	return messages, total, nil
+}
+
+// GetCapturedMessage retrieves a captured message with its raw content
+func (s *PostgreSQLStorage) GetCapturedMessage(projectID, messageID string) (*CapturedMessage, error) {
+	query := `
+		SELECT id, project_id, email_id, from_email, recipients, subject, raw, created_at
+		FROM captured_messages
+		WHERE id = $1 AND project_id = $2
+	`
+	
+	message := &CapturedMessage{}
+	var recipients string
+	err := s.db.QueryRow(query, messageID, projectID).Scan(&message.ID, &message.ProjectID, &message.EmailID,
+		&message.From, &recipients, &message.Subject, &message.Raw, &message.CreatedAt)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, fmt.Errorf("captured message not found")
+		}
+		return nil, fmt.Errorf("failed to get captured message: %w", err)
+	}
+	
+	message.Recipients = parseArrayString(recipients)
+	message.Size = len(message.Raw)
+	return message, nil
+}
+
+// ClearCapturedMessages deletes all of a project's captured messages
+func (s *PostgreSQLStorage) ClearCapturedMessages(projectID string) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM captured_messages WHERE project_id = $1`, projectID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear captured messages: %w", err)
+	}
+	
+	deleted, _ := result.RowsAffected()
+	return deleted, nil
+}
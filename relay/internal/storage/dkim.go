@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"fmt"
+)
+
+// ListDKIMKeys retrieves the DKIM signing keys for a project, newest first
+func (s *PostgreSQLStorage) ListDKIMKeys(projectID string) ([]*DKIMKey, error) {
+	query := `
+		SELECT id, project_id, domain, selector, algorithm, private_key_enc, public_key, created_at
+		FROM dkim_keys
+		WHERE project_id = $1
+		ORDER BY created_at DESC
+	`
+	
+	rows, err := s.db.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DKIM keys: %w", err)
+	}
+	defer rows.Close()
+	
+	keys := []*DKIMKey{}
+	for rows.Next() {
+		key := &DKIMKey{}
+		err := rows.Scan(&key.ID, &key.ProjectID, &key.Domain, &key.Selector,
+			&key.Algorithm, &key.PrivateKeyEnc, &key.PublicKey, &key.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan DKIM key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	
+	return keys, nil
+}
+
+// AddDKIMKey stores a DKIM signing key for a project
+func (s *PostgreSQLStorage) AddDKIMKey(key *DKIMKey) error {
+	query := `
+		INSERT INTO dkim_keys (id, project_id, domain, selector, algorithm, private_key_enc, public_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	
+	_, err := s.db.Exec(query, key.ID, key.ProjectID, key.Domain, key.Selector,
+		key.Algorithm, key.PrivateKeyEnc, key.PublicKey, key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add DKIM key: %w", err)
+	}
+	
+	return nil
+}
+
+// DeleteDKIMKey removes a DKIM signing key from a project
+func (s *PostgreSQLStorage) DeleteDKIMKey(projectID, keyID string) error {
+	result, err := s.db.Exec(`DELETE FROM dkim_keys WHERE id = $1 AND project_id = $2`, keyID, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete DKIM key: %w", err)
+	}
+	
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("DKIM key not found: %s", keyID)
+	}
+	
+	return nil
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"fmt"
+)
+
+// senderDomainColumns lists the columns selected for a SenderDomain, in scanSenderDomain order
+const senderDomainColumns = `id, project_id, domain, verification_token, status, last_error, last_checked_at, verified_at, created_at`
+
+// scanSenderDomain scans a row selected with senderDomainColumns into a SenderDomain
+func scanSenderDomain(row rowScanner) (*SenderDomain, error) {
+	domain := &SenderDomain{}
+	err := row.Scan(
+		&domain.ID, &domain.ProjectID, &domain.Domain, &domain.VerificationToken, &domain.Status,
+		&domain.LastError, &domain.LastCheckedAt, &domain.VerifiedAt, &domain.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return domain, nil
+}
+
+// ListSenderDomains retrieves the sender domains claimed by a project
+func (s *PostgreSQLStorage) ListSenderDomains(projectID string) ([]*SenderDomain, error) {
+	query := `SELECT ` + senderDomainColumns + ` FROM project_domains WHERE project_id = $1 ORDER BY domain ASC`
+	return s.querySenderDomains(query, projectID)
+}
+
+// ListAllSenderDomains retrieves the sender domains of every non-deleted project
+func (s *PostgreSQLStorage) ListAllSenderDomains() ([]*SenderDomain, error) {
+	query := `
+		SELECT d.id, d.project_id, d.domain, d.verification_token, d.status, d.last_error,
+		       d.last_checked_at, d.verified_at, d.created_at
+		FROM project_domains d
+		INNER JOIN projects p ON d.project_id = p.id
+		WHERE p.status != 'deleted'
+		ORDER BY d.last_checked_at ASC NULLS FIRST
+	`
+	return s.querySenderDomains(query)
+}
+
+// querySenderDomains runs a sender domain query and scans every row
+func (s *PostgreSQLStorage) querySenderDomains(query string, args ...interface{}) ([]*SenderDomain, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sender domains: %w", err)
+	}
+	defer rows.Close()
+	
+	domains := []*SenderDomain{}
+	for rows.Next() {
+		domain, err := scanSenderDomain(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sender domain: %w", err)
+		}
+		domains = append(domains, domain)
+	}
+	
+	return domains, nil
+}
+
+// GetSenderDomain retrieves a single sender domain of a project
+func (s *PostgreSQLStorage) GetSenderDomain(projectID, domainID string) (*SenderDomain, error) {
+	query := `SELECT ` + senderDomainColumns + ` FROM project_domains WHERE id = $1 AND project_id = $2`
+	
+	domain, err := scanSenderDomain(s.db.QueryRow(query, domainID, projectID))
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, fmt.Errorf("sender domain not found: %s", domainID)
+		}
+		return nil, fmt.Errorf("failed to get sender domain: %w", err)
+	}
+	
+	return domain, nil
+}
+
+// AddSenderDomain claims a sender domain for a project
+func (s *PostgreSQLStorage) AddSenderDomain(domain *SenderDomain) error {
+	query := `
+		INSERT INTO project_domains (id, project_id, domain, verification_token, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	
+	_, err := s.db.Exec(query, domain.ID, domain.ProjectID, domain.Domain, domain.VerificationToken,
+		domain.Status, domain.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add sender domain: %w", err)
+	}
+	
+	s.notifyProjectChanged(domain.ProjectID)
+	return nil
+}
+
+// UpdateSenderDomainStatus records the outcome of a verification check
+func (s *PostgreSQLStorage) UpdateSenderDomainStatus(id string, status string, lastError *string) error {
+	query := `
+		UPDATE project_domains
+		SET status = $1, last_error = $2, last_checked_at = NOW(),
+		    verified_at = CASE WHEN $1 = 'verified' THEN COALESCE(verified_at, NOW()) ELSE NULL END
+		WHERE id = $3
+		RETURNING project_id
+	`
+	
+	var projectID string
+	if err := s.db.QueryRow(query, status, lastError, id).Scan(&projectID); err != nil {
+		return fmt.Errorf("failed to update sender domain status: %w", err)
+	}
+	
+	s.notifyProjectChanged(projectID)
+	return nil
+}
+
+// DeleteSenderDomain removes a sender domain from a project
+func (s *PostgreSQLStorage) DeleteSenderDomain(projectID, domainID string) error {
+	result, err := s.db.Exec(`DELETE FROM project_domains WHERE id = $1 AND project_id = $2`, domainID, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete sender domain: %w", err)
+	}
+	
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("sender domain not found: %s", domainID)
+	}
+	
+	s.notifyProjectChanged(projectID)
+	return nil
+}
@@ -2,27 +2,50 @@ package storage
 
 import (
 	"fmt"
+	"time"
 )
 
-// StoreEmail stores an email record in the database
+// StoreEmail stores an email record and its pending recipients in one transaction
 func (s *PostgreSQLStorage) StoreEmail(email *Email) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to store email: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+	
 	query := `
 		INSERT INTO emails (id, message_id, project_id, from_email, to_emails, subject, 
-		                   content_enc, size, status, error_msg, attempts, sent_at, metadata)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		                   content_enc, size, status, error_msg, attempts, sent_at, metadata, scheduled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 	
 	// Convert []string to pq.Array for PostgreSQL
-	_, err := s.db.Exec(query,
+	_, err = tx.Exec(query,
 		email.ID, email.MessageID, email.ProjectID, email.From, 
 		fmt.Sprintf("{%s}", joinStrings(email.To, ",")), // Simple array conversion
 		email.Subject, email.ContentEnc, email.Size, email.Status,
-		email.Error, email.Attempts, email.SentAt, nil) // metadata as nil for now
+		email.Error, email.Attempts, email.SentAt, nil, email.ScheduledAt) // metadata as nil for now
 	
 	if err != nil {
 		return fmt.Errorf("failed to store email: %w", err)
 	}
 	
+	// Track each recipient separately, starting as pending
+	for _, recipient := range email.To {
+		_, err := tx.Exec(`
+			INSERT INTO email_recipients (id, email_id, recipient, status, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, NOW(), NOW())
+			ON CONFLICT (email_id, recipient) DO NOTHING
+		`, recipientRowID(email.ID, recipient), email.ID, recipient, RecipientStatusPending)
+		if err != nil {
+			return fmt.Errorf("failed to store email recipient: %w", err)
+		}
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to store email: %w", err)
+	}
+	
 	return nil
 }
 
@@ -30,7 +53,8 @@ func (s *PostgreSQLStorage) StoreEmail(email *Email) error {
 func (s *PostgreSQLStorage) GetEmail(id string) (*Email, error) {
 	query := `
 		SELECT id, message_id, project_id, from_email, to_emails, subject,
-		       content_enc, size, status, error_msg, attempts, sent_at
+		       content_enc, size, status, error_msg, attempts, sent_at, upstream, tls_version, tls_cipher,
+		       opened_at, open_count, proxy_open_count, clicked_at, click_count, scheduled_at
 		FROM emails WHERE id = $1
 	`
 	
@@ -42,7 +66,8 @@ func (s *PostgreSQLStorage) GetEmail(id string) (*Email, error) {
 	err := row.Scan(
 		&email.ID, &email.MessageID, &email.ProjectID, &email.From,
 		&toEmails, &email.Subject, &email.ContentEnc, &email.Size,
-		&email.Status, &email.Error, &email.Attempts, &email.SentAt,
+		&email.Status, &email.Error, &email.Attempts, &email.SentAt, &email.Upstream, &email.TLSVersion, &email.TLSCipher,
+		&email.OpenedAt, &email.OpenCount, &email.ProxyOpenCount, &email.ClickedAt, &email.ClickCount, &email.ScheduledAt,
 	)
 	
 	if err != nil {
@@ -62,7 +87,8 @@ func (s *PostgreSQLStorage) GetEmail(id string) (*Email, error) {
 func (s *PostgreSQLStorage) ListEmails(projectID string, limit, offset int) ([]*Email, error) {
 	query := `
 		SELECT e.id, e.message_id, e.project_id, e.from_email, e.to_emails, e.subject, e.content_enc,
-		       e.size, e.status, e.error_msg, e.attempts, e.sent_at
+		       e.size, e.status, e.error_msg, e.attempts, e.sent_at, e.upstream, e.tls_version, e.tls_cipher,
+		       e.opened_at, e.open_count, e.proxy_open_count, e.clicked_at, e.click_count, e.scheduled_at
 		FROM emails e
 		INNER JOIN projects p ON e.project_id = p.id
 		WHERE e.project_id = $1 AND p.status != 'deleted'
@@ -84,7 +110,8 @@ func (s *PostgreSQLStorage) ListEmails(projectID string, limit, offset int) ([]*
 		err := rows.Scan(
 			&email.ID, &email.MessageID, &email.ProjectID, &email.From,
 			&toEmails, &email.Subject, &email.ContentEnc, &email.Size, &email.Status,
-			&email.Error, &email.Attempts, &email.SentAt,
+			&email.Error, &email.Attempts, &email.SentAt, &email.Upstream, &email.TLSVersion, &email.TLSCipher,
+			&email.OpenedAt, &email.OpenCount, &email.ProxyOpenCount, &email.ClickedAt, &email.ClickCount, &email.ScheduledAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan email: %w", err)
@@ -101,7 +128,8 @@ func (s *PostgreSQLStorage) ListEmails(projectID string, limit, offset int) ([]*
 func (s *PostgreSQLStorage) ListAllEmails(limit, offset int) ([]*Email, error) {
 	query := `
 		SELECT e.id, e.message_id, e.project_id, e.from_email, e.to_emails, e.subject, e.content_enc,
-		       e.size, e.status, e.error_msg, e.attempts, e.sent_at
+		       e.size, e.status, e.error_msg, e.attempts, e.sent_at, e.upstream, e.tls_version, e.tls_cipher,
+		       e.opened_at, e.open_count, e.proxy_open_count, e.clicked_at, e.click_count, e.scheduled_at
 		FROM emails e
 		INNER JOIN projects p ON e.project_id = p.id
 		WHERE p.status != 'deleted'
@@ -123,7 +151,8 @@ func (s *PostgreSQLStorage) ListAllEmails(limit, offset int) ([]*Email, error) {
 		err := rows.Scan(
 			&email.ID, &email.MessageID, &email.ProjectID, &email.From,
 			&toEmails, &email.Subject, &email.ContentEnc, &email.Size, &email.Status,
-			&email.Error, &email.Attempts, &email.SentAt,
+			&email.Error, &email.Attempts, &email.SentAt, &email.Upstream, &email.TLSVersion, &email.TLSCipher,
+			&email.OpenedAt, &email.OpenCount, &email.ProxyOpenCount, &email.ClickedAt, &email.ClickCount, &email.ScheduledAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan email: %w", err)
@@ -146,7 +175,8 @@ func (s *PostgreSQLStorage) SearchEmails(projectID string, searchQuery string, l
 		countQuery = `SELECT COUNT(*) FROM emails e INNER JOIN projects p ON e.project_id = p.id WHERE e.project_id = $1 AND p.status != 'deleted'`
 		emailQuery = `
 			SELECT e.id, e.message_id, e.project_id, e.from_email, e.to_emails, e.subject, e.content_enc,
-			       e.size, e.status, e.error_msg, e.attempts, e.sent_at
+			       e.size, e.status, e.error_msg, e.attempts, e.sent_at, e.upstream, e.tls_version, e.tls_cipher,
+			       e.opened_at, e.open_count, e.proxy_open_count, e.clicked_at, e.click_count, e.scheduled_at
 			FROM emails e
 			INNER JOIN projects p ON e.project_id = p.id
 			WHERE e.project_id = $1 AND p.status != 'deleted'
@@ -161,7 +191,8 @@ func (s *PostgreSQLStorage) SearchEmails(projectID string, searchQuery string, l
 		              AND (e.from_email ILIKE $2 OR e.subject ILIKE $2 OR array_to_string(e.to_emails, ',') ILIKE $2)`
 		emailQuery = `
 			SELECT e.id, e.message_id, e.project_id, e.from_email, e.to_emails, e.subject, e.content_enc,
-			       e.size, e.status, e.error_msg, e.attempts, e.sent_at
+			       e.size, e.status, e.error_msg, e.attempts, e.sent_at, e.upstream, e.tls_version, e.tls_cipher,
+			       e.opened_at, e.open_count, e.proxy_open_count, e.clicked_at, e.click_count, e.scheduled_at
 			FROM emails e
 			INNER JOIN projects p ON e.project_id = p.id
 			WHERE e.project_id = $1 AND p.status != 'deleted' 
@@ -193,7 +224,8 @@ func (s *PostgreSQLStorage) SearchEmails(projectID string, searchQuery string, l
 		err := rows.Scan(
 			&email.ID, &email.MessageID, &email.ProjectID, &email.From,
 			&toEmails, &email.Subject, &email.ContentEnc, &email.Size, &email.Status,
-			&email.Error, &email.Attempts, &email.SentAt,
+			&email.Error, &email.Attempts, &email.SentAt, &email.Upstream, &email.TLSVersion, &email.TLSCipher,
+			&email.OpenedAt, &email.OpenCount, &email.ProxyOpenCount, &email.ClickedAt, &email.ClickCount, &email.ScheduledAt,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan email: %w", err)
@@ -216,7 +248,8 @@ func (s *PostgreSQLStorage) SearchAllEmails(searchQuery string, limit, offset in
 		countQuery = `SELECT COUNT(*) FROM emails e INNER JOIN projects p ON e.project_id = p.id WHERE p.status != 'deleted'`
 		emailQuery = `
 			SELECT e.id, e.message_id, e.project_id, e.from_email, e.to_emails, e.subject, e.content_enc,
-			       e.size, e.status, e.error_msg, e.attempts, e.sent_at
+			       e.size, e.status, e.error_msg, e.attempts, e.sent_at, e.upstream, e.tls_version, e.tls_cipher,
+			       e.opened_at, e.open_count, e.proxy_open_count, e.clicked_at, e.click_count, e.scheduled_at
 			FROM emails e
 			INNER JOIN projects p ON e.project_id = p.id
 			WHERE p.status != 'deleted'
@@ -231,7 +264,8 @@ func (s *PostgreSQLStorage) SearchAllEmails(searchQuery string, limit, offset in
 		              AND (e.from_email ILIKE $1 OR e.subject ILIKE $1 OR array_to_string(e.to_emails, ',') ILIKE $1)`
 		emailQuery = `
 			SELECT e.id, e.message_id, e.project_id, e.from_email, e.to_emails, e.subject, e.content_enc,
-			       e.size, e.status, e.error_msg, e.attempts, e.sent_at
+			       e.size, e.status, e.error_msg, e.attempts, e.sent_at, e.upstream, e.tls_version, e.tls_cipher,
+			       e.opened_at, e.open_count, e.proxy_open_count, e.clicked_at, e.click_count, e.scheduled_at
 			FROM emails e
 			INNER JOIN projects p ON e.project_id = p.id
 			WHERE p.status != 'deleted' 
@@ -270,7 +304,8 @@ func (s *PostgreSQLStorage) SearchAllEmails(searchQuery string, limit, offset in
 		err := rows.Scan(
 			&email.ID, &email.MessageID, &email.ProjectID, &email.From,
 			&toEmails, &email.Subject, &email.ContentEnc, &email.Size, &email.Status,
-			&email.Error, &email.Attempts, &email.SentAt,
+			&email.Error, &email.Attempts, &email.SentAt, &email.Upstream, &email.TLSVersion, &email.TLSCipher,
+			&email.OpenedAt, &email.OpenCount, &email.ProxyOpenCount, &email.ClickedAt, &email.ClickCount, &email.ScheduledAt,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan email: %w", err)
@@ -318,7 +353,8 @@ func (s *PostgreSQLStorage) SearchEmailsWithStatus(projectID string, searchQuery
 	// Build email query
 	emailQuery = fmt.Sprintf(`
 		SELECT e.id, e.message_id, e.project_id, e.from_email, e.to_emails, e.subject, e.content_enc,
-		       e.size, e.status, e.error_msg, e.attempts, e.sent_at
+		       e.size, e.status, e.error_msg, e.attempts, e.sent_at, e.upstream, e.tls_version, e.tls_cipher,
+		       e.opened_at, e.open_count, e.proxy_open_count, e.clicked_at, e.click_count, e.scheduled_at
 		FROM emails e
 		INNER JOIN projects p ON e.project_id = p.id
 		WHERE %s%s%s
@@ -351,7 +387,8 @@ func (s *PostgreSQLStorage) SearchEmailsWithStatus(projectID string, searchQuery
 		err := rows.Scan(
 			&email.ID, &email.MessageID, &email.ProjectID, &email.From,
 			&toEmails, &email.Subject, &email.ContentEnc, &email.Size, &email.Status,
-			&email.Error, &email.Attempts, &email.SentAt,
+			&email.Error, &email.Attempts, &email.SentAt, &email.Upstream, &email.TLSVersion, &email.TLSCipher,
+			&email.OpenedAt, &email.OpenCount, &email.ProxyOpenCount, &email.ClickedAt, &email.ClickCount, &email.ScheduledAt,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan email: %w", err)
@@ -397,7 +434,8 @@ func (s *PostgreSQLStorage) SearchAllEmailsWithStatus(searchQuery string, status
 	// Build email query
 	emailQuery = fmt.Sprintf(`
 		SELECT e.id, e.message_id, e.project_id, e.from_email, e.to_emails, e.subject, e.content_enc,
-		       e.size, e.status, e.error_msg, e.attempts, e.sent_at
+		       e.size, e.status, e.error_msg, e.attempts, e.sent_at, e.upstream, e.tls_version, e.tls_cipher,
+		       e.opened_at, e.open_count, e.proxy_open_count, e.clicked_at, e.click_count, e.scheduled_at
 		FROM emails e
 		INNER JOIN projects p ON e.project_id = p.id
 		WHERE %s%s%s
@@ -436,7 +474,8 @@ func (s *PostgreSQLStorage) SearchAllEmailsWithStatus(searchQuery string, status
 		err := rows.Scan(
 			&email.ID, &email.MessageID, &email.ProjectID, &email.From,
 			&toEmails, &email.Subject, &email.ContentEnc, &email.Size, &email.Status,
-			&email.Error, &email.Attempts, &email.SentAt,
+			&email.Error, &email.Attempts, &email.SentAt, &email.Upstream, &email.TLSVersion, &email.TLSCipher,
+			&email.OpenedAt, &email.OpenCount, &email.ProxyOpenCount, &email.ClickedAt, &email.ClickCount, &email.ScheduledAt,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan email: %w", err)
@@ -459,4 +498,329 @@ func (s *PostgreSQLStorage) UpdateEmailStatus(id string, status string, errorMsg
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// SetEmailUpstream records which upstream relay delivered an email and the
+// TLS version and cipher negotiated with it (nil for plaintext)
+func (s *PostgreSQLStorage) SetEmailUpstream(id string, upstream string, tlsVersion, tlsCipher *string) error {
+	query := `UPDATE emails SET upstream = $1, tls_version = $2, tls_cipher = $3 WHERE id = $4`
+	
+	_, err := s.db.Exec(query, upstream, tlsVersion, tlsCipher, id)
+	if err != nil {
+		return fmt.Errorf("failed to set email upstream: %w", err)
+	}
+	
+	return nil
+}
+
+// ListEmailRecipients retrieves the per-recipient delivery status of an email.
+// Emails stored before recipients were tracked have no rows.
+func (s *PostgreSQLStorage) ListEmailRecipients(emailID string) ([]*EmailRecipient, error) {
+	query := `
+		SELECT id, email_id, recipient, status, response_code, response_text, attempts,
+		       created_at, updated_at, delivered_at
+		FROM email_recipients
+		WHERE email_id = $1
+		ORDER BY created_at ASC, recipient ASC
+	`
+	
+	rows, err := s.db.Query(query, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email recipients: %w", err)
+	}
+	defer rows.Close()
+	
+	recipients := []*EmailRecipient{}
+	for rows.Next() {
+		r := &EmailRecipient{}
+		err := rows.Scan(&r.ID, &r.EmailID, &r.Recipient, &r.Status, &r.ResponseCode, &r.ResponseText,
+			&r.Attempts, &r.CreatedAt, &r.UpdatedAt, &r.DeliveredAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email recipient: %w", err)
+		}
+		recipients = append(recipients, r)
+	}
+	
+	return recipients, nil
+}
+
+// ResetUndeliveredRecipients marks every recipient of an email that has not
+// been delivered as pending, so the queue sends to them again
+func (s *PostgreSQLStorage) ResetUndeliveredRecipients(emailID string) error {
+	query := `UPDATE email_recipients SET status = 'pending', updated_at = NOW() WHERE email_id = $1 AND status != 'delivered'`
+	
+	if _, err := s.db.Exec(query, emailID); err != nil {
+		return fmt.Errorf("failed to reset email recipients: %w", err)
+	}
+	
+	return nil
+}
+
+// CountQueuedRecipientsByDomain counts the pending recipients of queued
+// emails per recipient domain
+func (s *PostgreSQLStorage) CountQueuedRecipientsByDomain() (map[string]int, error) {
+	query := `
+		SELECT LOWER(SPLIT_PART(r.recipient, '@', 2)) AS domain, COUNT(*)
+		FROM email_recipients r
+		JOIN emails e ON e.id = r.email_id
+		WHERE e.status = 'queued' AND r.status = 'pending'
+		GROUP BY domain
+	`
+	
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count queued recipients: %w", err)
+	}
+	defer rows.Close()
+	
+	counts := make(map[string]int)
+	for rows.Next() {
+		var domain string
+		var count int
+		if err := rows.Scan(&domain, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan queued recipient count: %w", err)
+		}
+		counts[domain] = count
+	}
+	
+	return counts, nil
+}
+
+// recipientRowID builds the email_recipients ID of one recipient of an email
+func recipientRowID(emailID, recipient string) string {
+	return emailID + "-" + recipient
+}
+
+// UpdateRecipientStatus records a delivery attempt for one recipient of an email
+func (s *PostgreSQLStorage) UpdateRecipientStatus(emailID, recipient, status string, responseCode *int, responseText *string) error {
+	query := `
+		INSERT INTO email_recipients (id, email_id, recipient, status, response_code, response_text,
+		                              attempts, created_at, updated_at, delivered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 1, NOW(), NOW(), $7)
+		ON CONFLICT (email_id, recipient) DO UPDATE
+		SET status = EXCLUDED.status, response_code = EXCLUDED.response_code,
+		    response_text = EXCLUDED.response_text, attempts = email_recipients.attempts + 1,
+		    updated_at = NOW(), delivered_at = COALESCE(email_recipients.delivered_at, EXCLUDED.delivered_at)
+	`
+	
+	var deliveredAt *time.Time
+	if status == RecipientStatusDelivered {
+		now := time.Now()
+		deliveredAt = &now
+	}
+	
+	_, err := s.db.Exec(query, recipientRowID(emailID, recipient), emailID, recipient, status, responseCode, responseText, deliveredAt)
+	if err != nil {
+		return fmt.Errorf("failed to update recipient status: %w", err)
+	}
+	
+	return nil
+}
+
+// ListDueScheduledEmails retrieves scheduled emails whose send time has come, oldest first
+func (s *PostgreSQLStorage) ListDueScheduledEmails(limit int) ([]*Email, error) {
+	query := `
+		SELECT id, message_id, project_id, from_email, to_emails, subject,
+		       content_enc, size, status, error_msg, attempts, sent_at, upstream, tls_version, tls_cipher,
+		       opened_at, open_count, proxy_open_count, clicked_at, click_count, scheduled_at
+		FROM emails
+		WHERE status = 'scheduled' AND scheduled_at <= NOW()
+		ORDER BY scheduled_at ASC
+		LIMIT $1
+	`
+	
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due scheduled emails: %w", err)
+	}
+	defer rows.Close()
+	
+	var emails []*Email
+	for rows.Next() {
+		email := &Email{}
+		var toEmails string
+		
+		err := rows.Scan(
+			&email.ID, &email.MessageID, &email.ProjectID, &email.From,
+			&toEmails, &email.Subject, &email.ContentEnc, &email.Size, &email.Status,
+			&email.Error, &email.Attempts, &email.SentAt, &email.Upstream, &email.TLSVersion, &email.TLSCipher,
+			&email.OpenedAt, &email.OpenCount, &email.ProxyOpenCount, &email.ClickedAt, &email.ClickCount, &email.ScheduledAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+		
+		email.To = parseArrayString(toEmails)
+		emails = append(emails, email)
+	}
+	
+	return emails, nil
+}
+
+// ReleaseScheduledEmail marks a scheduled email as processed and sent now, so
+// it counts towards quotas from here on. It returns false when the email is
+// no longer scheduled (cancelled, or released by another instance).
+func (s *PostgreSQLStorage) ReleaseScheduledEmail(id string) (bool, error) {
+	query := `UPDATE emails SET status = 'processed', sent_at = NOW() WHERE id = $1 AND status = 'scheduled'`
+	
+	result, err := s.db.Exec(query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to release scheduled email: %w", err)
+	}
+	
+	released, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to release scheduled email: %w", err)
+	}
+	return released == 1, nil
+}
+
+// RescheduleEmail changes when a scheduled email is sent. It returns false
+// when the email is not scheduled.
+func (s *PostgreSQLStorage) RescheduleEmail(id string, sendAt time.Time) (bool, error) {
+	query := `UPDATE emails SET scheduled_at = $2 WHERE id = $1 AND status = 'scheduled'`
+	
+	result, err := s.db.Exec(query, id, sendAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to reschedule email: %w", err)
+	}
+	
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to reschedule email: %w", err)
+	}
+	return updated == 1, nil
+}
+
+// ListQueuedEmails retrieves queued emails of projects whose delivery is not
+// paused, oldest first
+func (s *PostgreSQLStorage) ListQueuedEmails(limit int) ([]*Email, error) {
+	query := `
+		SELECT e.id, e.message_id, e.project_id, e.from_email, e.to_emails, e.subject, e.content_enc,
+		       e.size, e.status, e.error_msg, e.attempts, e.sent_at, e.upstream, e.tls_version, e.tls_cipher,
+		       e.opened_at, e.open_count, e.proxy_open_count, e.clicked_at, e.click_count, e.scheduled_at
+		FROM emails e
+		JOIN projects p ON p.id = e.project_id
+		WHERE e.status = 'queued' AND NOT p.delivery_paused
+		ORDER BY e.sent_at ASC
+		LIMIT $1
+	`
+	
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queued emails: %w", err)
+	}
+	defer rows.Close()
+	
+	var emails []*Email
+	for rows.Next() {
+		email := &Email{}
+		var toEmails string
+		
+		err := rows.Scan(
+			&email.ID, &email.MessageID, &email.ProjectID, &email.From,
+			&toEmails, &email.Subject, &email.ContentEnc, &email.Size, &email.Status,
+			&email.Error, &email.Attempts, &email.SentAt, &email.Upstream, &email.TLSVersion, &email.TLSCipher,
+			&email.OpenedAt, &email.OpenCount, &email.ProxyOpenCount, &email.ClickedAt, &email.ClickCount, &email.ScheduledAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+		
+		email.To = parseArrayString(toEmails)
+		emails = append(emails, email)
+	}
+	
+	return emails, nil
+}
+
+// ClaimQueuedEmail marks a queued email as processed before it is forwarded.
+// It returns false when the email is no longer queued (cancelled, or claimed
+// by another instance).
+func (s *PostgreSQLStorage) ClaimQueuedEmail(id string) (bool, error) {
+	query := `UPDATE emails SET status = 'processed' WHERE id = $1 AND status = 'queued'`
+	
+	result, err := s.db.Exec(query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim queued email: %w", err)
+	}
+	
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim queued email: %w", err)
+	}
+	return claimed == 1, nil
+}
+
+// RequeueEmail queues a failed or deferred email for another delivery
+// attempt to its undelivered recipients. It returns false when the email is
+// in any other status.
+func (s *PostgreSQLStorage) RequeueEmail(id string) (bool, error) {
+	query := `UPDATE emails SET status = 'queued', error_msg = NULL, attempts = attempts + 1 WHERE id = $1 AND status IN ('failed', 'deferred')`
+	
+	result, err := s.db.Exec(query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to requeue email: %w", err)
+	}
+	
+	requeued, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to requeue email: %w", err)
+	}
+	if requeued != 1 {
+		return false, nil
+	}
+	
+	if err := s.ResetUndeliveredRecipients(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CancelEmail cancels a queued or scheduled email. It returns false when the
+// email is in any other status.
+func (s *PostgreSQLStorage) CancelEmail(id string) (bool, error) {
+	query := `UPDATE emails SET status = 'cancelled', error_msg = 'Cancelled before sending' WHERE id = $1 AND status IN ('queued', 'scheduled')`
+	
+	result, err := s.db.Exec(query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel email: %w", err)
+	}
+	
+	cancelled, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel email: %w", err)
+	}
+	return cancelled == 1, nil
+}
+
+// ListEmailIDs returns the IDs of every email matching filter, oldest first
+func (s *PostgreSQLStorage) ListEmailIDs(filter EmailFilter) ([]string, error) {
+	query := `
+		SELECT id FROM emails
+		WHERE ($1 = '' OR project_id = $1)
+		  AND (cardinality($2::text[]) = 0 OR status = ANY($2::text[]))
+		  AND ($3::timestamptz IS NULL OR sent_at >= $3)
+		  AND ($4::timestamptz IS NULL OR sent_at < $4)
+		  AND ($5 = '' OR POSITION(LOWER($5) IN LOWER(COALESCE(error_msg, ''))) > 0)
+		ORDER BY sent_at ASC
+	`
+	
+	rows, err := s.db.Query(query, filter.ProjectID, fmt.Sprintf("{%s}", joinStrings(filter.Statuses, ",")),
+		filter.Since, filter.Until, filter.ErrorContains)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list emails: %w", err)
+	}
+	defer rows.Close()
+	
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	
+	return ids, nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// jobColumns lists the columns selected for a Job, in scanJob order
+const jobColumns = `id, project_id, type, filter_statuses, filter_since, filter_until, filter_error,
+		       status, total, processed, succeeded, error_msg, created_at, updated_at, finished_at`
+
+// scanJob scans a row selected with jobColumns into a Job
+func scanJob(row rowScanner) (*Job, error) {
+	job := &Job{}
+	var statuses string
+	err := row.Scan(&job.ID, &job.ProjectID, &job.Type, &statuses, &job.Filter.Since, &job.Filter.Until,
+		&job.Filter.ErrorContains, &job.Status, &job.Total, &job.Processed, &job.Succeeded, &job.Error,
+		&job.CreatedAt, &job.UpdatedAt, &job.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	
+	job.Filter.Statuses = parseArrayString(statuses)
+	if job.ProjectID != nil {
+		job.Filter.ProjectID = *job.ProjectID
+	}
+	return job, nil
+}
+
+// CreateJob records a new background job
+func (s *PostgreSQLStorage) CreateJob(job *Job) error {
+	query := `
+		INSERT INTO jobs (id, project_id, type, filter_statuses, filter_since, filter_until, filter_error,
+		                  status, total, processed, succeeded, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $12)
+	`
+	
+	_, err := s.db.Exec(query, job.ID, job.ProjectID, job.Type, fmt.Sprintf("{%s}", joinStrings(job.Filter.Statuses, ",")),
+		job.Filter.Since, job.Filter.Until, job.Filter.ErrorContains,
+		job.Status, job.Total, job.Processed, job.Succeeded, job.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	
+	return nil
+}
+
+// GetJob retrieves a background job by ID
+func (s *PostgreSQLStorage) GetJob(id string) (*Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1`
+	
+	job, err := scanJob(s.db.QueryRow(query, id))
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, fmt.Errorf("job not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	
+	return job, nil
+}
+
+// ListJobs retrieves background jobs, newest first, optionally only those of
+// one project
+func (s *PostgreSQLStorage) ListJobs(projectID string, limit, offset int) ([]*Job, int, error) {
+	where := `WHERE ($1 = '' OR project_id = $1)`
+	
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM jobs `+where, projectID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	
+	query := `
+		SELECT ` + jobColumns + `
+		FROM jobs ` + where + `
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	
+	rows, err := s.db.Query(query, projectID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+	
+	jobs := []*Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	
+	return jobs, total, nil
+}
+
+// UpdateJobProgress records how far a running job has got
+func (s *PostgreSQLStorage) UpdateJobProgress(id string, total, processed, succeeded int) error {
+	query := `
+		UPDATE jobs SET total = $2, processed = $3, succeeded = $4, updated_at = NOW()
+		WHERE id = $1 AND status = 'running'
+	`
+	
+	if _, err := s.db.Exec(query, id, total, processed, succeeded); err != nil {
+		return fmt.Errorf("failed to update job progress: %w", err)
+	}
+	
+	return nil
+}
+
+// FinishJob marks a running job as completed or failed
+func (s *PostgreSQLStorage) FinishJob(id string, status string, errorMsg *string) error {
+	query := `
+		UPDATE jobs SET status = $2, error_msg = $3, updated_at = NOW(), finished_at = NOW()
+		WHERE id = $1 AND status = 'running'
+	`
+	
+	if _, err := s.db.Exec(query, id, status, errorMsg); err != nil {
+		return fmt.Errorf("failed to finish job: %w", err)
+	}
+	
+	return nil
+}
+
+// FailStaleJobs fails running jobs whose progress has not been updated for
+// staleAfter, e.g. because the relay running them was restarted
+func (s *PostgreSQLStorage) FailStaleJobs(staleAfter time.Duration, reason string) (int64, error) {
+	query := `
+		UPDATE jobs SET status = 'failed', error_msg = $2, updated_at = NOW(), finished_at = NOW()
+		WHERE status = 'running' AND updated_at < $1
+	`
+	
+	result, err := s.db.Exec(query, time.Now().Add(-staleAfter), reason)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail stale jobs: %w", err)
+	}
+	
+	failed, _ := result.RowsAffected()
+	return failed, nil
+}
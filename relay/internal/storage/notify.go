@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ProjectChangesChannel is the Postgres NOTIFY channel carrying changed project IDs
+const ProjectChangesChannel = "mailpulse_project_changes"
+
+// notifyProjectChanged tells every relay replica that a project changed
+func (s *PostgreSQLStorage) notifyProjectChanged(projectID string) {
+	if _, err := s.db.Exec(`SELECT pg_notify($1, $2)`, ProjectChangesChannel, projectID); err != nil {
+		log.Printf("⚠️  Failed to notify project change for %s: %v", projectID, err)
+	}
+}
+
+// ListenProjectChanges subscribes to project change notifications.
+// The returned channel receives the ID of each changed project, or an empty
+// string after a reconnect when notifications may have been missed.
+func (s *PostgreSQLStorage) ListenProjectChanges() (<-chan string, error) {
+	listener := pq.NewListener(s.databaseURL, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("⚠️  Project change listener: %v", err)
+		}
+	})
+	
+	if err := listener.Listen(ProjectChangesChannel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen for project changes: %w", err)
+	}
+	
+	changes := make(chan string, 64)
+	go func() {
+		defer close(changes)
+		for {
+			select {
+			case n, ok := <-listener.Notify:
+				if !ok {
+					return
+				}
+				if n == nil {
+					// Connection was re-established; anything may have changed
+					changes <- ""
+					continue
+				}
+				changes <- n.Extra
+			case <-time.After(90 * time.Second):
+				go listener.Ping()
+			}
+		}
+	}()
+	
+	return changes, nil
+}
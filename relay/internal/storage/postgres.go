@@ -3,14 +3,18 @@ package storage
 import (
 	"database/sql"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
+	"github.com/Renespeare/mailpulse/relay/internal/crypto"
 	_ "github.com/lib/pq"
 )
 
 // PostgreSQLStorage implements Storage interface with PostgreSQL
 type PostgreSQLStorage struct {
-	db *sql.DB
+	db          *sql.DB
+	databaseURL string
 }
 
 // NewPostgreSQLStorage creates a new PostgreSQL storage instance
@@ -30,7 +34,7 @@ func NewPostgreSQLStorage(databaseURL string) (*PostgreSQLStorage, error) {
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(time.Hour)
 	
-	storage := &PostgreSQLStorage{db: db}
+	storage := &PostgreSQLStorage{db: db, databaseURL: databaseURL}
 	
 	// Initialize tables if needed
 	if err := storage.initTables(); err != nil {
@@ -64,10 +68,95 @@ func (s *PostgreSQLStorage) initTables() error {
 			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
 			last_used_at TIMESTAMP WITH TIME ZONE
 		)`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS api_key_digest VARCHAR(64)`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS require_ip_allow BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS delivery_mode VARCHAR(20) NOT NULL DEFAULT 'relay'`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS upstream_tls_mode VARCHAR(20) NOT NULL DEFAULT 'opportunistic'`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS upstream_tls_min_version VARCHAR(10) NOT NULL DEFAULT ''`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS upstream_tls_ca_bundle TEXT`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS upstream_tls_server_name VARCHAR(255)`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS upstream_auth_mechanism VARCHAR(20) NOT NULL DEFAULT 'plain'`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS oauth_token_url TEXT`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS oauth_client_id VARCHAR(255)`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS oauth_client_secret_enc TEXT`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS oauth_refresh_token_enc TEXT`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS oauth_scope TEXT`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS transport_endpoint TEXT`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS transport_region VARCHAR(50)`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS transport_access_key_id VARCHAR(255)`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS transport_secret_enc TEXT`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS suppression_mode VARCHAR(10) NOT NULL DEFAULT 'reject'`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS track_opens BOOLEAN NOT NULL DEFAULT false`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS track_clicks BOOLEAN NOT NULL DEFAULT false`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS list_unsubscribe BOOLEAN NOT NULL DEFAULT false`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS unsubscribe_email VARCHAR(320)`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS delivery_paused BOOLEAN NOT NULL DEFAULT false`,
 		`CREATE INDEX IF NOT EXISTS idx_projects_api_key_enc ON projects(api_key_enc)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_api_key_digest ON projects(api_key_digest)`,
 		`CREATE INDEX IF NOT EXISTS idx_projects_status ON projects(status)`,
 		`CREATE INDEX IF NOT EXISTS idx_projects_user_id ON projects(user_id)`,
 		
+		`CREATE TABLE IF NOT EXISTS project_ip_allowlist (
+			id VARCHAR(255) PRIMARY KEY,
+			project_id VARCHAR(255) NOT NULL,
+			cidr VARCHAR(64) NOT NULL,
+			description TEXT DEFAULT '',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			UNIQUE (project_id, cidr)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_project_ip_allowlist_project_id ON project_ip_allowlist(project_id)`,
+		
+		`CREATE TABLE IF NOT EXISTS project_sender_rules (
+			id VARCHAR(255) PRIMARY KEY,
+			project_id VARCHAR(255) NOT NULL,
+			pattern VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			UNIQUE (project_id, pattern)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_project_sender_rules_project_id ON project_sender_rules(project_id)`,
+		
+		`CREATE TABLE IF NOT EXISTS project_domains (
+			id VARCHAR(255) PRIMARY KEY,
+			project_id VARCHAR(255) NOT NULL,
+			domain VARCHAR(255) NOT NULL,
+			verification_token VARCHAR(255) NOT NULL,
+			status VARCHAR(50) NOT NULL DEFAULT 'pending',
+			last_error TEXT,
+			last_checked_at TIMESTAMP WITH TIME ZONE,
+			verified_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			UNIQUE (project_id, domain)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_project_domains_project_id ON project_domains(project_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_project_domains_status ON project_domains(status)`,
+		
+		`CREATE TABLE IF NOT EXISTS project_upstreams (
+			id VARCHAR(255) PRIMARY KEY,
+			project_id VARCHAR(255) NOT NULL,
+			host VARCHAR(255) NOT NULL,
+			port INTEGER NOT NULL DEFAULT 587,
+			username VARCHAR(255),
+			password_enc TEXT,
+			priority INTEGER NOT NULL DEFAULT 0,
+			weight INTEGER NOT NULL DEFAULT 1,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_project_upstreams_project_id ON project_upstreams(project_id)`,
+		
+		`CREATE TABLE IF NOT EXISTS dkim_keys (
+			id VARCHAR(255) PRIMARY KEY,
+			project_id VARCHAR(255) NOT NULL,
+			domain VARCHAR(255) NOT NULL,
+			selector VARCHAR(63) NOT NULL,
+			algorithm VARCHAR(50) NOT NULL,
+			private_key_enc TEXT NOT NULL,
+			public_key TEXT NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			UNIQUE (project_id, domain, selector)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_dkim_keys_project_id ON dkim_keys(project_id)`,
+		
 		`CREATE TABLE IF NOT EXISTS emails (
 			id VARCHAR(255) PRIMARY KEY,
 			message_id VARCHAR(255) UNIQUE NOT NULL,
@@ -89,6 +178,142 @@ func (s *PostgreSQLStorage) initTables() error {
 		`CREATE INDEX IF NOT EXISTS idx_emails_project_id ON emails(project_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_emails_sent_at ON emails(sent_at)`,
 		`CREATE INDEX IF NOT EXISTS idx_emails_status ON emails(status)`,
+		`ALTER TABLE emails ADD COLUMN IF NOT EXISTS upstream VARCHAR(255)`,
+		`ALTER TABLE emails ADD COLUMN IF NOT EXISTS tls_version VARCHAR(20)`,
+		`ALTER TABLE emails ADD COLUMN IF NOT EXISTS tls_cipher VARCHAR(100)`,
+		`ALTER TABLE emails ADD COLUMN IF NOT EXISTS open_count INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE emails ADD COLUMN IF NOT EXISTS proxy_open_count INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE emails ADD COLUMN IF NOT EXISTS click_count INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE emails ADD COLUMN IF NOT EXISTS scheduled_at TIMESTAMP WITH TIME ZONE`,
+		`CREATE INDEX IF NOT EXISTS idx_emails_scheduled ON emails(scheduled_at) WHERE status = 'scheduled'`,
+		
+		`CREATE TABLE IF NOT EXISTS email_recipients (
+			id VARCHAR(255) PRIMARY KEY,
+			email_id VARCHAR(255) NOT NULL REFERENCES emails(id) ON DELETE CASCADE,
+			recipient VARCHAR(320) NOT NULL,
+			status VARCHAR(50) NOT NULL DEFAULT 'pending',
+			response_code INTEGER,
+			response_text TEXT,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			delivered_at TIMESTAMP WITH TIME ZONE,
+			UNIQUE (email_id, recipient)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_email_recipients_email_id ON email_recipients(email_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_email_recipients_status ON email_recipients(status)`,
+		
+		`CREATE TABLE IF NOT EXISTS email_opens (
+			id VARCHAR(255) PRIMARY KEY,
+			email_id VARCHAR(255) NOT NULL REFERENCES emails(id) ON DELETE CASCADE,
+			project_id VARCHAR(255) NOT NULL,
+			user_agent TEXT NOT NULL DEFAULT '',
+			ip_address VARCHAR(45) NOT NULL DEFAULT '',
+			proxy VARCHAR(50) NOT NULL DEFAULT '',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_email_opens_email_id ON email_opens(email_id, created_at)`,
+		
+		`CREATE TABLE IF NOT EXISTS email_clicks (
+			id VARCHAR(255) PRIMARY KEY,
+			email_id VARCHAR(255) NOT NULL REFERENCES emails(id) ON DELETE CASCADE,
+			project_id VARCHAR(255) NOT NULL,
+			url TEXT NOT NULL,
+			user_agent TEXT NOT NULL DEFAULT '',
+			ip_address VARCHAR(45) NOT NULL DEFAULT '',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_email_clicks_email_id ON email_clicks(email_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_email_clicks_project_id ON email_clicks(project_id, url)`,
+		
+		`CREATE TABLE IF NOT EXISTS suppressions (
+			id VARCHAR(255) PRIMARY KEY,
+			project_id VARCHAR(255) NOT NULL,
+			address VARCHAR(320) NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			source VARCHAR(20) NOT NULL,
+			email_id VARCHAR(255),
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			UNIQUE (project_id, address)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_suppressions_project_id ON suppressions(project_id, created_at)`,
+		
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id VARCHAR(255) PRIMARY KEY,
+			project_id VARCHAR(255) NOT NULL,
+			url TEXT NOT NULL,
+			secret_enc TEXT NOT NULL,
+			events TEXT[] NOT NULL DEFAULT '{}',
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			consecutive_failures INTEGER NOT NULL DEFAULT 0,
+			last_success_at TIMESTAMP WITH TIME ZONE,
+			last_failure_at TIMESTAMP WITH TIME ZONE,
+			last_error TEXT,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhooks_project_id ON webhooks(project_id)`,
+		
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id VARCHAR(255) PRIMARY KEY,
+			webhook_id VARCHAR(255) NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+			project_id VARCHAR(255) NOT NULL,
+			event_type VARCHAR(50) NOT NULL,
+			email_id VARCHAR(255),
+			payload BYTEA NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_code INTEGER,
+			last_error TEXT,
+			next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			delivered_at TIMESTAMP WITH TIME ZONE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at)`,
+		
+		`CREATE TABLE IF NOT EXISTS captured_messages (
+			id VARCHAR(255) PRIMARY KEY,
+			project_id VARCHAR(255) NOT NULL,
+			email_id VARCHAR(255) NOT NULL,
+			from_email VARCHAR(255) NOT NULL,
+			recipients TEXT[] NOT NULL,
+			subject TEXT NOT NULL,
+			raw BYTEA NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_captured_messages_project_id ON captured_messages(project_id, created_at)`,
+		
+		`CREATE TABLE IF NOT EXISTS jobs (
+			id VARCHAR(255) PRIMARY KEY,
+			project_id VARCHAR(255),
+			type VARCHAR(20) NOT NULL,
+			filter_statuses TEXT[] NOT NULL DEFAULT '{}',
+			filter_since TIMESTAMP WITH TIME ZONE,
+			filter_until TIMESTAMP WITH TIME ZONE,
+			filter_error TEXT NOT NULL DEFAULT '',
+			status VARCHAR(20) NOT NULL DEFAULT 'running',
+			total INTEGER NOT NULL DEFAULT 0,
+			processed INTEGER NOT NULL DEFAULT 0,
+			succeeded INTEGER NOT NULL DEFAULT 0,
+			error_msg TEXT,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			finished_at TIMESTAMP WITH TIME ZONE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_project_id ON jobs(project_id, created_at)`,
+		
+		`CREATE TABLE IF NOT EXISTS warmup_plans (
+			id VARCHAR(255) PRIMARY KEY,
+			project_id VARCHAR(255) NOT NULL,
+			domain VARCHAR(255) NOT NULL DEFAULT '',
+			start_daily INTEGER NOT NULL,
+			days INTEGER NOT NULL,
+			started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			UNIQUE (project_id, domain)
+		)`,
 		
 		`CREATE TABLE IF NOT EXISTS audit_logs (
 			id VARCHAR(255) PRIMARY KEY,
@@ -112,6 +337,43 @@ func (s *PostgreSQLStorage) initTables() error {
 		}
 	}
 	
+	return s.backfillAPIKeyDigests()
+}
+
+// backfillAPIKeyDigests computes the API key digest for projects created before digests existed
+func (s *PostgreSQLStorage) backfillAPIKeyDigests() error {
+	rows, err := s.db.Query(`SELECT id, api_key_enc FROM projects WHERE api_key_digest IS NULL`)
+	if err != nil {
+		return fmt.Errorf("failed to list projects without API key digest: %w", err)
+	}
+	
+	pending := map[string]string{}
+	for rows.Next() {
+		var id, apiKeyEnc string
+		if err := rows.Scan(&id, &apiKeyEnc); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan project: %w", err)
+		}
+		pending[id] = apiKeyEnc
+	}
+	rows.Close()
+	
+	for id, apiKeyEnc := range pending {
+		apiKey, err := crypto.DecryptAPIKey(apiKeyEnc)
+		if err != nil {
+			log.Printf("⚠️  Could not decrypt API key for project %s, skipping digest backfill: %v", id, err)
+			continue
+		}
+		
+		if _, err := s.db.Exec(`UPDATE projects SET api_key_digest = $1 WHERE id = $2`, crypto.HashAPIKey(apiKey), id); err != nil {
+			return fmt.Errorf("failed to backfill API key digest for project %s: %w", id, err)
+		}
+	}
+	
+	if len(pending) > 0 {
+		log.Printf("✅ Backfilled API key digests for %d projects", len(pending))
+	}
+	
 	return nil
 }
 
@@ -137,12 +399,32 @@ func joinStrings(strs []string, sep string) string {
 	return result
 }
 
+// parseArrayString parses a one-dimensional PostgreSQL text array literal,
+// e.g. {a@example.com,"b,c@example.com"}
 func parseArrayString(s string) []string {
-	// Simplified array parsing - in production use proper PostgreSQL array parsing
-	if s == "" || s == "{}" {
+	if len(s) < 2 || s == "{}" {
 		return []string{}
 	}
-	// Remove braces and split by comma
 	s = s[1 : len(s)-1] // Remove { and }
-	return []string{s}   // Simplified - just return as single element
+	
+	var elements []string
+	var current strings.Builder
+	quoted, escaped := false, false
+	for _, c := range s {
+		switch {
+		case escaped:
+			current.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			elements = append(elements, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(c)
+		}
+	}
+	return append(elements, current.String())
 }
\ No newline at end of file
@@ -2,25 +2,49 @@ package storage
 
 import (
 	"fmt"
+	"time"
 )
 
-// GetProject retrieves a project by ID
-func (s *PostgreSQLStorage) GetProject(id string) (*Project, error) {
-	query := `
-		SELECT id, name, description, api_key_enc, password_hash, smtp_host, smtp_port, smtp_user, 
-		       smtp_password_enc, quota_daily, quota_per_minute, status, user_id, created_at, last_used_at
-		FROM projects
-		WHERE id = $1
-	`
-	
+// projectColumns lists the columns selected for a Project, in scanProject order
+const projectColumns = `id, name, description, api_key_enc, api_key_digest, password_hash, smtp_host, smtp_port, smtp_user,
+		       smtp_password_enc, quota_daily, quota_per_minute, status, require_ip_allow, delivery_mode,
+		       upstream_tls_mode, upstream_tls_min_version, upstream_tls_ca_bundle, upstream_tls_server_name,
+		       upstream_auth_mechanism, oauth_token_url, oauth_client_id, oauth_client_secret_enc,
+		       oauth_refresh_token_enc, oauth_scope, transport_endpoint, transport_region,
+		       transport_access_key_id, transport_secret_enc, suppression_mode, track_opens, track_clicks,
+		       list_unsubscribe, unsubscribe_email, delivery_paused, user_id, created_at, last_used_at`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProject scans a row selected with projectColumns into a Project
+func scanProject(row rowScanner) (*Project, error) {
 	project := &Project{}
-	err := s.db.QueryRow(query, id).Scan(
-		&project.ID, &project.Name, &project.Description, &project.APIKeyEnc,
+	err := row.Scan(
+		&project.ID, &project.Name, &project.Description, &project.APIKeyEnc, &project.APIKeyDigest,
 		&project.PasswordHash, &project.SMTPHost, &project.SMTPPort, &project.SMTPUser,
 		&project.SMTPPasswordEnc, &project.QuotaDaily, &project.QuotaPerMinute, &project.Status,
-		&project.UserID, &project.CreatedAt, &project.LastUsedAt,
+		&project.RequireIPAllow, &project.DeliveryMode,
+		&project.UpstreamTLS.Mode, &project.UpstreamTLS.MinVersion, &project.UpstreamTLS.CABundle, &project.UpstreamTLS.ServerName,
+		&project.UpstreamAuth.Mechanism, &project.UpstreamAuth.OAuthTokenURL, &project.UpstreamAuth.OAuthClientID,
+		&project.UpstreamAuth.OAuthClientSecretEnc, &project.UpstreamAuth.OAuthRefreshTokenEnc, &project.UpstreamAuth.OAuthScope,
+		&project.Transport.Endpoint, &project.Transport.Region, &project.Transport.AccessKeyID, &project.Transport.SecretEnc,
+		&project.SuppressionMode, &project.TrackOpens, &project.TrackClicks,
+		&project.ListUnsubscribe, &project.UnsubscribeEmail, &project.DeliveryPaused, &project.UserID, &project.CreatedAt, &project.LastUsedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// GetProject retrieves a project by ID
+func (s *PostgreSQLStorage) GetProject(id string) (*Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1`
 	
+	project, err := scanProject(s.db.QueryRow(query, id))
 	if err != nil {
 		if err.Error() == "sql: no rows in result set" {
 			return nil, fmt.Errorf("project not found: %s", id)
@@ -31,11 +55,25 @@ func (s *PostgreSQLStorage) GetProject(id string) (*Project, error) {
 	return project, nil
 }
 
+// GetProjectByAPIKeyDigest retrieves a non-deleted project by the HMAC digest of its API key
+func (s *PostgreSQLStorage) GetProjectByAPIKeyDigest(digest string) (*Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE api_key_digest = $1 AND status != 'deleted'`
+	
+	project, err := scanProject(s.db.QueryRow(query, digest))
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, fmt.Errorf("project not found for API key")
+		}
+		return nil, fmt.Errorf("failed to get project by API key: %w", err)
+	}
+	
+	return project, nil
+}
+
 // ListAllProjects retrieves all projects
 func (s *PostgreSQLStorage) ListAllProjects() ([]*Project, error) {
 	query := `
-		SELECT id, name, description, api_key_enc, password_hash, smtp_host, smtp_port, smtp_user, 
-		       smtp_password_enc, quota_daily, quota_per_minute, status, user_id, created_at, last_used_at
+		SELECT ` + projectColumns + `
 		FROM projects
 		WHERE status != 'deleted'
 		ORDER BY created_at DESC
@@ -49,13 +87,7 @@ func (s *PostgreSQLStorage) ListAllProjects() ([]*Project, error) {
 	
 	var projects []*Project
 	for rows.Next() {
-		project := &Project{}
-		err := rows.Scan(
-			&project.ID, &project.Name, &project.Description, &project.APIKeyEnc,
-			&project.PasswordHash, &project.SMTPHost, &project.SMTPPort, &project.SMTPUser,
-			&project.SMTPPasswordEnc, &project.QuotaDaily, &project.QuotaPerMinute, &project.Status,
-			&project.UserID, &project.CreatedAt, &project.LastUsedAt,
-		)
+		project, err := scanProject(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan project: %w", err)
 		}
@@ -72,21 +104,47 @@ func (s *PostgreSQLStorage) ListAllProjects() ([]*Project, error) {
 
 // CreateProject creates a new project
 func (s *PostgreSQLStorage) CreateProject(project *Project) error {
+	if project.DeliveryMode == "" {
+		project.DeliveryMode = DeliveryModeRelay
+	}
+	if project.UpstreamTLS.Mode == "" {
+		project.UpstreamTLS.Mode = UpstreamTLSOpportunistic
+	}
+	if project.UpstreamAuth.Mechanism == "" {
+		project.UpstreamAuth.Mechanism = UpstreamAuthPlain
+	}
+	if project.SuppressionMode == "" {
+		project.SuppressionMode = SuppressionModeReject
+	}
+	
 	query := `
-		INSERT INTO projects (id, name, description, api_key_enc, password_hash, smtp_host, smtp_port, smtp_user, 
-		                     smtp_password_enc, quota_daily, quota_per_minute, status, user_id, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		INSERT INTO projects (id, name, description, api_key_enc, api_key_digest, password_hash, smtp_host, smtp_port, smtp_user, 
+		                     smtp_password_enc, quota_daily, quota_per_minute, status, require_ip_allow, delivery_mode,
+		                     upstream_tls_mode, upstream_tls_min_version, upstream_tls_ca_bundle, upstream_tls_server_name,
+		                     upstream_auth_mechanism, oauth_token_url, oauth_client_id, oauth_client_secret_enc,
+		                     oauth_refresh_token_enc, oauth_scope, transport_endpoint, transport_region,
+		                     transport_access_key_id, transport_secret_enc, suppression_mode, track_opens, track_clicks,
+		                     list_unsubscribe, unsubscribe_email, delivery_paused, user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+		        $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36, $37)
 	`
 	
 	_, err := s.db.Exec(query,
-		project.ID, project.Name, project.Description, project.APIKeyEnc, project.PasswordHash,
+		project.ID, project.Name, project.Description, project.APIKeyEnc, project.APIKeyDigest, project.PasswordHash,
 		project.SMTPHost, project.SMTPPort, project.SMTPUser, project.SMTPPasswordEnc,
-		project.QuotaDaily, project.QuotaPerMinute, project.Status, project.UserID, project.CreatedAt)
+		project.QuotaDaily, project.QuotaPerMinute, project.Status, project.RequireIPAllow, project.DeliveryMode,
+		project.UpstreamTLS.Mode, project.UpstreamTLS.MinVersion, project.UpstreamTLS.CABundle, project.UpstreamTLS.ServerName,
+		project.UpstreamAuth.Mechanism, project.UpstreamAuth.OAuthTokenURL, project.UpstreamAuth.OAuthClientID,
+		project.UpstreamAuth.OAuthClientSecretEnc, project.UpstreamAuth.OAuthRefreshTokenEnc, project.UpstreamAuth.OAuthScope,
+		project.Transport.Endpoint, project.Transport.Region, project.Transport.AccessKeyID, project.Transport.SecretEnc,
+		project.SuppressionMode, project.TrackOpens, project.TrackClicks,
+		project.ListUnsubscribe, project.UnsubscribeEmail, project.DeliveryPaused, project.UserID, project.CreatedAt)
 	
 	if err != nil {
 		return fmt.Errorf("failed to create project: %w", err)
 	}
 	
+	s.notifyProjectChanged(project.ID)
 	return nil
 }
 
@@ -96,19 +154,43 @@ func (s *PostgreSQLStorage) UpdateProject(id string, project *Project) error {
 		UPDATE projects 
 		SET name = $1, description = $2, password_hash = $3, smtp_host = $4, smtp_port = $5, 
 		    smtp_user = $6, smtp_password_enc = $7, quota_daily = $8, quota_per_minute = $9, 
-		    status = $10, last_used_at = $11
-		WHERE id = $12
+		    status = $10, last_used_at = $11, require_ip_allow = $12, delivery_mode = $13,
+		    upstream_tls_mode = $14, upstream_tls_min_version = $15, upstream_tls_ca_bundle = $16,
+		    upstream_tls_server_name = $17, upstream_auth_mechanism = $18, oauth_token_url = $19,
+		    oauth_client_id = $20, oauth_client_secret_enc = $21, oauth_refresh_token_enc = $22, oauth_scope = $23,
+		    transport_endpoint = $24, transport_region = $25, transport_access_key_id = $26, transport_secret_enc = $27,
+		    suppression_mode = $28, track_opens = $29, track_clicks = $30,
+		    list_unsubscribe = $31, unsubscribe_email = $32, delivery_paused = $33
+		WHERE id = $34
 	`
 	
 	_, err := s.db.Exec(query,
 		project.Name, project.Description, project.PasswordHash, project.SMTPHost, 
 		project.SMTPPort, project.SMTPUser, project.SMTPPasswordEnc, project.QuotaDaily, 
-		project.QuotaPerMinute, project.Status, project.LastUsedAt, id)
+		project.QuotaPerMinute, project.Status, project.LastUsedAt, project.RequireIPAllow, project.DeliveryMode,
+		project.UpstreamTLS.Mode, project.UpstreamTLS.MinVersion, project.UpstreamTLS.CABundle,
+		project.UpstreamTLS.ServerName, project.UpstreamAuth.Mechanism, project.UpstreamAuth.OAuthTokenURL,
+		project.UpstreamAuth.OAuthClientID, project.UpstreamAuth.OAuthClientSecretEnc,
+		project.UpstreamAuth.OAuthRefreshTokenEnc, project.UpstreamAuth.OAuthScope,
+		project.Transport.Endpoint, project.Transport.Region, project.Transport.AccessKeyID, project.Transport.SecretEnc,
+		project.SuppressionMode, project.TrackOpens, project.TrackClicks,
+		project.ListUnsubscribe, project.UnsubscribeEmail, project.DeliveryPaused, id)
 	
 	if err != nil {
 		return fmt.Errorf("failed to update project: %w", err)
 	}
 	
+	s.notifyProjectChanged(id)
+	return nil
+}
+
+// UpdateOAuthRefreshToken stores a rotated XOAUTH2 refresh token (encrypted)
+func (s *PostgreSQLStorage) UpdateOAuthRefreshToken(projectID string, refreshTokenEnc string) error {
+	_, err := s.db.Exec(`UPDATE projects SET oauth_refresh_token_enc = $1 WHERE id = $2`, refreshTokenEnc, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to update OAuth refresh token: %w", err)
+	}
+	
 	return nil
 }
 
@@ -121,6 +203,7 @@ func (s *PostgreSQLStorage) DeleteProject(id string) error {
 		return fmt.Errorf("failed to delete project: %w", err)
 	}
 	
+	s.notifyProjectChanged(id)
 	return nil
 }
 
@@ -132,11 +215,12 @@ func (s *PostgreSQLStorage) GetQuotaUsage(projectID string) (*QuotaUsage, error)
 		return nil, fmt.Errorf("failed to get project: %w", err)
 	}
 	
-	// Count emails sent in the last 24 hours
+	// Count emails sent in the last 24 hours (scheduled emails count once released)
 	dailyQuery := `
 	
 	SELECT COUNT(*) FROM emails 
 		WHERE project_id = $1 AND sent_at > NOW() - INTERVAL '24 hours'
+		AND status NOT IN ('scheduled', 'cancelled')
 	`
 	var dailyUsed int
 	if err := s.db.QueryRow(dailyQuery, projectID).Scan(&dailyUsed); err != nil {
@@ -147,6 +231,7 @@ func (s *PostgreSQLStorage) GetQuotaUsage(projectID string) (*QuotaUsage, error)
 	minuteQuery := `
 		SELECT COUNT(*) FROM emails 
 		WHERE project_id = $1 AND sent_at > NOW() - INTERVAL '1 minute'
+		AND status NOT IN ('scheduled', 'cancelled')
 	`
 	var minuteUsed int
 	if err := s.db.QueryRow(minuteQuery, projectID).Scan(&minuteUsed); err != nil {
@@ -159,9 +244,33 @@ func (s *PostgreSQLStorage) GetQuotaUsage(projectID string) (*QuotaUsage, error)
 		DailyLimit:     project.QuotaDaily,
 		MinuteUsed:     minuteUsed,
 		MinuteLimit:    project.QuotaPerMinute,
-		DailyRemaining: project.QuotaDaily - dailyUsed,
-		MinuteRemaining: project.QuotaPerMinute - minuteUsed,
+		Warmups:        []*WarmupProgress{},
+	}
+	
+	// Warm-up plans lower the effective limits until they are complete
+	plans, err := s.ListWarmupPlans(projectID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, plan := range plans {
+		progress := plan.Progress(now, project.QuotaDaily, project.QuotaPerMinute)
+		if plan.Domain == "" {
+			progress.DailyUsed, progress.MinuteUsed = dailyUsed, minuteUsed
+			if !progress.Complete {
+				quota.DailyLimit = progress.DailyAllowance
+				quota.MinuteLimit = progress.MinuteAllowance
+			}
+		} else {
+			progress.DailyUsed, progress.MinuteUsed, err = s.warmupUsage(projectID, plan.Domain)
+			if err != nil {
+				return nil, err
+			}
+		}
+		quota.Warmups = append(quota.Warmups, progress)
 	}
+	quota.DailyRemaining = quota.DailyLimit - dailyUsed
+	quota.MinuteRemaining = quota.MinuteLimit - minuteUsed
 	
 	// Ensure remaining counts don't go negative
 	if quota.DailyRemaining < 0 {
@@ -174,8 +283,9 @@ func (s *PostgreSQLStorage) GetQuotaUsage(projectID string) (*QuotaUsage, error)
 	return quota, nil
 }
 
-// CheckQuotaLimits checks if a project has exceeded its quotas
-func (s *PostgreSQLStorage) CheckQuotaLimits(projectID string) error {
+// CheckQuotaLimits checks if a project has exceeded its quotas, including the
+// warm-up allowance of the sender's domain
+func (s *PostgreSQLStorage) CheckQuotaLimits(projectID string, from string) error {
 	quota, err := s.GetQuotaUsage(projectID)
 	if err != nil {
 		return fmt.Errorf("failed to check quotas: %w", err)
@@ -189,5 +299,20 @@ func (s *PostgreSQLStorage) CheckQuotaLimits(projectID string) error {
 		return fmt.Errorf("per-minute quota exceeded: %d/%d emails used", quota.MinuteUsed, quota.MinuteLimit)
 	}
 	
+	domain := senderDomain(from)
+	for _, warmup := range quota.Warmups {
+		if warmup.Domain == "" || warmup.Domain != domain || warmup.Complete {
+			continue
+		}
+		if warmup.DailyUsed >= warmup.DailyAllowance {
+			return fmt.Errorf("warm-up daily allowance for %s exceeded: %d/%d emails used (day %d of %d)",
+				domain, warmup.DailyUsed, warmup.DailyAllowance, warmup.Day, warmup.Days)
+		}
+		if warmup.MinuteUsed >= warmup.MinuteAllowance {
+			return fmt.Errorf("warm-up per-minute allowance for %s exceeded: %d/%d emails used",
+				domain, warmup.MinuteUsed, warmup.MinuteAllowance)
+		}
+	}
+	
 	return nil
 }
\ No newline at end of file
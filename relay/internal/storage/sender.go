@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"fmt"
+)
+
+// ListSenderRules retrieves the allowed sender patterns for a project
+func (s *PostgreSQLStorage) ListSenderRules(projectID string) ([]*SenderRule, error) {
+	query := `
+		SELECT id, project_id, pattern, created_at
+		FROM project_sender_rules
+		WHERE project_id = $1
+		ORDER BY created_at ASC
+	`
+	
+	rows, err := s.db.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sender rules: %w", err)
+	}
+	defer rows.Close()
+	
+	rules := []*SenderRule{}
+	for rows.Next() {
+		rule := &SenderRule{}
+		if err := rows.Scan(&rule.ID, &rule.ProjectID, &rule.Pattern, &rule.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sender rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	
+	return rules, nil
+}
+
+// AddSenderRule adds an allowed sender pattern to a project
+func (s *PostgreSQLStorage) AddSenderRule(rule *SenderRule) error {
+	query := `
+		INSERT INTO project_sender_rules (id, project_id, pattern, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	
+	_, err := s.db.Exec(query, rule.ID, rule.ProjectID, rule.Pattern, rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add sender rule: %w", err)
+	}
+	
+	s.notifyProjectChanged(rule.ProjectID)
+	return nil
+}
+
+// DeleteSenderRule removes an allowed sender pattern from a project
+func (s *PostgreSQLStorage) DeleteSenderRule(projectID, ruleID string) error {
+	result, err := s.db.Exec(`DELETE FROM project_sender_rules WHERE id = $1 AND project_id = $2`, ruleID, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete sender rule: %w", err)
+	}
+	
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("sender rule not found: %s", ruleID)
+	}
+	
+	s.notifyProjectChanged(projectID)
+	return nil
+}
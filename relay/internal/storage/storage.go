@@ -6,21 +6,101 @@ import (
 
 // Email represents an email record in the database
 type Email struct {
-	ID          string
-	MessageID   string
-	ProjectID   string
-	From        string
-	To          []string
-	Subject     string
-	ContentEnc  []byte
-	Size        int
-	Status      string
-	Error       *string
-	Attempts    int
-	SentAt      time.Time
-	OpenedAt    *time.Time
-	ClickedAt   *time.Time
-	Metadata    map[string]interface{}
+	ID             string
+	MessageID      string
+	ProjectID      string
+	From           string
+	To             []string
+	Subject        string
+	ContentEnc     []byte
+	Size           int
+	Status         string
+	Error          *string
+	Attempts       int
+	SentAt         time.Time
+	Upstream       *string
+	TLSVersion     *string
+	TLSCipher      *string
+	OpenedAt       *time.Time
+	ClickedAt      *time.Time
+	OpenCount      int
+	ProxyOpenCount int // opens fetched by a mail provider proxy rather than a reader
+	ClickCount     int
+	ScheduledAt    *time.Time // when a scheduled email is due to be sent
+	Metadata       map[string]interface{}
+}
+
+// Email statuses of the delivery queue. Queued emails wait to be forwarded
+// (held while the project's delivery is paused); scheduled emails do not
+// count towards quotas until they are released for delivery.
+const (
+	EmailStatusQueued    = "queued"
+	EmailStatusScheduled = "scheduled"
+	EmailStatusCancelled = "cancelled"
+)
+
+// EmailFilter selects the emails a bulk operation applies to. Empty fields
+// match every email.
+type EmailFilter struct {
+	ProjectID     string
+	Statuses      []string
+	Since         *time.Time // sent at or after
+	Until         *time.Time // sent before
+	ErrorContains string     // case-insensitive substring of the error message
+}
+
+// EmailOpen records one fetch of an email's open tracking pixel
+type EmailOpen struct {
+	ID        string
+	EmailID   string
+	ProjectID string
+	UserAgent string
+	IPAddress string
+	Proxy     string // mail provider proxy that fetched the pixel, empty for a direct open
+	CreatedAt time.Time
+}
+
+// EmailClick records one click of a tracked link in an email
+type EmailClick struct {
+	ID        string
+	EmailID   string
+	ProjectID string
+	URL       string
+	UserAgent string
+	IPAddress string
+	CreatedAt time.Time
+}
+
+// LinkClickStats summarises the clicks of one link
+type LinkClickStats struct {
+	URL            string
+	Clicks         int
+	Emails         int // distinct emails the link was clicked in
+	FirstClickedAt time.Time
+	LastClickedAt  time.Time
+}
+
+// Recipient delivery statuses
+const (
+	RecipientStatusPending   = "pending"
+	RecipientStatusDelivered = "delivered"
+	RecipientStatusDeferred  = "deferred"
+	RecipientStatusBounced   = "bounced"
+	RecipientStatusFailed    = "failed"
+)
+
+// EmailRecipient tracks delivery of an email to one recipient
+type EmailRecipient struct {
+	ID           string
+	EmailID      string
+	Recipient    string
+	Status       string
+	ResponseCode *int
+	ResponseText *string
+	Attempts     int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeliveredAt  *time.Time
 }
 
 // Project represents a project configuration
@@ -29,6 +109,7 @@ type Project struct {
 	Name             string
 	Description      string
 	APIKeyEnc        string    // Encrypted API key (database storage)
+	APIKeyDigest     *string   // Keyed HMAC digest of the API key (lookup index)
 	PasswordHash     *string
 	SMTPHost         *string
 	SMTPPort         *int
@@ -37,11 +118,283 @@ type Project struct {
 	QuotaDaily       int
 	QuotaPerMinute   int
 	Status           string
+	RequireIPAllow   bool
+	DeliveryMode     string
+	UpstreamTLS      UpstreamTLSPolicy
+	UpstreamAuth     UpstreamAuthSettings
+	Transport        TransportSettings
+	SuppressionMode  string
+	TrackOpens       bool
+	TrackClicks      bool
+	ListUnsubscribe  bool
+	UnsubscribeEmail *string   // Optional mailto: address offered next to the one-click URL
+	DeliveryPaused   bool      // Hold new mail in the queue instead of forwarding it
 	UserID           *string
 	CreatedAt        time.Time
 	LastUsedAt       *time.Time
 }
 
+// Project delivery modes
+const (
+	DeliveryModeRelay    = "relay"    // via the project's upstream SMTP host
+	DeliveryModeMX       = "mx"       // directly to the recipients' mail exchangers
+	DeliveryModeWebhook  = "webhook"  // POSTed as JSON to a generic HTTP endpoint
+	DeliveryModeSES      = "ses"      // through the Amazon SES v2 API
+	DeliveryModeSendGrid = "sendgrid" // through the SendGrid v3 API
+	DeliveryModeSandbox  = "sandbox"  // captured for inspection, never sent
+)
+
+// Suppression modes: what happens to a suppressed recipient
+const (
+	SuppressionModeReject = "reject" // refused with an error
+	SuppressionModeDrop   = "drop"   // silently left out of the delivery
+)
+
+// TransportSettings configures the HTTP API transport of the webhook, ses and
+// sendgrid delivery modes. SecretEnc holds the API key (webhook, SendGrid) or
+// the secret access key (SES).
+type TransportSettings struct {
+	Endpoint    *string // overrides the provider's default API URL
+	Region      *string // SES region
+	AccessKeyID *string // SES access key ID
+	SecretEnc   *string
+}
+
+// Upstream represents an upstream SMTP relay (smarthost) for a project.
+// Lower priorities are tried first; weight spreads load within a priority.
+type Upstream struct {
+	ID          string
+	ProjectID   string
+	Host        string
+	Port        int
+	Username    *string
+	PasswordEnc *string
+	Priority    int
+	Weight      int
+	Enabled     bool
+	CreatedAt   time.Time
+}
+
+// Upstream TLS modes
+const (
+	UpstreamTLSNone          = "none"          // plaintext, never STARTTLS
+	UpstreamTLSOpportunistic = "opportunistic" // STARTTLS when offered
+	UpstreamTLSStartTLS      = "starttls"      // STARTTLS required
+	UpstreamTLSImplicit      = "implicit"      // TLS from connect (port 465)
+)
+
+// UpstreamTLSPolicy controls how a project's upstream relays are connected to
+type UpstreamTLSPolicy struct {
+	Mode       string
+	MinVersion string  // "1.0" - "1.3", empty for the Go default
+	CABundle   *string // PEM certificates trusted instead of the system roots
+	ServerName *string // overrides the host name used for certificate verification
+}
+
+// Upstream authentication mechanisms
+const (
+	UpstreamAuthPlain   = "plain"
+	UpstreamAuthLogin   = "login"
+	UpstreamAuthCRAMMD5 = "cram-md5"
+	UpstreamAuthXOAUTH2 = "xoauth2"
+)
+
+// UpstreamAuthSettings controls how a project authenticates to its upstream
+// relays. XOAUTH2 exchanges the stored refresh token for access tokens.
+type UpstreamAuthSettings struct {
+	Mechanism            string
+	OAuthTokenURL        *string
+	OAuthClientID        *string
+	OAuthClientSecretEnc *string
+	OAuthRefreshTokenEnc *string
+	OAuthScope           *string
+}
+
+// IPAllowlistEntry represents an allowed IPv4/IPv6 address or CIDR range for a project
+type IPAllowlistEntry struct {
+	ID          string
+	ProjectID   string
+	CIDR        string
+	Description string
+	CreatedAt   time.Time
+}
+
+// SenderRule represents an allowed sender pattern for a project:
+// "user@example.com", "example.com" or "*.example.com"
+type SenderRule struct {
+	ID        string
+	ProjectID string
+	Pattern   string
+	CreatedAt time.Time
+}
+
+// Sender domain verification statuses
+const (
+	DomainStatusPending  = "pending"
+	DomainStatusVerified = "verified"
+	DomainStatusFailed   = "failed"
+)
+
+// SenderDomain represents a domain a project has claimed for sending, verified via DNS TXT
+type SenderDomain struct {
+	ID                string
+	ProjectID         string
+	Domain            string
+	VerificationToken string
+	Status            string
+	LastError         *string
+	LastCheckedAt     *time.Time
+	VerifiedAt        *time.Time
+	CreatedAt         time.Time
+}
+
+// DKIMKey represents a DKIM signing key for one of a project's sender domains.
+// The private key is stored PEM encoded and encrypted.
+type DKIMKey struct {
+	ID            string
+	ProjectID     string
+	Domain        string
+	Selector      string
+	Algorithm     string
+	PrivateKeyEnc string
+	PublicKey     string
+	CreatedAt     time.Time
+}
+
+// Suppression sources
+const (
+	SuppressionSourceManual      = "manual"
+	SuppressionSourceImport      = "import"
+	SuppressionSourceBounce      = "bounce"
+	SuppressionSourceComplaint   = "complaint"
+	SuppressionSourceUnsubscribe = "unsubscribe"
+)
+
+// Suppression is an address a project must no longer send to. Addresses are
+// stored lower-cased; EmailID links entries added by bounce processing and
+// unsubscribes.
+type Suppression struct {
+	ID        string
+	ProjectID string
+	Address   string
+	Reason    string
+	Source    string
+	EmailID   *string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Webhook is a project endpoint notified of email lifecycle events. An empty
+// Events list subscribes to every event type.
+type Webhook struct {
+	ID                  string
+	ProjectID           string
+	URL                 string
+	SecretEnc           string // HMAC-SHA256 signing secret (encrypted)
+	Events              []string
+	Enabled             bool
+	ConsecutiveFailures int
+	FailedDeliveries    int // dead-lettered deliveries
+	LastSuccessAt       *time.Time
+	LastFailureAt       *time.Time
+	LastError           *string
+	CreatedAt           time.Time
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed" // out of retries (dead letter)
+)
+
+// WebhookDelivery is one event queued for one webhook endpoint
+type WebhookDelivery struct {
+	ID            string
+	WebhookID     string
+	ProjectID     string
+	EventType     string
+	EmailID       *string
+	Payload       []byte
+	Status        string
+	Attempts      int
+	ResponseCode  *int
+	LastError     *string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeliveredAt   *time.Time
+}
+
+// CapturedMessage is a message accepted by a sandbox project and stored
+// instead of being sent
+type CapturedMessage struct {
+	ID         string
+	ProjectID  string
+	EmailID    string
+	From       string
+	Recipients []string
+	Subject    string
+	Raw        []byte
+	Size       int
+	CreatedAt  time.Time
+}
+
+// Job tracks a bulk email operation running in the background
+type Job struct {
+	ID         string
+	ProjectID  *string
+	Type       string
+	Filter     EmailFilter
+	Status     string
+	Total      int
+	Processed  int
+	Succeeded  int // emails the operation applied to; the rest had changed status meanwhile
+	Error      *string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// Job types
+const (
+	JobTypeRetry  = "retry"
+	JobTypeCancel = "cancel"
+)
+
+// Job statuses
+const (
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+// WarmupPlan ramps a project's effective quota up over a number of days so a
+// new project or sender domain does not go from nothing to its full volume
+type WarmupPlan struct {
+	ID         string
+	ProjectID  string
+	Domain     string // sender domain the plan applies to; empty for the whole project
+	StartDaily int    // daily allowance on the first day
+	Days       int    // days until the full quota applies
+	StartedAt  time.Time
+	CreatedAt  time.Time
+}
+
+// WarmupProgress describes where a warm-up plan is in its ramp
+type WarmupProgress struct {
+	PlanID          string
+	Domain          string
+	Day             int // 1-based day of the plan; beyond Days once complete
+	Days            int
+	Complete        bool
+	DailyAllowance  int
+	MinuteAllowance int
+	DailyUsed       int
+	MinuteUsed      int
+	EndsAt          time.Time
+}
+
 // AuditLog represents an audit log entry
 type AuditLog struct {
 	ID        string
@@ -63,6 +416,7 @@ type QuotaUsage struct {
 	MinuteLimit    int
 	DailyRemaining int
 	MinuteRemaining int
+	Warmups        []*WarmupProgress // limits above include a whole-project plan; domain plans are checked per sender
 }
 
 // Storage interface defines database operations
@@ -77,17 +431,105 @@ type Storage interface {
 	SearchEmailsWithStatus(projectID string, searchQuery string, statusFilter string, limit, offset int) ([]*Email, int, error)
 	SearchAllEmailsWithStatus(searchQuery string, statusFilter string, limit, offset int) ([]*Email, int, error)
 	UpdateEmailStatus(id string, status string, error *string) error
+	ListEmailRecipients(emailID string) ([]*EmailRecipient, error)
+	UpdateRecipientStatus(emailID, recipient, status string, responseCode *int, responseText *string) error
+	ResetUndeliveredRecipients(emailID string) error
+	CountQueuedRecipientsByDomain() (map[string]int, error)
+	SetEmailUpstream(id string, upstream string, tlsVersion, tlsCipher *string) error
+	ListDueScheduledEmails(limit int) ([]*Email, error)
+	ReleaseScheduledEmail(id string) (bool, error)
+	RescheduleEmail(id string, sendAt time.Time) (bool, error)
+	ListQueuedEmails(limit int) ([]*Email, error)
+	ClaimQueuedEmail(id string) (bool, error)
+	RequeueEmail(id string) (bool, error)
+	CancelEmail(id string) (bool, error)
+	ListEmailIDs(filter EmailFilter) ([]string, error)
+	RecordEmailOpen(open *EmailOpen) (int, error)
+	ListEmailOpens(emailID string, limit int) ([]*EmailOpen, error)
+	RecordEmailClick(click *EmailClick) (int, error)
+	ListEmailLinkStats(emailID string) ([]*LinkClickStats, error)
+	ListProjectLinkStats(projectID string, limit, offset int) ([]*LinkClickStats, int, error)
 	
 	// Project operations
 	CreateProject(project *Project) error
 	GetProject(id string) (*Project, error)
+	GetProjectByAPIKeyDigest(digest string) (*Project, error)
 	UpdateProject(id string, project *Project) error
+	UpdateOAuthRefreshToken(projectID string, refreshTokenEnc string) error
 	DeleteProject(id string) error
 	ListAllProjects() ([]*Project, error)
 	
+	// Upstream relay operations
+	ListUpstreams(projectID string) ([]*Upstream, error)
+	AddUpstream(upstream *Upstream) error
+	UpdateUpstream(upstream *Upstream) error
+	DeleteUpstream(projectID, upstreamID string) error
+	
+	// IP allowlist operations
+	ListIPAllowlist(projectID string) ([]*IPAllowlistEntry, error)
+	AddIPAllowlistEntry(entry *IPAllowlistEntry) error
+	DeleteIPAllowlistEntry(projectID, entryID string) error
+	
+	// Sender rule operations
+	ListSenderRules(projectID string) ([]*SenderRule, error)
+	AddSenderRule(rule *SenderRule) error
+	DeleteSenderRule(projectID, ruleID string) error
+	
+	// Sender domain operations
+	ListSenderDomains(projectID string) ([]*SenderDomain, error)
+	ListAllSenderDomains() ([]*SenderDomain, error)
+	GetSenderDomain(projectID, domainID string) (*SenderDomain, error)
+	AddSenderDomain(domain *SenderDomain) error
+	UpdateSenderDomainStatus(id string, status string, lastError *string) error
+	DeleteSenderDomain(projectID, domainID string) error
+	
+	// DKIM key operations
+	ListDKIMKeys(projectID string) ([]*DKIMKey, error)
+	AddDKIMKey(key *DKIMKey) error
+	DeleteDKIMKey(projectID, keyID string) error
+	
+	// Suppression list operations
+	ListSuppressions(projectID, search string, limit, offset int) ([]*Suppression, int, error)
+	FindSuppressions(projectID string, addresses []string) ([]*Suppression, error)
+	AddSuppression(suppression *Suppression) error
+	DeleteSuppression(projectID, suppressionID string) error
+	
+	// Webhook operations
+	ListWebhooks(projectID string) ([]*Webhook, error)
+	GetWebhook(projectID, webhookID string) (*Webhook, error)
+	AddWebhook(webhook *Webhook) error
+	UpdateWebhook(webhook *Webhook) error
+	DeleteWebhook(projectID, webhookID string) error
+	RecordWebhookResult(webhookID string, success bool, lastError *string) error
+	CreateWebhookDelivery(delivery *WebhookDelivery) error
+	ClaimDueWebhookDeliveries(limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	UpdateWebhookDelivery(delivery *WebhookDelivery) error
+	GetWebhookDelivery(projectID, deliveryID string) (*WebhookDelivery, error)
+	ListWebhookDeliveries(projectID, webhookID, status string, limit, offset int) ([]*WebhookDelivery, int, error)
+	RequeueWebhookDeliveries(projectID, webhookID string) (int64, error)
+	
+	// Sandbox capture operations
+	StoreCapturedMessage(message *CapturedMessage) error
+	ListCapturedMessages(projectID, recipient string, limit, offset int) ([]*CapturedMessage, int, error)
+	GetCapturedMessage(projectID, messageID string) (*CapturedMessage, error)
+	ClearCapturedMessages(projectID string) (int64, error)
+	
+	// Background job operations
+	CreateJob(job *Job) error
+	GetJob(id string) (*Job, error)
+	ListJobs(projectID string, limit, offset int) ([]*Job, int, error)
+	UpdateJobProgress(id string, total, processed, succeeded int) error
+	FinishJob(id string, status string, errorMsg *string) error
+	FailStaleJobs(staleAfter time.Duration, reason string) (int64, error)
+	
+	// Warm-up plan operations
+	ListWarmupPlans(projectID string) ([]*WarmupPlan, error)
+	CreateWarmupPlan(plan *WarmupPlan) error
+	DeleteWarmupPlan(projectID, planID string) error
+	
 	// Quota operations
 	GetQuotaUsage(projectID string) (*QuotaUsage, error)
-	CheckQuotaLimits(projectID string) error
+	CheckQuotaLimits(projectID string, from string) error
 	
 	// Audit operations
 	RecordAuditLog(log *AuditLog) error
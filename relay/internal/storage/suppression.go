@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ListSuppressions retrieves a project's suppressed addresses, newest first.
+// A non-empty search only returns addresses containing it.
+func (s *PostgreSQLStorage) ListSuppressions(projectID, search string, limit, offset int) ([]*Suppression, int, error) {
+	where := `WHERE project_id = $1 AND ($2 = '' OR address LIKE '%' || lower($2) || '%')`
+	
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM suppressions `+where, projectID, search).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count suppressions: %w", err)
+	}
+	
+	query := `
+		SELECT id, project_id, address, reason, source, email_id, created_at, updated_at
+		FROM suppressions ` + where + `
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	
+	rows, err := s.db.Query(query, projectID, search, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list suppressions: %w", err)
+	}
+	defer rows.Close()
+	
+	suppressions := []*Suppression{}
+	for rows.Next() {
+		suppression := &Suppression{}
+		err := rows.Scan(&suppression.ID, &suppression.ProjectID, &suppression.Address, &suppression.Reason,
+			&suppression.Source, &suppression.EmailID, &suppression.CreatedAt, &suppression.UpdatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan suppression: %w", err)
+		}
+		suppressions = append(suppressions, suppression)
+	}
+	
+	return suppressions, total, nil
+}
+
+// FindSuppressions returns the entries of a project's suppression list that
+// match any of the given addresses (case-insensitive)
+func (s *PostgreSQLStorage) FindSuppressions(projectID string, addresses []string) ([]*Suppression, error) {
+	if len(addresses) == 0 {
+		return []*Suppression{}, nil
+	}
+	
+	lowered := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		lowered = append(lowered, strings.ToLower(address))
+	}
+	
+	query := `
+		SELECT id, project_id, address, reason, source, email_id, created_at, updated_at
+		FROM suppressions
+		WHERE project_id = $1 AND address = ANY($2::text[])
+	`
+	
+	rows, err := s.db.Query(query, projectID, fmt.Sprintf("{%s}", joinStrings(lowered, ",")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find suppressions: %w", err)
+	}
+	defer rows.Close()
+	
+	suppressions := []*Suppression{}
+	for rows.Next() {
+		suppression := &Suppression{}
+		err := rows.Scan(&suppression.ID, &suppression.ProjectID, &suppression.Address, &suppression.Reason,
+			&suppression.Source, &suppression.EmailID, &suppression.CreatedAt, &suppression.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan suppression: %w", err)
+		}
+		suppressions = append(suppressions, suppression)
+	}
+	
+	return suppressions, nil
+}
+
+// AddSuppression adds an address to a project's suppression list. An address
+// that is already suppressed keeps its ID and creation time but takes the new
+// reason and source, except that an unsubscribe never replaces an existing
+// entry (such as a hard bounce or complaint). suppression is filled in with
+// the stored values.
+func (s *PostgreSQLStorage) AddSuppression(suppression *Suppression) error {
+	suppression.Address = strings.ToLower(suppression.Address)
+	
+	query := `
+		INSERT INTO suppressions (id, project_id, address, reason, source, email_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (project_id, address) DO UPDATE
+		SET reason = CASE WHEN EXCLUDED.source = $8 THEN suppressions.reason ELSE EXCLUDED.reason END,
+		    source = CASE WHEN EXCLUDED.source = $8 THEN suppressions.source ELSE EXCLUDED.source END,
+		    email_id = CASE WHEN EXCLUDED.source = $8 THEN suppressions.email_id ELSE EXCLUDED.email_id END,
+		    updated_at = EXCLUDED.updated_at
+		RETURNING id, reason, source, email_id, created_at, updated_at
+	`
+	
+	err := s.db.QueryRow(query, suppression.ID, suppression.ProjectID, suppression.Address, suppression.Reason,
+		suppression.Source, suppression.EmailID, suppression.CreatedAt, SuppressionSourceUnsubscribe).Scan(
+		&suppression.ID, &suppression.Reason, &suppression.Source, &suppression.EmailID, &suppression.CreatedAt, &suppression.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add suppression: %w", err)
+	}
+	
+	return nil
+}
+
+// DeleteSuppression removes an entry from a project's suppression list
+func (s *PostgreSQLStorage) DeleteSuppression(projectID, suppressionID string) error {
+	result, err := s.db.Exec(`DELETE FROM suppressions WHERE id = $1 AND project_id = $2`, suppressionID, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete suppression: %w", err)
+	}
+	
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("suppression not found: %s", suppressionID)
+	}
+	
+	return nil
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// RecordEmailOpen stores an open of an email and updates its open counters.
+// OpenedAt is only set by the first open. It returns the email's open count
+// including this one, so 1 means this was the first open.
+func (s *PostgreSQLStorage) RecordEmailOpen(open *EmailOpen) (int, error) {
+	query := `
+		UPDATE emails
+		SET open_count = open_count + 1,
+		    proxy_open_count = proxy_open_count + CASE WHEN $2 <> '' THEN 1 ELSE 0 END,
+		    opened_at = COALESCE(opened_at, $3)
+		WHERE id = $1
+		RETURNING open_count, project_id
+	`
+	
+	var openCount int
+	err := s.db.QueryRow(query, open.EmailID, open.Proxy, open.CreatedAt).Scan(&openCount, &open.ProjectID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return 0, fmt.Errorf("email not found")
+		}
+		return 0, fmt.Errorf("failed to record email open: %w", err)
+	}
+	
+	_, err = s.db.Exec(`
+		INSERT INTO email_opens (id, email_id, project_id, user_agent, ip_address, proxy, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, open.ID, open.EmailID, open.ProjectID, open.UserAgent, open.IPAddress, open.Proxy, open.CreatedAt)
+	if err != nil {
+		return openCount, fmt.Errorf("failed to store email open: %w", err)
+	}
+	
+	return openCount, nil
+}
+
+// ListEmailOpens retrieves the most recent opens of an email, newest first
+func (s *PostgreSQLStorage) ListEmailOpens(emailID string, limit int) ([]*EmailOpen, error) {
+	query := `
+		SELECT id, email_id, project_id, user_agent, ip_address, proxy, created_at
+		FROM email_opens
+		WHERE email_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+	
+	rows, err := s.db.Query(query, emailID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email opens: %w", err)
+	}
+	defer rows.Close()
+	
+	opens := []*EmailOpen{}
+	for rows.Next() {
+		open := &EmailOpen{}
+		err := rows.Scan(&open.ID, &open.EmailID, &open.ProjectID, &open.UserAgent, &open.IPAddress,
+			&open.Proxy, &open.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email open: %w", err)
+		}
+		opens = append(opens, open)
+	}
+	
+	return opens, nil
+}
+
+// RecordEmailClick stores a click of a tracked link and updates the email's
+// click counter. ClickedAt is only set by the first click. It returns the
+// email's click count including this one.
+func (s *PostgreSQLStorage) RecordEmailClick(click *EmailClick) (int, error) {
+	query := `
+		UPDATE emails
+		SET click_count = click_count + 1, clicked_at = COALESCE(clicked_at, $2)
+		WHERE id = $1
+		RETURNING click_count, project_id
+	`
+	
+	var clickCount int
+	err := s.db.QueryRow(query, click.EmailID, click.CreatedAt).Scan(&clickCount, &click.ProjectID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return 0, fmt.Errorf("email not found")
+		}
+		return 0, fmt.Errorf("failed to record email click: %w", err)
+	}
+	
+	_, err = s.db.Exec(`
+		INSERT INTO email_clicks (id, email_id, project_id, url, user_agent, ip_address, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, click.ID, click.EmailID, click.ProjectID, click.URL, click.UserAgent, click.IPAddress, click.CreatedAt)
+	if err != nil {
+		return clickCount, fmt.Errorf("failed to store email click: %w", err)
+	}
+	
+	return clickCount, nil
+}
+
+// ListEmailLinkStats retrieves the click stats of each clicked link in an email, most clicked first
+func (s *PostgreSQLStorage) ListEmailLinkStats(emailID string) ([]*LinkClickStats, error) {
+	query := `
+		SELECT url, COUNT(*), COUNT(DISTINCT email_id), MIN(created_at), MAX(created_at)
+		FROM email_clicks
+		WHERE email_id = $1
+		GROUP BY url
+		ORDER BY COUNT(*) DESC, url ASC
+	`
+	
+	rows, err := s.db.Query(query, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email link stats: %w", err)
+	}
+	defer rows.Close()
+	
+	return scanLinkStats(rows)
+}
+
+// ListProjectLinkStats retrieves the click stats of each link clicked in a
+// project's emails, most clicked first, with the number of distinct links
+func (s *PostgreSQLStorage) ListProjectLinkStats(projectID string, limit, offset int) ([]*LinkClickStats, int, error) {
+	var totalCount int
+	err := s.db.QueryRow(`SELECT COUNT(DISTINCT url) FROM email_clicks WHERE project_id = $1`, projectID).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count project links: %w", err)
+	}
+	
+	query := `
+		SELECT url, COUNT(*), COUNT(DISTINCT email_id), MIN(created_at), MAX(created_at)
+		FROM email_clicks
+		WHERE project_id = $1
+		GROUP BY url
+		ORDER BY COUNT(*) DESC, url ASC
+		LIMIT $2 OFFSET $3
+	`
+	
+	rows, err := s.db.Query(query, projectID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list project link stats: %w", err)
+	}
+	defer rows.Close()
+	
+	stats, err := scanLinkStats(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return stats, totalCount, nil
+}
+
+// scanLinkStats scans rows of url, clicks, emails, first and last click
+func scanLinkStats(rows *sql.Rows) ([]*LinkClickStats, error) {
+	stats := []*LinkClickStats{}
+	for rows.Next() {
+		link := &LinkClickStats{}
+		if err := rows.Scan(&link.URL, &link.Clicks, &link.Emails, &link.FirstClickedAt, &link.LastClickedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan link stats: %w", err)
+		}
+		stats = append(stats, link)
+	}
+	return stats, nil
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"fmt"
+)
+
+// ListUpstreams retrieves a project's upstream relays in priority order
+func (s *PostgreSQLStorage) ListUpstreams(projectID string) ([]*Upstream, error) {
+	query := `
+		SELECT id, project_id, host, port, username, password_enc, priority, weight, enabled, created_at
+		FROM project_upstreams
+		WHERE project_id = $1
+		ORDER BY priority ASC, created_at ASC
+	`
+	
+	rows, err := s.db.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upstreams: %w", err)
+	}
+	defer rows.Close()
+	
+	upstreams := []*Upstream{}
+	for rows.Next() {
+		u := &Upstream{}
+		err := rows.Scan(&u.ID, &u.ProjectID, &u.Host, &u.Port, &u.Username, &u.PasswordEnc,
+			&u.Priority, &u.Weight, &u.Enabled, &u.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan upstream: %w", err)
+		}
+		upstreams = append(upstreams, u)
+	}
+	
+	return upstreams, nil
+}
+
+// AddUpstream adds an upstream relay to a project
+func (s *PostgreSQLStorage) AddUpstream(upstream *Upstream) error {
+	query := `
+		INSERT INTO project_upstreams (id, project_id, host, port, username, password_enc, priority, weight, enabled, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	
+	_, err := s.db.Exec(query, upstream.ID, upstream.ProjectID, upstream.Host, upstream.Port,
+		upstream.Username, upstream.PasswordEnc, upstream.Priority, upstream.Weight,
+		upstream.Enabled, upstream.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add upstream: %w", err)
+	}
+	
+	return nil
+}
+
+// UpdateUpstream updates an upstream relay's settings
+func (s *PostgreSQLStorage) UpdateUpstream(upstream *Upstream) error {
+	query := `
+		UPDATE project_upstreams
+		SET host = $1, port = $2, username = $3, password_enc = $4, priority = $5, weight = $6, enabled = $7
+		WHERE id = $8 AND project_id = $9
+	`
+	
+	result, err := s.db.Exec(query, upstream.Host, upstream.Port, upstream.Username, upstream.PasswordEnc,
+		upstream.Priority, upstream.Weight, upstream.Enabled, upstream.ID, upstream.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to update upstream: %w", err)
+	}
+	
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("upstream not found: %s", upstream.ID)
+	}
+	
+	return nil
+}
+
+// DeleteUpstream removes an upstream relay from a project
+func (s *PostgreSQLStorage) DeleteUpstream(projectID, upstreamID string) error {
+	result, err := s.db.Exec(`DELETE FROM project_upstreams WHERE id = $1 AND project_id = $2`, upstreamID, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete upstream: %w", err)
+	}
+	
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("upstream not found: %s", upstreamID)
+	}
+	
+	return nil
+}
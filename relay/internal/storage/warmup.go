@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// warmupPlanColumns lists the columns selected for a WarmupPlan, in scanWarmupPlan order
+const warmupPlanColumns = `id, project_id, domain, start_daily, days, started_at, created_at`
+
+// scanWarmupPlan scans a row selected with warmupPlanColumns into a WarmupPlan
+func scanWarmupPlan(row rowScanner) (*WarmupPlan, error) {
+	plan := &WarmupPlan{}
+	err := row.Scan(&plan.ID, &plan.ProjectID, &plan.Domain, &plan.StartDaily, &plan.Days,
+		&plan.StartedAt, &plan.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Allowance returns the daily and per-minute allowance on the given day of
+// the plan (0-based). The daily allowance grows geometrically from
+// StartDaily to quotaDaily, which applies in full once the plan is over; the
+// per-minute allowance is scaled down in the same proportion.
+func (p *WarmupPlan) Allowance(day int, quotaDaily, quotaPerMinute int) (int, int) {
+	if day >= p.Days || p.StartDaily >= quotaDaily {
+		return quotaDaily, quotaPerMinute
+	}
+	if day < 0 {
+		day = 0
+	}
+	
+	ratio := float64(quotaDaily) / float64(p.StartDaily)
+	daily := int(math.Round(float64(p.StartDaily) * math.Pow(ratio, float64(day)/float64(p.Days))))
+	if daily > quotaDaily {
+		daily = quotaDaily
+	}
+	
+	perMinute := int(math.Ceil(float64(quotaPerMinute) * float64(daily) / float64(quotaDaily)))
+	if perMinute < 1 {
+		perMinute = 1
+	}
+	return daily, perMinute
+}
+
+// Progress returns where the plan is in its ramp at now, without usage
+func (p *WarmupPlan) Progress(now time.Time, quotaDaily, quotaPerMinute int) *WarmupProgress {
+	day := int(now.Sub(p.StartedAt) / (24 * time.Hour))
+	daily, perMinute := p.Allowance(day, quotaDaily, quotaPerMinute)
+	return &WarmupProgress{
+		PlanID:          p.ID,
+		Domain:          p.Domain,
+		Day:             day + 1,
+		Days:            p.Days,
+		Complete:        day >= p.Days,
+		DailyAllowance:  daily,
+		MinuteAllowance: perMinute,
+		EndsAt:          p.StartedAt.Add(time.Duration(p.Days) * 24 * time.Hour),
+	}
+}
+
+// ListWarmupPlans retrieves a project's warm-up plans, the whole-project plan first
+func (s *PostgreSQLStorage) ListWarmupPlans(projectID string) ([]*WarmupPlan, error) {
+	query := `SELECT ` + warmupPlanColumns + ` FROM warmup_plans WHERE project_id = $1 ORDER BY domain ASC`
+	
+	rows, err := s.db.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list warm-up plans: %w", err)
+	}
+	defer rows.Close()
+	
+	plans := []*WarmupPlan{}
+	for rows.Next() {
+		plan, err := scanWarmupPlan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan warm-up plan: %w", err)
+		}
+		plans = append(plans, plan)
+	}
+	
+	return plans, nil
+}
+
+// CreateWarmupPlan adds a warm-up plan to a project. A project has at most one
+// plan for itself and one per sender domain.
+func (s *PostgreSQLStorage) CreateWarmupPlan(plan *WarmupPlan) error {
+	query := `
+		INSERT INTO warmup_plans (id, project_id, domain, start_daily, days, started_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	
+	_, err := s.db.Exec(query, plan.ID, plan.ProjectID, plan.Domain, plan.StartDaily, plan.Days,
+		plan.StartedAt, plan.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create warm-up plan: %w", err)
+	}
+	
+	return nil
+}
+
+// DeleteWarmupPlan removes a warm-up plan from a project
+func (s *PostgreSQLStorage) DeleteWarmupPlan(projectID, planID string) error {
+	result, err := s.db.Exec(`DELETE FROM warmup_plans WHERE id = $1 AND project_id = $2`, planID, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete warm-up plan: %w", err)
+	}
+	
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("warm-up plan not found: %s", planID)
+	}
+	
+	return nil
+}
+
+// warmupUsage counts a project's emails from one sender domain in the last
+// day and the last minute
+func (s *PostgreSQLStorage) warmupUsage(projectID, domain string) (int, int, error) {
+	query := `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE sent_at > NOW() - INTERVAL '1 minute')
+		FROM emails
+		WHERE project_id = $1 AND sent_at > NOW() - INTERVAL '24 hours'
+		AND status NOT IN ('scheduled', 'cancelled')
+		AND LOWER(RTRIM(SPLIT_PART(from_email, '@', 2), '> ')) = $2
+	`
+	
+	var daily, minute int
+	if err := s.db.QueryRow(query, projectID, domain).Scan(&daily, &minute); err != nil {
+		return 0, 0, fmt.Errorf("failed to get warm-up usage: %w", err)
+	}
+	return daily, minute, nil
+}
+
+// senderDomain returns the lowercased domain of a sender address, which may
+// include a display name
+func senderDomain(from string) string {
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		return strings.ToLower(strings.TrimRight(from[at+1:], "> "))
+	}
+	return ""
+}
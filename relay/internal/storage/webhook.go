@@ -0,0 +1,293 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// webhookColumns lists the columns selected for a Webhook, in scanWebhook order
+const webhookColumns = `id, project_id, url, secret_enc, events, enabled, consecutive_failures,
+		       (SELECT COUNT(*) FROM webhook_deliveries d WHERE d.webhook_id = webhooks.id AND d.status = 'failed'),
+		       last_success_at, last_failure_at, last_error, created_at`
+
+// scanWebhook scans a row selected with webhookColumns into a Webhook
+func scanWebhook(row rowScanner) (*Webhook, error) {
+	webhook := &Webhook{}
+	var events string
+	err := row.Scan(&webhook.ID, &webhook.ProjectID, &webhook.URL, &webhook.SecretEnc, &events, &webhook.Enabled,
+		&webhook.ConsecutiveFailures, &webhook.FailedDeliveries, &webhook.LastSuccessAt, &webhook.LastFailureAt,
+		&webhook.LastError, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Events = parseArrayString(events)
+	return webhook, nil
+}
+
+// ListWebhooks retrieves the webhook endpoints of a project
+func (s *PostgreSQLStorage) ListWebhooks(projectID string) ([]*Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE project_id = $1 ORDER BY created_at ASC`
+	
+	rows, err := s.db.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+	
+	webhooks := []*Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	
+	return webhooks, nil
+}
+
+// GetWebhook retrieves one webhook endpoint of a project
+func (s *PostgreSQLStorage) GetWebhook(projectID, webhookID string) (*Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND project_id = $2`
+	
+	webhook, err := scanWebhook(s.db.QueryRow(query, webhookID, projectID))
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, fmt.Errorf("webhook not found: %s", webhookID)
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	
+	return webhook, nil
+}
+
+// AddWebhook adds a webhook endpoint to a project
+func (s *PostgreSQLStorage) AddWebhook(webhook *Webhook) error {
+	query := `
+		INSERT INTO webhooks (id, project_id, url, secret_enc, events, enabled, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	
+	_, err := s.db.Exec(query, webhook.ID, webhook.ProjectID, webhook.URL, webhook.SecretEnc,
+		fmt.Sprintf("{%s}", joinStrings(webhook.Events, ",")), webhook.Enabled, webhook.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add webhook: %w", err)
+	}
+	
+	return nil
+}
+
+// UpdateWebhook updates a webhook's URL, secret, event filter and enabled flag.
+// Re-enabling an endpoint clears its failure count.
+func (s *PostgreSQLStorage) UpdateWebhook(webhook *Webhook) error {
+	query := `
+		UPDATE webhooks
+		SET url = $1, secret_enc = $2, events = $3, enabled = $4,
+		    consecutive_failures = CASE WHEN $4 AND NOT enabled THEN 0 ELSE consecutive_failures END
+		WHERE id = $5 AND project_id = $6
+	`
+	
+	result, err := s.db.Exec(query, webhook.URL, webhook.SecretEnc, fmt.Sprintf("{%s}", joinStrings(webhook.Events, ",")),
+		webhook.Enabled, webhook.ID, webhook.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("webhook not found: %s", webhook.ID)
+	}
+	
+	return nil
+}
+
+// DeleteWebhook removes a webhook endpoint and its deliveries
+func (s *PostgreSQLStorage) DeleteWebhook(projectID, webhookID string) error {
+	result, err := s.db.Exec(`DELETE FROM webhooks WHERE id = $1 AND project_id = $2`, webhookID, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("webhook not found: %s", webhookID)
+	}
+	
+	return nil
+}
+
+// RecordWebhookResult tracks the health of an endpoint after a delivery attempt
+func (s *PostgreSQLStorage) RecordWebhookResult(webhookID string, success bool, lastError *string) error {
+	query := `
+		UPDATE webhooks
+		SET consecutive_failures = 0, last_success_at = NOW()
+		WHERE id = $1
+	`
+	args := []interface{}{webhookID}
+	if !success {
+		query = `
+			UPDATE webhooks
+			SET consecutive_failures = consecutive_failures + 1, last_failure_at = NOW(), last_error = $2
+			WHERE id = $1
+		`
+		args = append(args, lastError)
+	}
+	
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to record webhook result: %w", err)
+	}
+	
+	return nil
+}
+
+// webhookDeliveryColumns lists the columns selected for a WebhookDelivery, in scanWebhookDelivery order
+const webhookDeliveryColumns = `id, webhook_id, project_id, event_type, email_id, payload, status, attempts,
+		       response_code, last_error, next_attempt_at, created_at, updated_at, delivered_at`
+
+// scanWebhookDelivery scans a row selected with webhookDeliveryColumns into a WebhookDelivery
+func scanWebhookDelivery(row rowScanner) (*WebhookDelivery, error) {
+	delivery := &WebhookDelivery{}
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.ProjectID, &delivery.EventType, &delivery.EmailID,
+		&delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.ResponseCode, &delivery.LastError,
+		&delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.UpdatedAt, &delivery.DeliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// CreateWebhookDelivery queues an event for a webhook endpoint
+func (s *PostgreSQLStorage) CreateWebhookDelivery(delivery *WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (id, webhook_id, project_id, event_type, email_id, payload, status,
+		                                attempts, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+	`
+	
+	_, err := s.db.Exec(query, delivery.ID, delivery.WebhookID, delivery.ProjectID, delivery.EventType, delivery.EmailID,
+		delivery.Payload, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+	
+	return nil
+}
+
+// ClaimDueWebhookDeliveries returns pending deliveries whose next attempt is
+// due, pushing their next attempt back by lease so that other replicas skip
+// them while they are being sent
+func (s *PostgreSQLStorage) ClaimDueWebhookDeliveries(limit int, lease time.Duration) ([]*WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND w.enabled
+			ORDER BY d.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+	
+	rows, err := s.db.Query(query, limit, int(lease.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	
+	deliveries := []*WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	
+	return deliveries, nil
+}
+
+// UpdateWebhookDelivery stores the outcome of a delivery attempt
+func (s *PostgreSQLStorage) UpdateWebhookDelivery(delivery *WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_code = $3, last_error = $4, next_attempt_at = $5,
+		    delivered_at = $6, updated_at = NOW()
+		WHERE id = $7
+	`
+	
+	_, err := s.db.Exec(query, delivery.Status, delivery.Attempts, delivery.ResponseCode, delivery.LastError,
+		delivery.NextAttemptAt, delivery.DeliveredAt, delivery.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	
+	return nil
+}
+
+// GetWebhookDelivery retrieves one webhook delivery of a project
+func (s *PostgreSQLStorage) GetWebhookDelivery(projectID, deliveryID string) (*WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1 AND project_id = $2`
+	
+	delivery, err := scanWebhookDelivery(s.db.QueryRow(query, deliveryID, projectID))
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, fmt.Errorf("webhook delivery not found: %s", deliveryID)
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	
+	return delivery, nil
+}
+
+// ListWebhookDeliveries retrieves a project's webhook deliveries, newest
+// first, optionally only those of one webhook and/or with one status
+func (s *PostgreSQLStorage) ListWebhookDeliveries(projectID, webhookID, status string, limit, offset int) ([]*WebhookDelivery, int, error) {
+	where := `WHERE project_id = $1 AND ($2 = '' OR webhook_id = $2) AND ($3 = '' OR status = $3)`
+	
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM webhook_deliveries `+where, projectID, webhookID, status).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+	
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries ` + where + `
+		ORDER BY created_at DESC
+		LIMIT $4 OFFSET $5
+	`
+	
+	rows, err := s.db.Query(query, projectID, webhookID, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	
+	deliveries := []*WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	
+	return deliveries, total, nil
+}
+
+// RequeueWebhookDeliveries queues every dead-lettered delivery of a webhook
+// for another round of attempts
+func (s *PostgreSQLStorage) RequeueWebhookDeliveries(projectID, webhookID string) (int64, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+		WHERE project_id = $1 AND webhook_id = $2 AND status = 'failed'
+	`
+	
+	result, err := s.db.Exec(query, projectID, webhookID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue webhook deliveries: %w", err)
+	}
+	
+	requeued, _ := result.RowsAffected()
+	return requeued, nil
+}
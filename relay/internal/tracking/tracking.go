@@ -0,0 +1,172 @@
+package tracking
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"html"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Renespeare/mailpulse/relay/internal/crypto"
+)
+
+// HTTP path prefixes tracking URLs are served under
+const (
+	OpenPath        = "/t/open/"        // open tracking pixel
+	ClickPath       = "/t/click/"       // click tracking redirect
+	UnsubscribePath = "/t/unsubscribe/" // one-click unsubscribe (RFC 8058)
+)
+
+// signatureLength is how many bytes of the HMAC a token carries
+const signatureLength = 16
+
+// Mail provider proxies that fetch images on the reader's behalf
+const (
+	ProxyApple  = "apple"  // Apple Mail Privacy Protection prefetch
+	ProxyGoogle = "google" // Gmail image proxy
+	ProxyYahoo  = "yahoo"  // Yahoo Mail image proxy
+)
+
+// Pixel is a transparent 1x1 GIF
+var Pixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// linkHref matches the href attribute of an <a> tag and its quoted value
+var linkHref = regexp.MustCompile(`(?is)(<a\b[^>]*?\shref\s*=\s*)("[^"]*"|'[^']*')`)
+
+// appleNetwork is Apple's 17.0.0.0/8 block, where Mail Privacy Protection
+// fetches can originate
+var appleNetwork = &net.IPNet{IP: net.IPv4(17, 0, 0, 0), Mask: net.CIDRMask(8, 32)}
+
+// Token signs a payload into a URL-safe token: <payload>.<signature>
+func Token(payload string) string {
+	signature := crypto.SignTrackingToken(payload)[:signatureLength]
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// ParseToken verifies a token and returns its payload
+func ParseToken(token string) (string, bool) {
+	dot := strings.IndexByte(token, '.')
+	if dot < 0 {
+		return "", false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(token[:dot])
+	if err != nil {
+		return "", false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(token[dot+1:])
+	if err != nil {
+		return "", false
+	}
+	
+	expected := crypto.SignTrackingToken(string(payload))[:signatureLength]
+	if !hmac.Equal(signature, expected) {
+		return "", false
+	}
+	return string(payload), true
+}
+
+// OpenURL returns the open tracking pixel URL of an email
+func OpenURL(baseURL, emailID string) string {
+	return strings.TrimRight(baseURL, "/") + OpenPath + Token("open:"+emailID) + ".gif"
+}
+
+// EmailIDFromOpenToken returns the email an open tracking token was issued for
+func EmailIDFromOpenToken(token string) (string, bool) {
+	payload, ok := ParseToken(strings.TrimSuffix(token, ".gif"))
+	if !ok || !strings.HasPrefix(payload, "open:") {
+		return "", false
+	}
+	return strings.TrimPrefix(payload, "open:"), true
+}
+
+// ClickURL returns the click tracking URL that redirects to target. The
+// target is part of the signed token, so only it can be redirected to.
+func ClickURL(baseURL, emailID, target string) string {
+	return strings.TrimRight(baseURL, "/") + ClickPath + Token("click:"+emailID+"\n"+target)
+}
+
+// ParseClickToken returns the email and target URL a click tracking token was issued for
+func ParseClickToken(token string) (string, string, bool) {
+	payload, ok := ParseToken(token)
+	if !ok || !strings.HasPrefix(payload, "click:") {
+		return "", "", false
+	}
+	emailID, target, found := strings.Cut(strings.TrimPrefix(payload, "click:"), "\n")
+	if !found {
+		return "", "", false
+	}
+	return emailID, target, true
+}
+
+// UnsubscribeURL returns the one-click unsubscribe URL of one recipient of an email
+func UnsubscribeURL(baseURL, emailID, recipient string) string {
+	return strings.TrimRight(baseURL, "/") + UnsubscribePath + Token("unsubscribe:"+emailID+"\n"+recipient)
+}
+
+// ParseUnsubscribeToken returns the email and recipient an unsubscribe token was issued for
+func ParseUnsubscribeToken(token string) (string, string, bool) {
+	payload, ok := ParseToken(token)
+	if !ok || !strings.HasPrefix(payload, "unsubscribe:") {
+		return "", "", false
+	}
+	emailID, recipient, found := strings.Cut(strings.TrimPrefix(payload, "unsubscribe:"), "\n")
+	if !found || recipient == "" {
+		return "", "", false
+	}
+	return emailID, recipient, true
+}
+
+// RewriteLinks replaces the absolute http(s) links of an HTML body with
+// tracked(url). Other links (mailto:, anchors, relative) are left alone.
+func RewriteLinks(body string, tracked func(target string) string) string {
+	return linkHref.ReplaceAllStringFunc(body, func(match string) string {
+		parts := linkHref.FindStringSubmatch(match)
+		quoted := parts[2]
+		target := strings.TrimSpace(html.UnescapeString(quoted[1 : len(quoted)-1]))
+		
+		parsed, err := url.Parse(target)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+			strings.ContainsAny(target, "\r\n") {
+			return match
+		}
+		return parts[1] + `"` + html.EscapeString(tracked(target)) + `"`
+	})
+}
+
+// InjectPixel adds an open tracking pixel to an HTML body, just before
+// </body> when there is one and at the end otherwise
+func InjectPixel(html, pixelURL string) string {
+	img := `<img src="` + pixelURL + `" width="1" height="1" alt="" style="display:block;width:1px;height:1px;border:0" />`
+	if end := strings.LastIndex(strings.ToLower(html), "</body>"); end >= 0 {
+		return html[:end] + img + html[end:]
+	}
+	return html + img
+}
+
+// DetectProxy reports which mail provider proxy fetched the pixel, or ""
+// when it looks like the reader's own mail client. Proxy fetches happen when
+// mail is received or prefetched, so they do not prove a human read it.
+func DetectProxy(userAgent, ip string) string {
+	switch {
+	case strings.Contains(userAgent, "GoogleImageProxy"):
+		return ProxyGoogle
+	case strings.Contains(userAgent, "YahooMailProxy"):
+		return ProxyYahoo
+	}
+	
+	// Privacy Protection sends a bare "Mozilla/5.0" user agent
+	if strings.TrimSpace(userAgent) == "Mozilla/5.0" {
+		return ProxyApple
+	}
+	if parsed := net.ParseIP(ip); parsed != nil && appleNetwork.Contains(parsed) {
+		return ProxyApple
+	}
+	return ""
+}
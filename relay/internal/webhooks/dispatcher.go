@@ -0,0 +1,348 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Renespeare/mailpulse/relay/internal/crypto"
+	"github.com/Renespeare/mailpulse/relay/internal/storage"
+)
+
+// Email lifecycle event types
+const (
+	EventAccepted     = "email.accepted"
+	EventDelivered    = "email.delivered"
+	EventDeferred     = "email.deferred"
+	EventFailed       = "email.failed"
+	EventBounced      = "email.bounced"
+	EventComplaint    = "email.complained"
+	EventOpened       = "email.opened"
+	EventClicked      = "email.clicked"
+	EventUnsubscribed = "email.unsubscribed"
+)
+
+// EventTypes lists every event type a webhook can subscribe to
+var EventTypes = []string{
+	EventAccepted, EventDelivered, EventDeferred, EventFailed,
+	EventBounced, EventComplaint, EventOpened, EventClicked, EventUnsubscribed,
+}
+
+// Headers set on every webhook request
+const (
+	SignatureHeader = "X-MailPulse-Signature" // t=<unix time>,v1=<hex HMAC-SHA256>
+	EventHeader     = "X-MailPulse-Event"
+	DeliveryHeader  = "X-MailPulse-Delivery"
+)
+
+// retrySchedule is the wait before each retry; a delivery that fails once
+// more after the last one is dead-lettered
+var retrySchedule = []time.Duration{
+	30 * time.Second, 2 * time.Minute, 10 * time.Minute, 30 * time.Minute,
+	1 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour,
+}
+
+// Deliveries are claimed in batches and sent one at a time, each bounded by
+// deliveryTimeout. The lease hides a claimed batch from other replicas for
+// long enough to send all of it even if every endpoint times out, so no
+// delivery is sent twice.
+const (
+	claimBatch      = 10
+	deliveryTimeout = 10 * time.Second
+	claimLease      = claimBatch*deliveryTimeout + time.Minute
+)
+
+// Event is the JSON body posted to webhook endpoints
+type Event struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	CreatedAt time.Time              `json:"createdAt"`
+	ProjectID string                 `json:"projectId"`
+	Email     *EventEmail            `json:"email,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// EventEmail summarises the email an event is about
+type EventEmail struct {
+	ID        string   `json:"id"`
+	MessageID string   `json:"messageId"`
+	From      string   `json:"from"`
+	To        []string `json:"to"`
+	Subject   string   `json:"subject"`
+}
+
+// Dispatcher queues lifecycle events for the project's webhook endpoints and
+// delivers them in the background, retrying with backoff. Deliveries are
+// stored, so pending retries survive restarts.
+type Dispatcher struct {
+	storage storage.Storage
+	client  *http.Client
+	wake    chan struct{}
+}
+
+// NewDispatcher creates a new webhook dispatcher
+func NewDispatcher(storage storage.Storage) *Dispatcher {
+	return &Dispatcher{
+		storage: storage,
+		client:  &http.Client{Timeout: deliveryTimeout},
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// ValidEventType reports whether eventType is a known event type
+func ValidEventType(eventType string) bool {
+	for _, known := range EventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateSecret returns a new random signing secret
+func GenerateSecret() string {
+	bytes := make([]byte, 24)
+	rand.Read(bytes)
+	return "whsec_" + hex.EncodeToString(bytes)
+}
+
+// Sign computes the signature header value for a request body. Receivers
+// recompute HMAC-SHA256(secret, "<t>.<body>") and compare it with v1.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%s,v1=%s", t, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// Start polls for due deliveries every interval (and whenever an event is
+// emitted) until the process exits
+func (d *Dispatcher) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			d.deliverDue()
+			select {
+			case <-ticker.C:
+			case <-d.wake:
+			}
+		}
+	}()
+}
+
+// Emit queues an event for every enabled webhook of the project subscribed
+// to its type. A nil dispatcher ignores events.
+func (d *Dispatcher) Emit(eventType string, projectID string, email *storage.Email, data map[string]interface{}) {
+	if d == nil {
+		return
+	}
+	
+	webhooks, err := d.storage.ListWebhooks(projectID)
+	if err != nil {
+		log.Printf("⚠️  Failed to list webhooks for project %s: %v", projectID, err)
+		return
+	}
+	
+	event := &Event{
+		ID:        newID("evt_"),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		ProjectID: projectID,
+		Data:      data,
+	}
+	var emailID *string
+	if email != nil {
+		event.Email = &EventEmail{
+			ID:        email.ID,
+			MessageID: email.MessageID,
+			From:      email.From,
+			To:        email.To,
+			Subject:   email.Subject,
+		}
+		emailID = &email.ID
+	}
+	
+	var payload []byte
+	queued := 0
+	for _, webhook := range webhooks {
+		if !webhook.Enabled || !subscribed(webhook, eventType) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				log.Printf("⚠️  Failed to encode %s event: %v", eventType, err)
+				return
+			}
+		}
+		
+		delivery := &storage.WebhookDelivery{
+			ID:            newID("whd_"),
+			WebhookID:     webhook.ID,
+			ProjectID:     projectID,
+			EventType:     eventType,
+			EmailID:       emailID,
+			Payload:       payload,
+			Status:        storage.WebhookDeliveryPending,
+			NextAttemptAt: time.Now(),
+			CreatedAt:     time.Now(),
+		}
+		if err := d.storage.CreateWebhookDelivery(delivery); err != nil {
+			log.Printf("⚠️  Failed to queue %s event for webhook %s: %v", eventType, webhook.ID, err)
+			continue
+		}
+		queued++
+	}
+	
+	if queued > 0 {
+		d.Wake()
+	}
+}
+
+// Wake makes the delivery loop check for due deliveries now
+func (d *Dispatcher) Wake() {
+	if d == nil {
+		return
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// subscribed reports whether a webhook wants events of eventType
+func subscribed(webhook *storage.Webhook, eventType string) bool {
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, event := range webhook.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// deliverDue sends every delivery whose next attempt is due
+func (d *Dispatcher) deliverDue() {
+	for {
+		deliveries, err := d.storage.ClaimDueWebhookDeliveries(claimBatch, claimLease)
+		if err != nil {
+			log.Printf("⚠️  Failed to load due webhook deliveries: %v", err)
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+		
+		for _, delivery := range deliveries {
+			d.attempt(delivery)
+		}
+	}
+}
+
+// attempt makes one delivery attempt and schedules a retry or dead-letters
+// the delivery when it fails
+func (d *Dispatcher) attempt(delivery *storage.WebhookDelivery) {
+	// Deliveries of a deleted webhook are deleted with it and never claimed, so
+	// an error here is storage trouble - the lease expires and the delivery is retried
+	webhook, err := d.storage.GetWebhook(delivery.ProjectID, delivery.WebhookID)
+	if err != nil {
+		log.Printf("⚠️  Failed to load webhook %s of delivery %s: %v", delivery.WebhookID, delivery.ID, err)
+		return
+	}
+	
+	code, err := d.send(webhook, delivery)
+	delivery.Attempts++
+	delivery.ResponseCode = nil
+	if code > 0 {
+		delivery.ResponseCode = &code
+	}
+	
+	if err == nil {
+		now := time.Now()
+		delivery.Status = storage.WebhookDeliveryDelivered
+		delivery.LastError = nil
+		delivery.DeliveredAt = &now
+		log.Printf("✅ Webhook %s event delivered to %s", delivery.EventType, webhook.URL)
+	} else {
+		errorMsg := err.Error()
+		delivery.LastError = &errorMsg
+		if delivery.Attempts > len(retrySchedule) {
+			delivery.Status = storage.WebhookDeliveryFailed
+			log.Printf("❌ Webhook %s event to %s dead-lettered after %d attempts: %v", delivery.EventType, webhook.URL, delivery.Attempts, err)
+		} else {
+			delivery.NextAttemptAt = time.Now().Add(retrySchedule[delivery.Attempts-1])
+			log.Printf("⏳ Webhook %s event to %s failed (attempt %d), retrying at %s: %v", delivery.EventType, webhook.URL,
+				delivery.Attempts, delivery.NextAttemptAt.Format(time.RFC3339), err)
+		}
+	}
+	
+	if err := d.storage.UpdateWebhookDelivery(delivery); err != nil {
+		log.Printf("⚠️  Failed to record webhook delivery %s: %v", delivery.ID, err)
+	}
+	if err := d.storage.RecordWebhookResult(webhook.ID, delivery.Status == storage.WebhookDeliveryDelivered, delivery.LastError); err != nil {
+		log.Printf("⚠️  Failed to record health of webhook %s: %v", webhook.ID, err)
+	}
+}
+
+// send posts a delivery's payload to its endpoint. Any 2xx response is success.
+func (d *Dispatcher) send(webhook *storage.Webhook, delivery *storage.WebhookDelivery) (int, error) {
+	secret, err := crypto.DecryptWebhookSecret(webhook.SecretEnc)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decrypt webhook secret: %w", err)
+	}
+	
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "MailPulse-Webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(secret, time.Now(), delivery.Payload))
+	
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Redeliver queues a delivery (typically a dead letter) for a fresh round of attempts
+func (d *Dispatcher) Redeliver(delivery *storage.WebhookDelivery) error {
+	delivery.Status = storage.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.DeliveredAt = nil
+	if err := d.storage.UpdateWebhookDelivery(delivery); err != nil {
+		return err
+	}
+	
+	d.Wake()
+	return nil
+}
+
+// newID generates a random ID with the given prefix
+func newID(prefix string) string {
+	bytes := make([]byte, 12)
+	rand.Read(bytes)
+	return prefix + hex.EncodeToString(bytes)
+}